@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultFailures = 5
+	defaultCooldown = 30 * time.Second
+)
+
+// ErrUnavailable é retornado pelo Breaker enquanto o cache está desligado por falhas.
+var ErrUnavailable = errors.New("cache: indisponível")
+
+// Breaker protege as requisições de um cache fora do ar. Depois de Failures falhas seguidas, as
+// operações retornam ErrUnavailable sem chamar o cache durante Cooldown; passado esse tempo, uma única
+// operação testa o cache e, se funcionar, ele volta a ser usado.
+type Breaker struct {
+	Cache    Cache
+	Failures int
+	Cooldown time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+	now       func() time.Time
+}
+
+func NewBreaker(c Cache, failures int, cooldown time.Duration) *Breaker {
+	if failures <= 0 {
+		failures = defaultFailures
+	}
+	if cooldown <= 0 {
+		cooldown = defaultCooldown
+	}
+	return &Breaker{Cache: c, Failures: failures, Cooldown: cooldown, now: time.Now}
+}
+
+// NewRedisBreaker cria o Breaker do Redis configurado por CACHE_BREAKER_FAILURES e
+// CACHE_BREAKER_COOLDOWN, ou nil sem cliente. Além do cache, outros usos do mesmo Redis podem passar
+// pelo Breaker com Do, para que uma queda desligue todos de uma vez.
+func NewRedisBreaker(client *redis.Client) *Breaker {
+	if client == nil {
+		return nil
+	}
+	return NewBreaker(NewRedis(client), envInt("CACHE_BREAKER_FAILURES", defaultFailures),
+		envDuration("CACHE_BREAKER_COOLDOWN", defaultCooldown))
+}
+
+// Open informa se o cache está desligado por falhas.
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openUntil.IsZero()
+}
+
+func (b *Breaker) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := b.do(ctx, func() (err error) {
+		value, err = b.Cache.Get(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (b *Breaker) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return b.do(ctx, func() error { return b.Cache.Set(ctx, key, value, ttl) })
+}
+
+func (b *Breaker) Incr(ctx context.Context, key string) (int64, error) {
+	var value int64
+	err := b.do(ctx, func() (err error) {
+		value, err = b.Cache.Incr(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (b *Breaker) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	var unlock func()
+	var ok bool
+	err := b.do(ctx, func() (err error) {
+		unlock, ok, err = b.Cache.Lock(ctx, key, ttl)
+		return err
+	})
+	return unlock, ok, err
+}
+
+// Do executa fn, que acessa o cache por fora da interface Cache, sob o mesmo controle de falhas das
+// outras operações. Aberto, retorna ErrUnavailable sem chamar fn; para não contar como falha, fn deve
+// retornar ErrMiss quando a chave não existir.
+func (b *Breaker) Do(ctx context.Context, fn func() error) error {
+	return b.do(ctx, fn)
+}
+
+func (b *Breaker) do(ctx context.Context, fn func() error) error {
+	if !b.allow() {
+		return ErrUnavailable
+	}
+	err := fn()
+	// Um miss é uma resposta válida, e o cancelamento da requisição não diz nada sobre o cache. Já o
+	// prazo esgotado conta como falha: é assim que aparece um Redis que parou de responder.
+	if err != nil && !errors.Is(err, ErrMiss) && !errors.Is(ctx.Err(), context.Canceled) {
+		b.failure(err)
+	} else {
+		b.success()
+	}
+	return err
+}
+
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if b.probing || b.clock().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *Breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.openUntil.IsZero() && b.probing {
+		log.Printf("Cache disponível novamente")
+	}
+	if b.probing || b.openUntil.IsZero() {
+		b.failures, b.openUntil, b.probing = 0, time.Time{}, false
+	}
+}
+
+func (b *Breaker) failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.probing || (b.openUntil.IsZero() && b.failures >= b.Failures) {
+		if !b.probing {
+			log.Printf("Cache desligado por %s depois de %d falhas: %v", b.Cooldown, b.failures, err)
+		}
+		b.openUntil, b.probing = b.clock().Add(b.Cooldown), false
+	}
+}
+
+func (b *Breaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
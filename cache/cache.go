@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrMiss indica que a chave não está no cache.
+var ErrMiss = errors.New("cache: chave não encontrada")
+
+// Cache guarda valores temporários. Como é só um cache, quem o usa deve seguir sem ele quando uma
+// operação falhar.
+type Cache interface {
+	// Get retorna o valor da chave, ou ErrMiss.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set grava o valor da chave. Com ttl zero, o valor não expira.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Incr incrementa o contador da chave, criando-o com 1. Lido com Get, o contador vem em decimal.
+	Incr(ctx context.Context, key string) (int64, error)
+	// Lock obtém o bloqueio da chave por ttl, se ninguém o tiver. A função retornada libera o bloqueio
+	// e deve ser chamada só quando ele foi obtido.
+	Lock(ctx context.Context, key string, ttl time.Duration) (unlock func(), ok bool, err error)
+}
+
+// NewFromEnv cria o cache configurado em CACHE_DRIVER: "redis" (o padrão quando há cliente), "tiered",
+// "memory" ou "none". O Redis fica atrás de um Breaker, para que uma queda não atrase as requisições;
+// sem cliente, o cache é mantido em memória. O "tiered" guarda as leituras também em memória, por até
+// CACHE_LOCAL_TTL, e precisa que Tiered.Run esteja rodando para receber as invalidações. O breaker,
+// quando informado, é usado no lugar de um novo, para compartilhar o estado do Redis com outros usos.
+func NewFromEnv(client *redis.Client, breaker *Breaker) Cache {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("CACHE_DRIVER")))
+	if driver == "" {
+		driver = "redis"
+	}
+
+	switch driver {
+	case "redis", "tiered":
+		if client == nil {
+			log.Printf("Redis não configurado, usando o cache em memória")
+			break
+		}
+		if breaker == nil {
+			breaker = NewRedisBreaker(client)
+		}
+		if driver == "tiered" {
+			return NewTiered(NewMemory(envInt("CACHE_MEMORY_ITEMS", defaultMemoryItems)), breaker, client,
+				envDuration("CACHE_LOCAL_TTL", defaultLocalTTL))
+		}
+		return breaker
+	case "memory":
+	case "none":
+		return Noop{}
+	default:
+		log.Printf("Cache desconhecido em CACHE_DRIVER: %q, usando o cache em memória", driver)
+	}
+	return NewMemory(envInt("CACHE_MEMORY_ITEMS", defaultMemoryItems))
+}
+
+func envInt(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
+
+// Noop não guarda nada: toda leitura é um miss e todo bloqueio é obtido.
+type Noop struct{}
+
+func (Noop) Get(context.Context, string) ([]byte, error) { return nil, ErrMiss }
+
+func (Noop) Set(context.Context, string, []byte, time.Duration) error { return nil }
+
+func (Noop) Incr(context.Context, string) (int64, error) { return 0, nil }
+
+func (Noop) Lock(context.Context, string, time.Duration) (func(), bool, error) {
+	return func() {}, true, nil
+}
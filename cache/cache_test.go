@@ -0,0 +1,333 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryExpiracao(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(10)
+	agora := time.Now()
+	m.now = func() time.Time { return agora }
+
+	require.NoError(t, m.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, m.Set(ctx, "b", []byte("2"), 0))
+
+	agora = agora.Add(time.Minute)
+	_, err := m.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+	value, err := m.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("2"), value)
+	assert.Equal(t, 1, m.Len())
+}
+
+func TestMemoryDescartaMenosUsadas(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+
+	require.NoError(t, m.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, m.Set(ctx, "b", []byte("2"), 0))
+	_, err := m.Get(ctx, "a")
+	require.NoError(t, err)
+	require.NoError(t, m.Set(ctx, "c", []byte("3"), 0))
+
+	_, err = m.Get(ctx, "b")
+	assert.ErrorIs(t, err, ErrMiss)
+	for _, key := range []string{"a", "c"} {
+		_, err := m.Get(ctx, key)
+		assert.NoError(t, err, key)
+	}
+}
+
+func TestMemoryCopiaValores(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(10)
+
+	original := []byte("livros")
+	require.NoError(t, m.Set(ctx, "a", original, 0))
+	original[0] = 'L'
+
+	value, err := m.Get(ctx, "a")
+	require.NoError(t, err)
+	value[1] = 'I'
+
+	value, err = m.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("livros"), value)
+}
+
+func TestMemoryIncrMantemExpiracao(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(10)
+	agora := time.Now()
+	m.now = func() time.Time { return agora }
+
+	require.NoError(t, m.Set(ctx, "versao", []byte("4"), time.Minute))
+	value, err := m.Incr(ctx, "versao")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), value)
+
+	agora = agora.Add(time.Minute)
+	value, err = m.Incr(ctx, "versao")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), value)
+
+	data, err := m.Get(ctx, "versao")
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(data))
+}
+
+func TestMemoryLock(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(10)
+	agora := time.Now()
+	m.now = func() time.Time { return agora }
+
+	unlock, ok, err := m.Lock(ctx, "bloqueio", time.Second)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, ok, err = m.Lock(ctx, "bloqueio", time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	unlock()
+	_, ok, err = m.Lock(ctx, "bloqueio", time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// O bloqueio expira, e a liberação atrasada não remove o de quem o obteve depois.
+	agora = agora.Add(time.Second)
+	_, ok, err = m.Lock(ctx, "bloqueio", time.Second)
+	require.NoError(t, err)
+	require.True(t, ok)
+	unlock()
+	_, ok, err = m.Lock(ctx, "bloqueio", time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	r := NewRedis(client)
+
+	_, err := r.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	require.NoError(t, r.Set(ctx, "a", []byte("1"), time.Minute))
+	value, err := r.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+	assert.Equal(t, time.Minute, mr.TTL("a"))
+
+	_, err = r.Incr(ctx, "versao")
+	require.NoError(t, err)
+	n, err := r.Incr(ctx, "versao")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	value, err = r.Get(ctx, "versao")
+	require.NoError(t, err)
+	assert.Equal(t, "2", string(value))
+
+	unlock, ok, err := r.Lock(ctx, "bloqueio", time.Second)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, ok, err = r.Lock(ctx, "bloqueio", time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	mr.FastForward(time.Second)
+	_, ok, err = r.Lock(ctx, "bloqueio", time.Second)
+	require.NoError(t, err)
+	require.True(t, ok)
+	unlock()
+	assert.True(t, mr.Exists("bloqueio"))
+}
+
+func TestNoop(t *testing.T) {
+	ctx := context.Background()
+	var c Cache = Noop{}
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), 0))
+	_, err := c.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+	_, ok, err := c.Lock(ctx, "a", time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// cacheFalho falha enquanto err estiver definido e conta as chamadas recebidas.
+type cacheFalho struct {
+	Noop
+	err      error
+	chamadas int
+}
+
+func (c *cacheFalho) Get(context.Context, string) ([]byte, error) {
+	c.chamadas++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return nil, ErrMiss
+}
+
+func TestBreaker(t *testing.T) {
+	ctx := context.Background()
+	falho := &cacheFalho{err: errors.New("fora do ar")}
+	b := NewBreaker(falho, 2, time.Minute)
+	agora := time.Now()
+	b.now = func() time.Time { return agora }
+
+	for i := 0; i < 2; i++ {
+		_, err := b.Get(ctx, "a")
+		assert.EqualError(t, err, "fora do ar")
+	}
+	assert.True(t, b.Open())
+
+	// Aberto, não chama o cache.
+	_, err := b.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 2, falho.chamadas)
+
+	// Passada a pausa, uma falha no teste desliga o cache de novo.
+	agora = agora.Add(time.Minute)
+	_, err = b.Get(ctx, "a")
+	assert.EqualError(t, err, "fora do ar")
+	_, err = b.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 3, falho.chamadas)
+
+	// Um teste bem-sucedido religa o cache; miss não é falha.
+	falho.err = nil
+	agora = agora.Add(time.Minute)
+	_, err = b.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+	assert.False(t, b.Open())
+	_, err = b.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+	assert.Equal(t, 5, falho.chamadas)
+}
+
+func TestBreakerIgnoraCancelamento(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := NewBreaker(&cacheFalho{err: context.Canceled}, 1, time.Minute)
+
+	_, err := b.Get(ctx, "a")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, b.Open())
+}
+
+func TestNewFromEnv(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	t.Cleanup(func() { client.Close() })
+
+	tests := []struct {
+		driver string
+		client *redis.Client
+		want   Cache
+	}{
+		{"", client, &Breaker{}},
+		{"redis", client, &Breaker{}},
+		{"", nil, &Memory{}},
+		{"memory", client, &Memory{}},
+		{"none", client, Noop{}},
+		{"tiered", client, &Tiered{}},
+		{"tiered", nil, &Memory{}},
+		{"desconhecido", client, &Memory{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver+"/"+strconv.FormatBool(tt.client != nil), func(t *testing.T) {
+			t.Setenv("CACHE_DRIVER", tt.driver)
+			assert.IsType(t, tt.want, NewFromEnv(tt.client, nil))
+		})
+	}
+}
+
+// newTiered monta uma instância com cache em memória sobre o Redis falso, recebendo as invalidações
+// até o fim do teste.
+func newTiered(t *testing.T, mr *miniredis.Miniredis) *Tiered {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	tiered := NewTiered(NewMemory(10), NewRedis(client), client, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tiered.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return tiered
+}
+
+func TestTieredInvalidaOutrasInstancias(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	a, b := newTiered(t, mr), newTiered(t, mr)
+	require.Eventually(t, func() bool {
+		return mr.PubSubNumSub(InvalidationChannel)[InvalidationChannel] == 2
+	}, time.Second, 10*time.Millisecond)
+	// A inscrição limpa a memória; esperar por isso evita confundi-la com a invalidação abaixo.
+	require.Eventually(t, func() bool { return b.invalidacoes.Load() > 0 }, time.Second, 10*time.Millisecond)
+
+	// Um valor antigo na memória de b marca a chegada da invalidação: só depois dela a leitura
+	// seguinte pode guardar o valor novo sem que um aviso atrasado o remova.
+	require.NoError(t, b.Local.Set(ctx, "versao", []byte("0"), time.Minute))
+	_, err := a.Incr(ctx, "versao")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return b.Local.Len() == 0 }, time.Second, 10*time.Millisecond)
+	value, err := b.Get(ctx, "versao")
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(value))
+
+	// A leitura seguinte vem da memória, mesmo com o Redis alterado por fora.
+	require.NoError(t, mr.Set("versao", "7"))
+	value, err = b.Get(ctx, "versao")
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(value))
+
+	_, err = a.Incr(ctx, "versao")
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		value, err := b.Get(ctx, "versao")
+		return err == nil && string(value) == "8"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTieredSet(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	tiered := newTiered(t, mr)
+
+	require.NoError(t, tiered.Set(ctx, "a", []byte("1"), time.Hour))
+	assert.Equal(t, time.Hour, mr.TTL("a"))
+
+	mr.Del("a")
+	value, err := tiered.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+
+	// A memória guarda o valor por no máximo LocalTTL.
+	agora := time.Now().Add(time.Minute)
+	tiered.Local.now = func() time.Time { return agora }
+	_, err = tiered.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const defaultMemoryItems = 10000
+
+// Memory guarda o cache no próprio processo, descartando as chaves usadas há mais tempo quando passa
+// de MaxItems. As instâncias da API não compartilham o cache nem as invalidações.
+type Memory struct {
+	MaxItems int
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	// order tem as chaves da usada mais recentemente para a mais antiga.
+	order *list.List
+	now   func() time.Time
+}
+
+type memoryItem struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func NewMemory(maxItems int) *Memory {
+	if maxItems <= 0 {
+		maxItems = defaultMemoryItems
+	}
+	return &Memory{MaxItems: maxItems, items: make(map[string]*list.Element), order: list.New(), now: time.Now}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.get(key)
+	if !ok {
+		return nil, ErrMiss
+	}
+	return append([]byte(nil), item.value...), nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(key, append([]byte(nil), value...), m.expiration(ttl))
+	return nil
+}
+
+// Incr mantém a expiração de um contador existente, como o INCR do Redis.
+func (m *Memory) Incr(_ context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var value int64
+	var expiresAt time.Time
+	if item, ok := m.get(key); ok {
+		current, err := strconv.ParseInt(string(item.value), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		value, expiresAt = current, item.expiresAt
+	}
+	value++
+	m.set(key, []byte(strconv.FormatInt(value, 10)), expiresAt)
+	return value, nil
+}
+
+func (m *Memory) Lock(_ context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.get(key); ok {
+		return nil, false, nil
+	}
+	token := []byte(uuid.NewString())
+	m.set(key, token, m.expiration(ttl))
+
+	unlock := func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if item, ok := m.get(key); ok && string(item.value) == string(token) {
+			m.remove(m.items[key])
+		}
+	}
+	return unlock, true, nil
+}
+
+// Delete remove a chave.
+func (m *Memory) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.items[key]; ok {
+		m.remove(element)
+	}
+}
+
+// Clear remove todas as chaves.
+func (m *Memory) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items = make(map[string]*list.Element)
+	m.order.Init()
+}
+
+// Len retorna quantas chaves estão guardadas, incluindo as expiradas ainda não descartadas.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+func (m *Memory) expiration(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return m.now().Add(ttl)
+}
+
+// get retorna o item da chave e o marca como o usado mais recentemente. Itens expirados são descartados.
+func (m *Memory) get(key string) (*memoryItem, bool) {
+	element, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := element.Value.(*memoryItem)
+	if !item.expiresAt.IsZero() && !m.now().Before(item.expiresAt) {
+		m.remove(element)
+		return nil, false
+	}
+	m.order.MoveToFront(element)
+	return item, true
+}
+
+func (m *Memory) set(key string, value []byte, expiresAt time.Time) {
+	if element, ok := m.items[key]; ok {
+		item := element.Value.(*memoryItem)
+		item.value, item.expiresAt = value, expiresAt
+		m.order.MoveToFront(element)
+		return
+	}
+
+	m.items[key] = m.order.PushFront(&memoryItem{key: key, value: value, expiresAt: expiresAt})
+	for m.order.Len() > m.MaxItems {
+		m.remove(m.order.Back())
+	}
+}
+
+func (m *Memory) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.items, element.Value.(*memoryItem).key)
+}
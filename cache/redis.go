@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// unlockScript remove o bloqueio só se ele ainda pertencer a quem o obteve.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Redis guarda o cache no Redis, compartilhado entre as instâncias da API.
+type Redis struct {
+	Client *redis.Client
+}
+
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{Client: client}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.Client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.Client.Set(ctx, key, value, ttl).Err()
+}
+
+func (r *Redis) Incr(ctx context.Context, key string) (int64, error) {
+	return r.Client.Incr(ctx, key).Result()
+}
+
+// Lock grava na chave do bloqueio um token aleatório, conferido na liberação para não remover o
+// bloqueio de outra instância depois que o nosso expirou.
+func (r *Redis) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	token := uuid.NewString()
+	ok, err := r.Client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	unlock := func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+		defer cancel()
+		if err := unlockScript.Run(ctx, r.Client, []string{key}, token).Err(); err != nil {
+			log.Printf("Erro ao liberar bloqueio %s no Redis: %v", key, err)
+		}
+	}
+	return unlock, true, nil
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultLocalTTL = 30 * time.Second
+	// InvalidationChannel é o canal do Redis em que as instâncias avisam as chaves alteradas.
+	InvalidationChannel = "cache:invalidacoes"
+)
+
+// Tiered guarda as leituras frequentes em memória, na frente de um cache compartilhado. Os contadores
+// incrementados com Incr, como a geração do cache de livros, são avisados às outras instâncias pelo
+// canal do Redis, e cada instância remove a chave da sua memória ao receber o aviso (veja Run).
+//
+// Se um aviso se perder, a instância serve o valor antigo por no máximo LocalTTL.
+type Tiered struct {
+	Local    *Memory
+	Remote   Cache
+	Client   *redis.Client
+	Channel  string
+	LocalTTL time.Duration
+
+	// invalidacoes conta as chaves removidas da memória, para que uma leitura do cache compartilhado
+	// que começou antes de um aviso não grave o valor antigo na memória.
+	invalidacoes atomic.Uint64
+}
+
+func NewTiered(local *Memory, remote Cache, client *redis.Client, localTTL time.Duration) *Tiered {
+	if localTTL <= 0 {
+		localTTL = defaultLocalTTL
+	}
+	return &Tiered{Local: local, Remote: remote, Client: client, Channel: InvalidationChannel, LocalTTL: localTTL}
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, err := t.Local.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	invalidacoes := t.invalidacoes.Load()
+	value, err := t.Remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if t.invalidacoes.Load() == invalidacoes {
+		t.Local.Set(ctx, key, value, t.LocalTTL)
+	}
+	return value, nil
+}
+
+// Set grava no cache compartilhado e na memória. A memória guarda o valor mesmo se o cache
+// compartilhado falhar, e o erro é retornado.
+func (t *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := t.Remote.Set(ctx, key, value, ttl)
+	if ttl <= 0 || ttl > t.LocalTTL {
+		ttl = t.LocalTTL
+	}
+	t.Local.Set(ctx, key, value, ttl)
+	return err
+}
+
+// Incr incrementa o contador no cache compartilhado e avisa as outras instâncias da alteração.
+func (t *Tiered) Incr(ctx context.Context, key string) (int64, error) {
+	value, err := t.Remote.Incr(ctx, key)
+	t.invalidate(key)
+	if err != nil {
+		return 0, err
+	}
+	if t.Client != nil {
+		if err := t.Client.Publish(ctx, t.Channel, key).Err(); err != nil {
+			log.Printf("Erro ao avisar a invalidação de %s: %v", key, err)
+		}
+	}
+	return value, nil
+}
+
+func (t *Tiered) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	return t.Remote.Lock(ctx, key, ttl)
+}
+
+// Run assina o canal de invalidações e remove da memória as chaves avisadas, até ctx ser cancelado.
+// A cada nova assinatura, e quando a conexão cai, a memória é limpa, pois avisos podem ter se perdido.
+func (t *Tiered) Run(ctx context.Context) {
+	if t.Client == nil {
+		return
+	}
+	pubsub := t.Client.Subscribe(ctx, t.Channel)
+	defer pubsub.Close()
+	// A leitura do canal não acompanha o cancelamento de ctx; fechar a assinatura a interrompe.
+	stop := context.AfterFunc(ctx, func() { pubsub.Close() })
+	defer stop()
+
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			t.clear()
+			if !errors.Is(err, redis.ErrClosed) {
+				log.Printf("Erro ao receber invalidações do cache: %v", err)
+			}
+			// O cliente reconecta na próxima leitura.
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			if msg.Kind == "subscribe" {
+				t.clear()
+			}
+		case *redis.Message:
+			t.invalidate(msg.Payload)
+		}
+	}
+}
+
+func (t *Tiered) invalidate(key string) {
+	t.invalidacoes.Add(1)
+	t.Local.Delete(key)
+}
+
+func (t *Tiered) clear() {
+	t.invalidacoes.Add(1)
+	t.Local.Clear()
+}
@@ -45,6 +45,58 @@ func ConnectDatabase() {
 	if err = DB.AutoMigrate(&models.Livro{}); err != nil {
 		log.Fatalf("Erro ao migrar o modelo Livro: %v", err)
 	}
+	if err = DB.AutoMigrate(&models.UserToken{}); err != nil {
+		log.Fatalf("Erro ao migrar o modelo UserToken: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.LoginAttempt{}); err != nil {
+		log.Fatalf("Erro ao migrar o modelo LoginAttempt: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.RecoveryCode{}); err != nil {
+		log.Fatalf("Erro ao migrar o modelo RecoveryCode: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.APIKey{}); err != nil {
+		log.Fatalf("Erro ao migrar o modelo APIKey: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.SigningKey{}); err != nil {
+		log.Fatalf("Erro ao migrar o modelo SigningKey: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.ExternalIdentity{}); err != nil {
+		log.Fatalf("Erro ao migrar o modelo ExternalIdentity: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.Exemplar{}, &models.Inventario{}, &models.InventarioLeitura{}); err != nil {
+		log.Fatalf("Erro ao migrar os modelos de exemplares: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.Emprestimo{}); err != nil {
+		log.Fatalf("Erro ao migrar o modelo Emprestimo: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.Reserva{}); err != nil {
+		log.Fatalf("Erro ao migrar o modelo Reserva: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.Multa{}); err != nil {
+		log.Fatalf("Erro ao migrar o modelo Multa: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.Avaliacao{}, &models.SinalizacaoAvaliacao{}); err != nil {
+		log.Fatalf("Erro ao migrar os modelos de avaliações: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.Estante{}, &models.EstanteItem{}); err != nil {
+		log.Fatalf("Erro ao migrar os modelos de estantes: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.Leitura{}, &models.SessaoLeitura{}, &models.MetaLeitura{}); err != nil {
+		log.Fatalf("Erro ao migrar os modelos de leitura: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.InteracaoLivro{}); err != nil {
+		log.Fatalf("Erro ao migrar o modelo de interações: %v", err)
+	}
+	if err = DB.AutoMigrate(&models.Favorito{}, &models.AutorSeguido{}, &models.Notificacao{}); err != nil {
+		log.Fatalf("Erro ao migrar os modelos de favoritos e notificações: %v", err)
+	}
+	// Com o controle de exemplares, um livro pode ter vários empréstimos ativos; o índice único
+	// passou a ser por exemplar.
+	if DB.Migrator().HasIndex(&models.Emprestimo{}, "idx_emprestimo_ativo") {
+		if err = DB.Migrator().DropIndex(&models.Emprestimo{}, "idx_emprestimo_ativo"); err != nil {
+			log.Fatalf("Erro ao remover índice idx_emprestimo_ativo: %v", err)
+		}
+	}
 
 	log.Println("Banco de dados conectado e tabelas migradas com sucesso!")
 }
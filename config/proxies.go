@@ -0,0 +1,19 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// TrustedProxies retorna os proxies listados em TRUSTED_PROXIES, separados por vírgula, cujos
+// cabeçalhos X-Forwarded-For são aceitos para identificar o IP do cliente. Sem a variável, nenhum
+// proxy é confiável e o IP é o da conexão, para que o cabeçalho não possa ser forjado.
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
@@ -4,22 +4,37 @@ import (
 	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 var RedisClient *redis.Client
 
-// ConnectRedis inicializa a conexão com o Redis
+// ConnectRedis inicializa a conexão com o Redis. Sem REDIS_HOST, RedisClient fica nil e a API roda sem
+// ele. Se o Redis não responder, a API sobe mesmo assim: o cliente reconecta sozinho, e quem usa o
+// Redis segue sem ele enquanto isso.
 func ConnectRedis() {
+	host := os.Getenv("REDIS_HOST")
+	if host == "" {
+		log.Println("REDIS_HOST não configurado, a API vai rodar sem Redis")
+		return
+	}
+
 	RedisClient = redis.NewClient(&redis.Options{
-		Addr:     os.Getenv("REDIS_HOST") + ":" + os.Getenv("REDIS_PORT"),
+		Addr:     host + ":" + os.Getenv("REDIS_PORT"),
 		Password: "", // Defina aqui a senha caso necessário
 		DB:       1,  // Banco de dados padrão do Redis
+		// Tempos curtos, para que um Redis fora do ar não segure as requisições.
+		DialTimeout:  time.Second,
+		ReadTimeout:  500 * time.Millisecond,
+		WriteTimeout: 500 * time.Millisecond,
 	})
 
 	// Testa a conexão com o Redis
-	if _, err := RedisClient.Ping(context.Background()).Result(); err != nil {
-		log.Fatal("Falha ao conectar ao Redis:", err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := RedisClient.Ping(ctx).Result(); err != nil {
+		log.Printf("Falha ao conectar ao Redis, seguindo sem ele até que volte: %v", err)
 	}
 }
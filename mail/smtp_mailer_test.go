@@ -0,0 +1,122 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts a single SMTP session and records the envelope and data.
+type fakeSMTPServer struct {
+	listener net.Listener
+	from     string
+	to       []string
+	data     string
+	done     chan struct{}
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeSMTPServer{listener: listener, done: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP fake")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		upper := strings.ToUpper(cmd)
+
+		switch {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			s.from = strings.Trim(cmd[len("MAIL FROM:"):], "<> ")
+			reply("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			s.to = append(s.to, strings.Trim(cmd[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case upper == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.data = data.String()
+			reply("250 OK")
+		case upper == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	host, port, err := net.SplitHostPort(server.listener.Addr().String())
+	require.NoError(t, err)
+
+	mailer := NewSMTPMailer(SMTPConfig{Host: host, Port: port, From: "noreply@livros.local"})
+
+	msg, err := Render("pt-BR,pt;q=0.9", TemplateResetPassword, "leitor@example.com", map[string]string{
+		"Name":    "Leitor",
+		"Token":   "abc123",
+		"Link":    "http://localhost:8080/auth/redefinir-senha?token=abc123",
+		"Expires": "1 hora",
+	})
+	require.NoError(t, err)
+	require.NoError(t, mailer.Send(context.Background(), msg))
+	<-server.done
+
+	assert.Equal(t, "noreply@livros.local", server.from)
+	assert.Equal(t, []string{"leitor@example.com"}, server.to)
+	assert.Contains(t, server.data, "Subject: =?utf-8?q?Redefini=C3=A7=C3=A3o_de_senha?=")
+	assert.Contains(t, server.data, "Código: abc123")
+	assert.Contains(t, server.data, "token=abc123")
+}
+
+func TestSMTPMailerRequiresConfiguration(t *testing.T) {
+	err := NewSMTPMailer(SMTPConfig{}).Send(context.Background(), Message{To: "a@b.c"})
+	assert.Error(t, err)
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	assert.Equal(t, "pt", NormalizeLanguage(""))
+	assert.Equal(t, "pt", NormalizeLanguage("pt-BR"))
+	assert.Equal(t, "en", NormalizeLanguage("en-US,en;q=0.9"))
+	assert.Equal(t, "en", NormalizeLanguage("fr-FR, en;q=0.8"))
+	assert.Equal(t, "pt", NormalizeLanguage("de"))
+}
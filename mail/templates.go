@@ -0,0 +1,116 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Nomes dos modelos de e-mail disponíveis.
+const (
+	TemplateVerifyEmail   = "verificar-email"
+	TemplateResetPassword = "redefinir-senha"
+	// Avisos de empréstimo, enviados pelo pacote notify.
+	TemplateEmprestimoVencendo = "emprestimo-vencendo"
+	TemplateEmprestimoAtrasado = "emprestimo-atrasado"
+	// Aviso de livro novo de um autor seguido, enviado pelo pacote notify.
+	TemplateLivroNovo = "livro-novo"
+)
+
+// DefaultLanguage é o idioma usado quando o solicitado não é suportado.
+const DefaultLanguage = "pt"
+
+type emailTemplate struct {
+	subject string
+	body    *template.Template
+}
+
+var templates = map[string]map[string]emailTemplate{
+	"pt": {
+		TemplateVerifyEmail: newTemplate("Confirme seu e-mail", `Olá, {{.Name}}!
+
+Para confirmar seu endereço de e-mail, acesse o link abaixo:
+
+{{.Link}}
+
+O link expira em {{.Expires}}. Se você não criou esta conta, ignore esta mensagem.`),
+		TemplateResetPassword: newTemplate("Redefinição de senha", `Olá, {{.Name}}!
+
+Recebemos um pedido para redefinir a sua senha. Use o código abaixo ou acesse o link:
+
+Código: {{.Token}}
+{{.Link}}
+
+O link expira em {{.Expires}} e só pode ser usado uma vez. Se você não fez este pedido, ignore esta mensagem.`),
+		TemplateEmprestimoVencendo: newTemplate("Devolução próxima", `Olá, {{.nome}}!
+
+O empréstimo de "{{.livro}}" vence em {{.data_prevista}}. Devolva o livro ou procure a biblioteca
+até essa data para evitar multa.`),
+		TemplateEmprestimoAtrasado: newTemplate("Empréstimo em atraso", `Olá, {{.nome}}!
+
+O empréstimo de "{{.livro}}" venceu em {{.data_prevista}} e está {{.dias_atraso}} dia(s) em atraso.
+A multa acumulada até agora é de {{.multa}}. Devolva o livro o quanto antes.`),
+		TemplateLivroNovo: newTemplate("Novo livro de um autor que você segue", `Olá, {{.nome}}!
+
+{{.autor}} tem um livro novo no acervo: "{{.livro}}".`),
+	},
+	"en": {
+		TemplateVerifyEmail: newTemplate("Confirm your e-mail", `Hello, {{.Name}}!
+
+To confirm your e-mail address, open the link below:
+
+{{.Link}}
+
+The link expires in {{.Expires}}. If you did not create this account, please ignore this message.`),
+		TemplateResetPassword: newTemplate("Password reset", `Hello, {{.Name}}!
+
+We received a request to reset your password. Use the code below or open the link:
+
+Code: {{.Token}}
+{{.Link}}
+
+The link expires in {{.Expires}} and can only be used once. If you did not make this request, please ignore this message.`),
+		TemplateEmprestimoVencendo: newTemplate("Loan due soon", `Hello, {{.nome}}!
+
+Your loan of "{{.livro}}" is due on {{.data_prevista}}. Please return the book by then to avoid a fine.`),
+		TemplateEmprestimoAtrasado: newTemplate("Overdue loan", `Hello, {{.nome}}!
+
+Your loan of "{{.livro}}" was due on {{.data_prevista}} and is {{.dias_atraso}} day(s) overdue.
+The fine so far is {{.multa}}. Please return the book as soon as possible.`),
+		TemplateLivroNovo: newTemplate("New book by an author you follow", `Hello, {{.nome}}!
+
+{{.autor}} has a new book in the collection: "{{.livro}}".`),
+	},
+}
+
+func newTemplate(subject, body string) emailTemplate {
+	return emailTemplate{subject: subject, body: template.Must(template.New(subject).Parse(body))}
+}
+
+// Render monta uma mensagem a partir do modelo e idioma informados.
+func Render(lang, name, to string, data interface{}) (Message, error) {
+	tpl, ok := templates[NormalizeLanguage(lang)][name]
+	if !ok {
+		return Message{}, fmt.Errorf("modelo de e-mail desconhecido: %s", name)
+	}
+
+	var body bytes.Buffer
+	if err := tpl.body.Execute(&body, data); err != nil {
+		return Message{}, fmt.Errorf("erro ao montar e-mail: %w", err)
+	}
+
+	return Message{To: to, Subject: tpl.subject, Body: body.String()}, nil
+}
+
+// NormalizeLanguage converte um idioma ou cabeçalho Accept-Language em um idioma suportado.
+func NormalizeLanguage(lang string) string {
+	for _, part := range strings.Split(lang, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		base := strings.SplitN(tag, "-", 2)[0]
+		if _, ok := templates[base]; ok {
+			return base
+		}
+	}
+	return DefaultLanguage
+}
@@ -1,10 +1,18 @@
 package main
 
 import (
+	"books_api/cache"
 	"books_api/config"
+	"books_api/mail"
+	"books_api/notify"
+	"books_api/oidc"
+	"books_api/passhash"
+	"books_api/passpolicy"
 	"books_api/repository"
 	"books_api/routes"
 	"books_api/service"
+	"books_api/signing"
+	"context"
 	"log"
 
 	"github.com/gin-gonic/gin"
@@ -17,23 +25,79 @@ func main() {
 		log.Println("Não foi possível carregar o arquivo .env, utilizando variáveis de ambiente padrão.")
 	}
 
+	passhash.SetDefault(passhash.NewArgon2id(passhash.ParamsFromEnv()))
+	passpolicy.SetDefault(passpolicy.FromEnv())
+
 	config.ConnectDatabase()
 	config.ConnectRedis()
-	// Criar instância do LivroService usando o banco PostgreSQL
-	livroService := service.NewLivroService(config.DB)
 
 	// Criar instância do UserService e AuthService
 	userRepo := repository.NewUserRepository(config.DB)
-	authService := service.NewAuthService(userRepo)
+	tokenRepo := repository.NewUserTokenRepository(config.DB)
+	apiKeyRepo := repository.NewAPIKeyRepository(config.DB)
+	// Chaves de assinatura dos tokens JWT, compartilhadas entre instâncias pelo banco e rotacionadas em segundo plano
+	keys, err := signing.NewKeyManager(signing.ConfigFromEnv(), repository.NewSigningKeyRepository(config.DB))
+	if err != nil {
+		log.Fatalf("Erro ao inicializar chaves de assinatura: %v", err)
+	}
+	go keys.Run(context.Background())
+
+	// Um só Breaker para o Redis: quando ele cai, o cache, o controle de login e as recomendações deixam de esperá-lo juntos
+	redisBreaker := cache.NewRedisBreaker(config.RedisClient)
+
+	authService := service.NewAuthService(keys, userRepo, tokenRepo, apiKeyRepo, mail.NewMailerFromEnv())
+	authService.LoginGuard = service.NewLoginGuard(config.RedisClient, redisBreaker)
+	authService.LoginRepo = repository.NewLoginAttemptRepository(config.DB)
+	authService.OIDCProviders = oidc.LoadProvidersFromEnv(authService.BaseURL)
+	authService.IdentityRepo = repository.NewExternalIdentityRepository(config.DB)
+	authService.BootstrapAdmin()
+	go authService.LimparTokens(context.Background())
+	// Os seguidores do autor são notificados dos livros cadastrados, no aplicativo e, se pedirem, por e-mail
+	favoritoRepo := repository.NewFavoritoRepository(config.DB)
+	favoritoService := service.NewFavoritoService(favoritoRepo)
+	notificacaoService := service.NewNotificacaoService(repository.NewNotificacaoRepository(config.DB), favoritoRepo, notify.NewEmailNotifier(authService.Mailer))
+	// Criar instância do LivroService usando o banco PostgreSQL, com o cache escolhido em CACHE_DRIVER
+	livroCache := cache.NewFromEnv(config.RedisClient, redisBreaker)
+	if tiered, ok := livroCache.(*cache.Tiered); ok {
+		go tiered.Run(context.Background())
+	}
+	livroRepo := repository.NewCachedLivroRepository(repository.NewPostgresLivroRepository(config.DB), livroCache)
+	livroService := service.NewLivroService(livroRepo, repository.NewExemplarRepository(config.DB), notificacaoService)
+	userService := service.NewUserService(userRepo)
+	emprestimoRepo := repository.NewEmprestimoRepository(config.DB)
+	multaRepo := repository.NewMultaRepository(config.DB)
+	emprestimoService := service.NewEmprestimoService(emprestimoRepo, multaRepo)
+	multaService := service.NewMultaService(multaRepo)
+	// Lembretes de vencimento, avisos de atraso e multas são processados em segundo plano
+	atrasoService := service.NewAtrasoService(emprestimoRepo, multaRepo, notify.NewNotifierFromEnv(authService.Mailer))
+	go atrasoService.Run(context.Background())
+	avaliacaoRepo := repository.NewAvaliacaoRepository(config.DB)
+	avaliacaoRepo.Livros = livroRepo
+	avaliacaoService := service.NewAvaliacaoService(avaliacaoRepo)
+	estanteRepo := repository.NewEstanteRepository(config.DB)
+	estanteService := service.NewEstanteService(estanteRepo, authService.BaseURL)
+	leituraService := service.NewLeituraService(repository.NewLeituraRepository(config.DB), estanteRepo)
+	// Os livros similares são recalculados periodicamente a partir das visualizações e curtidas
+	recomendacaoService := service.NewRecomendacaoService(repository.NewInteracaoRepository(config.DB), config.RedisClient, redisBreaker)
+	go recomendacaoService.Run(context.Background())
+	exemplarService := service.NewExemplarService(repository.NewExemplarRepository(config.DB), repository.NewInventarioRepository(config.DB))
+	// Reservas com prazo de retirada vencido são expiradas em segundo plano, avançando a fila
+	reservaService := service.NewReservaService(repository.NewReservaRepository(config.DB))
+	go reservaService.Run(context.Background())
 
 	// Criar router do Gin
 	r := gin.Default()
+	// O IP do cliente, usado no bloqueio de login e no histórico de acessos, só vem de X-Forwarded-For
+	// quando a requisição passa por um dos proxies em TRUSTED_PROXIES
+	if err := r.SetTrustedProxies(config.TrustedProxies()); err != nil {
+		log.Fatalf("Erro em TRUSTED_PROXIES: %v", err)
+	}
 
 	// Permitir CORS
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-API-Key")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -45,8 +109,8 @@ func main() {
 	// Servir arquivos de imagem
 	r.Static("/uploads", "./uploads")
 
-	// Configurar rotas passando `authService` e `livroService`
-	routes.SetupRoutes(r, authService, livroService)
+	// Configurar rotas passando os serviços
+	routes.SetupRoutes(r, authService, userService, livroService, emprestimoService, exemplarService, reservaService, multaService, avaliacaoService, estanteService, leituraService, recomendacaoService, favoritoService, notificacaoService, livroRepo)
 
 	// Iniciar servidor
 	port := ":8080"
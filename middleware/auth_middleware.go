@@ -1,9 +1,8 @@
 package middleware
 
 import (
-	"fmt"
+	"books_api/models"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -11,12 +10,37 @@ import (
 )
 
 type CustomClaims struct {
-	Sub uint `json:"sub"`
+	Sub  uint        `json:"sub"`
+	Role models.Role `json:"role"`
+	Ver  uint        `json:"ver"`
+	// Purpose é preenchido apenas em tokens de uso restrito, como o de 2FA pendente,
+	// que não dão acesso à API.
+	Purpose string `json:"purpose"`
 	jwt.RegisteredClaims
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+// Formas de autenticação registradas no contexto em "authMethod".
+const (
+	AuthMethodJWT    = "jwt"
+	AuthMethodAPIKey = "api_key"
+)
+
+// Authenticator valida tokens e chaves de API e confirma que o usuário ainda pode acessar a API.
+type Authenticator interface {
+	VerifyToken(tokenString string, claims jwt.Claims) error
+	CheckAccount(userID uint) (*models.User, error)
+	AuthenticateAPIKey(key string) (*models.User, []models.Permission, error)
+}
+
+// AuthMiddleware autentica a requisição por um token JWT (Authorization: Bearer ...) ou por uma
+// chave de API (X-API-Key ou Authorization: ApiKey ...).
+func AuthMiddleware(auth Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := extractAPIKey(c); apiKey != "" {
+			authenticateAPIKey(c, auth, apiKey)
+			return
+		}
+
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"message": "Token não fornecido"})
@@ -26,28 +50,100 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
-		token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
+		claims := &CustomClaims{}
+		if err := auth.VerifyToken(tokenString, claims); err != nil || claims.Purpose != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Token inválido"})
+			c.Abort()
+			return
+		}
 
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
-			}
-			secret := os.Getenv("JWT_SECRET")
-			if secret == "" {
-				return nil, fmt.Errorf("JWT_SECRET não configurado")
-			}
-			return []byte(secret), nil
-		})
+		// O papel é lido do banco para refletir alterações feitas após a emissão do token.
+		user, err := auth.CheckAccount(claims.Sub)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Conta inválida ou desativada"})
+			c.Abort()
+			return
+		}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Token inválido"})
+		// Tokens emitidos antes de uma troca de senha deixam de ser aceitos.
+		if claims.Ver != user.SessionVersion {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Sessão expirada"})
 			c.Abort()
 			return
 		}
 
-		if claims, ok := token.Claims.(*CustomClaims); ok {
-			c.Set("userID", claims.Sub)
+		// Enquanto a troca de senha for obrigatória, só as rotas marcadas com AllowPasswordChange respondem.
+		if user.MustChangePassword && !c.GetBool(allowPasswordChangeKey) {
+			respondPasswordChangeRequired(c)
+			return
 		}
 
+		c.Set("userID", user.ID)
+		c.Set("role", user.Role)
+		c.Set("authMethod", AuthMethodJWT)
+
 		c.Next()
 	}
 }
+
+const allowPasswordChangeKey = "allowPasswordChange"
+
+// AllowPasswordChange libera a rota para usuários obrigados a trocar a senha. Deve vir antes do
+// AuthMiddleware e só deve ser usado nas rotas de troca de senha e de consulta do próprio perfil.
+func AllowPasswordChange() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(allowPasswordChangeKey, true)
+		c.Next()
+	}
+}
+
+func respondPasswordChangeRequired(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{"message": "É necessário alterar a senha antes de continuar", "password_change_required": true})
+	c.Abort()
+}
+
+// RequireUserSession recusa requisições autenticadas por chave de API. Usado em rotas que
+// gerenciam a própria conta e as credenciais do usuário.
+func RequireUserSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("authMethod") != AuthMethodJWT {
+			c.JSON(http.StatusForbidden, gin.H{"message": "Esta operação exige login com usuário e senha"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// authenticateAPIKey valida a chave de API e registra no contexto o usuário e os escopos concedidos.
+func authenticateAPIKey(c *gin.Context, auth Authenticator, apiKey string) {
+	user, scopes, err := auth.AuthenticateAPIKey(apiKey)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Chave de API inválida"})
+		c.Abort()
+		return
+	}
+	// Chaves de API não trocam a senha, então ficam suspensas enquanto a troca for obrigatória.
+	if user.MustChangePassword {
+		respondPasswordChangeRequired(c)
+		return
+	}
+
+	c.Set("userID", user.ID)
+	c.Set("role", user.Role)
+	c.Set("scopes", scopes)
+	c.Set("authMethod", AuthMethodAPIKey)
+
+	c.Next()
+}
+
+// extractAPIKey obtém a chave de API do cabeçalho X-API-Key ou Authorization: ApiKey.
+func extractAPIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "ApiKey ") {
+		return strings.TrimSpace(strings.TrimPrefix(header, "ApiKey "))
+	}
+	return ""
+}
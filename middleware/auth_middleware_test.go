@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"books_api/models"
+	"errors"
 	"github.com/golang-jwt/jwt/v4"
 	"net/http"
 	"net/http/httptest"
@@ -54,6 +56,30 @@ func TestAuthMiddleware(t *testing.T) {
 			expectedCode: http.StatusOK,
 			expectedBody: "OK",
 		},
+		{
+			name:         "MFAPendingToken",
+			authHeader:   "Bearer " + generateMFAPendingToken(),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"message":"Token inválido"}`,
+		},
+		{
+			name:         "DisabledUser",
+			authHeader:   "Bearer " + generateTokenFor(2),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"message":"Conta inválida ou desativada"}`,
+		},
+		{
+			name:         "RevokedSession",
+			authHeader:   "Bearer " + generateTokenFor(3),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"message":"Sessão expirada"}`,
+		},
+		{
+			name:         "UnknownUser",
+			authHeader:   "Bearer " + generateTokenFor(99),
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"message":"Conta inválida ou desativada"}`,
+		},
 	}
 
 	for _, test := range tests {
@@ -62,7 +88,7 @@ func TestAuthMiddleware(t *testing.T) {
 			r := gin.New()
 
 			// Add the AuthMiddleware.
-			r.Use(AuthMiddleware())
+			r.Use(AuthMiddleware(fakeAuthenticator{}))
 			// Add a dummy handler to simulate the protected route.
 			r.GET("/protected", func(c *gin.Context) {
 				c.String(http.StatusOK, "OK")
@@ -91,11 +117,143 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 }
 
+// fakeAuthenticator simulates the account lookup: user 1 is active, user 2 is disabled,
+// user 3 changed the password after the test tokens were issued and user 4 must change the password.
+type fakeAuthenticator struct{}
+
+// VerifyToken checks HS256 tokens signed with the test secret.
+func (fakeAuthenticator) VerifyToken(tokenString string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return errors.New("invalid token")
+	}
+	return nil
+}
+
+func (fakeAuthenticator) CheckAccount(userID uint) (*models.User, error) {
+	switch userID {
+	case 1:
+		return &models.User{ID: 1, Role: models.RoleReader}, nil
+	case 2:
+		return nil, errors.New("disabled")
+	case 3:
+		return &models.User{ID: 3, Role: models.RoleReader, SessionVersion: 1}, nil
+	case 4:
+		return &models.User{ID: 4, Role: models.RoleReader, MustChangePassword: true}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (fakeAuthenticator) AuthenticateAPIKey(key string) (*models.User, []models.Permission, error) {
+	switch key {
+	case "bka_valid":
+		return &models.User{ID: 1, Role: models.RoleEditor}, []models.Permission{models.PermLivrosRead}, nil
+	case "bka_must_change":
+		return &models.User{ID: 4, Role: models.RoleReader, MustChangePassword: true}, []models.Permission{models.PermLivrosRead}, nil
+	}
+	return nil, nil, errors.New("invalid key")
+}
+
 // generateValidToken creates a valid JWT for testing purposes.
 func generateValidToken() string {
+	return generateTokenFor(1)
+}
+
+// generateTokenFor creates a signed JWT for the given user ID.
+func generateTokenFor(userID uint) string {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": "test_user",
+		"sub":  userID,
+		"role": "reader",
 	})
 	tokenString, _ := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
 	return tokenString
 }
+
+// generateMFAPendingToken creates a token that only proves the password step of a 2FA login.
+func generateMFAPendingToken() string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":     1,
+		"purpose": "mfa",
+	})
+	tokenString, _ := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	return tokenString
+}
+
+func TestAuthMiddlewareAPIKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		path         string
+		expectedCode int
+	}{
+		{"XAPIKeyHeader", map[string]string{"X-API-Key": "bka_valid"}, "/livros", http.StatusOK},
+		{"AuthorizationApiKey", map[string]string{"Authorization": "ApiKey bka_valid"}, "/livros", http.StatusOK},
+		{"InvalidKey", map[string]string{"X-API-Key": "bka_wrong"}, "/livros", http.StatusUnauthorized},
+		{"ScopeNotGranted", map[string]string{"X-API-Key": "bka_valid"}, "/escrita", http.StatusForbidden},
+		{"SessionOnlyRoute", map[string]string{"X-API-Key": "bka_valid"}, "/conta", http.StatusForbidden},
+		{"MustChangePassword", map[string]string{"X-API-Key": "bka_must_change"}, "/livros", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(AuthMiddleware(fakeAuthenticator{}))
+			ok := func(c *gin.Context) { c.String(http.StatusOK, "OK") }
+			r.GET("/livros", RequirePermission(models.PermLivrosRead), ok)
+			// The editor role allows writes, but the key was only granted livros:read.
+			r.GET("/escrita", RequirePermission(models.PermLivrosWrite), ok)
+			r.GET("/conta", RequireUserSession(), ok)
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestAuthMiddlewareMustChangePassword(t *testing.T) {
+	os.Setenv("JWT_SECRET", "test_secret")
+
+	r := gin.New()
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "OK") }
+	r.GET("/livros", AuthMiddleware(fakeAuthenticator{}), ok)
+	r.POST("/senha", AllowPasswordChange(), AuthMiddleware(fakeAuthenticator{}), ok)
+
+	tests := []struct {
+		name         string
+		method, path string
+		userID       uint
+		expectedCode int
+	}{
+		{"BlockedRoute", http.MethodGet, "/livros", 4, http.StatusForbidden},
+		{"PasswordChangeRoute", http.MethodPost, "/senha", 4, http.StatusOK},
+		{"RegularUser", http.MethodGet, "/livros", 1, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, nil)
+			req.Header.Set("Authorization", "Bearer "+generateTokenFor(test.userID))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+			if test.expectedCode == http.StatusForbidden {
+				assert.JSONEq(t, `{"message":"É necessário alterar a senha antes de continuar","password_change_required":true}`, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"books_api/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole permite o acesso apenas aos usuários com um dos papéis informados.
+// Deve ser usado após o AuthMiddleware.
+func RequireRole(roles ...models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := currentRole(c)
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"message": "Acesso negado"})
+		c.Abort()
+	}
+}
+
+// RequirePermission permite o acesso apenas aos usuários cujo papel concede a permissão informada.
+// Em requisições autenticadas por chave de API, a permissão também precisa estar entre os escopos da chave.
+// Deve ser usado após o AuthMiddleware.
+func RequirePermission(perm models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, perm) {
+			c.JSON(http.StatusForbidden, gin.H{"message": "Acesso negado"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// HasPermission informa se o usuário autenticado possui a permissão, respeitando os escopos
+// da chave de API. Usado quando a permissão apenas amplia o que a rota permite fazer.
+func HasPermission(c *gin.Context, perm models.Permission) bool {
+	return currentRole(c).Can(perm) && scopeAllows(c, perm)
+}
+
+// currentRole obtém o papel do usuário autenticado a partir do contexto.
+func currentRole(c *gin.Context) models.Role {
+	role, _ := c.Get("role")
+	r, _ := role.(models.Role)
+	return r
+}
+
+// scopeAllows verifica os escopos da chave de API, quando a requisição foi autenticada por uma.
+func scopeAllows(c *gin.Context, perm models.Permission) bool {
+	value, exists := c.Get("scopes")
+	if !exists {
+		return true
+	}
+	scopes, _ := value.([]models.Permission)
+	for _, scope := range scopes {
+		if scope == perm {
+			return true
+		}
+	}
+	return false
+}
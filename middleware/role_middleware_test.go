@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"books_api/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name         string
+		role         models.Role
+		permission   models.Permission
+		expectedCode int
+	}{
+		{"ReaderCanRead", models.RoleReader, models.PermLivrosRead, http.StatusOK},
+		{"ReaderCannotWrite", models.RoleReader, models.PermLivrosWrite, http.StatusForbidden},
+		{"EditorCanWrite", models.RoleEditor, models.PermLivrosWrite, http.StatusOK},
+		{"EditorCannotManageUsers", models.RoleEditor, models.PermUsuariosManage, http.StatusForbidden},
+		{"AdminCanManageUsers", models.RoleAdmin, models.PermUsuariosManage, http.StatusOK},
+		{"NoRole", "", models.PermLivrosRead, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set("role", test.role)
+				c.Next()
+			})
+			r.GET("/protected", RequirePermission(test.permission), func(c *gin.Context) {
+				c.String(http.StatusOK, "OK")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("role", models.RoleEditor)
+		c.Next()
+	})
+	r.GET("/admin", RequireRole(models.RoleAdmin), func(c *gin.Context) {
+		c.String(http.StatusOK, "OK")
+	})
+	r.GET("/staff", RequireRole(models.RoleAdmin, models.RoleEditor), func(c *gin.Context) {
+		c.String(http.StatusOK, "OK")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"message":"Acesso negado"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/staff", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// APIKey é uma chave de acesso pessoal usada por scripts e outros serviços.
+// Apenas o hash da chave é armazenado; Prefix permite identificá-la nas listagens.
+type APIKey struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	UserID     uint         `json:"user_id" gorm:"index;not null"`
+	Name       string       `json:"name" gorm:"not null"`
+	Prefix     string       `json:"prefix" gorm:"not null"`
+	KeyHash    string       `json:"-" gorm:"uniqueIndex;not null"`
+	Scopes     []Permission `json:"scopes" gorm:"serializer:json;not null"`
+	ExpiresAt  *time.Time   `json:"expires_at"`
+	LastUsedAt *time.Time   `json:"last_used_at"`
+	RevokedAt  *time.Time   `json:"revoked_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+
+	User User `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Active informa se a chave pode ser usada no instante informado.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const avaliacaoTextoMax = 5000
+
+var (
+	ErrNotaInvalida        = errors.New("nota deve estar entre 1 e 5")
+	ErrTextoAvaliacaoLongo = errors.New("texto da avaliação deve ter no máximo 5000 caracteres")
+)
+
+// Avaliacao é a nota de 1 a 5 estrelas dada por um usuário a um livro, com um comentário opcional.
+// Cada usuário avalia um livro uma única vez. Avaliações ocultas pela moderação não aparecem nas
+// listagens públicas nem entram na média do livro.
+type Avaliacao struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	UserID  uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_avaliacao_usuario_livro"`
+	LivroID uint   `json:"livro_id" gorm:"not null;index;uniqueIndex:idx_avaliacao_usuario_livro"`
+	Nota    int    `json:"nota" gorm:"not null"`
+	Texto   string `json:"texto"`
+	// Sinalizacoes conta quantos usuários denunciaram a avaliação para a moderação.
+	Sinalizacoes int        `json:"sinalizacoes,omitempty" gorm:"not null;default:0;index"`
+	Oculta       bool       `json:"oculta,omitempty" gorm:"not null;default:false"`
+	MotivoOculta string     `json:"motivo_oculta,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	EditadaEm    *time.Time `json:"editada_em"`
+
+	User  User  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Livro Livro `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	// Autor é o nome exibido de quem avaliou.
+	Autor string `json:"autor" gorm:"-"`
+}
+
+// SinalizacaoAvaliacao registra a denúncia de uma avaliação por um usuário, uma por usuário.
+type SinalizacaoAvaliacao struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	AvaliacaoID uint      `json:"avaliacao_id" gorm:"not null;uniqueIndex:idx_sinalizacao_avaliacao_usuario"`
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_sinalizacao_avaliacao_usuario"`
+	Motivo      string    `json:"motivo"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	Avaliacao Avaliacao `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	User      User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Validate verifica a nota e o tamanho do comentário.
+func (a *Avaliacao) Validate() error {
+	if a.Nota < 1 || a.Nota > 5 {
+		return ErrNotaInvalida
+	}
+	a.Texto = strings.TrimSpace(a.Texto)
+	if len([]rune(a.Texto)) > avaliacaoTextoMax {
+		return ErrTextoAvaliacaoLongo
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Situações de um empréstimo, usadas nos filtros e nas respostas.
+const (
+	EmprestimoAtivo     = "ativo"
+	EmprestimoAtrasado  = "atrasado"
+	EmprestimoDevolvido = "devolvido"
+)
+
+// Emprestimo registra a retirada de um exemplar de um livro por um usuário. Enquanto DataDevolucao
+// estiver vazia, o exemplar não pode ser emprestado novamente; o índice único parcial garante isso no banco.
+// ExemplarID fica vazio apenas em empréstimos anteriores ao controle de exemplares.
+type Emprestimo struct {
+	ID                    uint       `json:"id" gorm:"primaryKey"`
+	UserID                uint       `json:"user_id" gorm:"index;not null"`
+	LivroID               uint       `json:"livro_id" gorm:"not null;index"`
+	ExemplarID            *uint      `json:"exemplar_id" gorm:"uniqueIndex:idx_emprestimo_exemplar_ativo,where:data_devolucao IS NULL"`
+	DataEmprestimo        time.Time  `json:"data_emprestimo" gorm:"not null"`
+	DataDevolucaoPrevista time.Time  `json:"data_devolucao_prevista" gorm:"not null;index"`
+	DataDevolucao         *time.Time `json:"data_devolucao"`
+	// LembreteEnviadoEm e AvisoAtrasoEm registram os avisos de vencimento e de atraso já enviados.
+	LembreteEnviadoEm *time.Time `json:"-"`
+	AvisoAtrasoEm     *time.Time `json:"-"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	// Status é calculado ao carregar o registro; não é gravado no banco.
+	Status string `json:"status" gorm:"-"`
+
+	User     User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Livro    Livro     `json:"livro,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Exemplar *Exemplar `json:"exemplar,omitempty" gorm:"constraint:OnDelete:SET NULL"`
+}
+
+// CalcularStatus informa se o empréstimo está ativo, atrasado ou devolvido no instante informado.
+func (e *Emprestimo) CalcularStatus(now time.Time) string {
+	switch {
+	case e.DataDevolucao != nil:
+		return EmprestimoDevolvido
+	case now.After(e.DataDevolucaoPrevista):
+		return EmprestimoAtrasado
+	default:
+		return EmprestimoAtivo
+	}
+}
+
+// AfterFind e AfterCreate preenchem o Status calculado.
+func (e *Emprestimo) AfterFind(tx *gorm.DB) error {
+	e.Status = e.CalcularStatus(time.Now())
+	return nil
+}
+
+func (e *Emprestimo) AfterCreate(tx *gorm.DB) error {
+	e.Status = e.CalcularStatus(time.Now())
+	return nil
+}
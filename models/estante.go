@@ -0,0 +1,89 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Tipos de estante. Toda conta tem as três estantes padrão, criadas no primeiro acesso; as demais
+// são listas personalizadas.
+const (
+	EstanteQueroLer      = "quero-ler"
+	EstanteLendo         = "lendo"
+	EstanteLidos         = "lidos"
+	EstantePersonalizada = "personalizada"
+)
+
+// EstantesPadrao são as estantes criadas para todo usuário, na ordem em que são exibidas, com o nome inicial.
+var EstantesPadrao = []struct {
+	Tipo string
+	Nome string
+}{
+	{EstanteQueroLer, "Quero ler"},
+	{EstanteLendo, "Lendo"},
+	{EstanteLidos, "Lidos"},
+}
+
+var (
+	ErrNomeEstanteInvalido = errors.New("nome da estante deve ter entre 1 e 100 caracteres")
+	ErrNotaItemLonga       = errors.New("nota deve ter no máximo 1000 caracteres")
+)
+
+// Estante é uma lista de livros de um usuário. Estantes privadas só são vistas pelo dono; as públicas
+// aparecem no perfil do usuário. Qualquer estante pode ser compartilhada por link, mesmo privada.
+type Estante struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_estante_usuario_nome;uniqueIndex:idx_estante_padrao,where:tipo <> 'personalizada'"`
+	Nome      string `json:"nome" gorm:"not null;uniqueIndex:idx_estante_usuario_nome"`
+	Descricao string `json:"descricao"`
+	// Tipo identifica as estantes padrão, uma de cada tipo por usuário, independentemente do nome.
+	Tipo    string `json:"tipo" gorm:"type:varchar(20);not null;default:personalizada;uniqueIndex:idx_estante_padrao,where:tipo <> 'personalizada'"`
+	Publica bool   `json:"publica" gorm:"not null;default:false"`
+	// TokenCompartilhamento identifica o link de compartilhamento; vazio se a estante não estiver compartilhada.
+	TokenCompartilhamento *string   `json:"-" gorm:"uniqueIndex"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+
+	User  User          `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Itens []EstanteItem `json:"itens,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	// TotalItens e LinkCompartilhamento são preenchidos nas respostas e não são gravados no banco.
+	TotalItens           int64  `json:"total_itens" gorm:"-"`
+	LinkCompartilhamento string `json:"link_compartilhamento,omitempty" gorm:"-"`
+}
+
+// EstanteItem é um livro em uma estante, com a posição na lista e uma nota do usuário.
+type EstanteItem struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	EstanteID uint      `json:"estante_id" gorm:"not null;uniqueIndex:idx_estante_item"`
+	LivroID   uint      `json:"livro_id" gorm:"not null;index;uniqueIndex:idx_estante_item"`
+	Posicao   int       `json:"posicao" gorm:"not null"`
+	Nota      string    `json:"nota"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Livro Livro `json:"livro" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Padrao informa se é uma das estantes padrão, que não podem ser excluídas.
+func (e *Estante) Padrao() bool {
+	return e.Tipo != EstantePersonalizada
+}
+
+// Validate normaliza e verifica o nome da estante.
+func (e *Estante) Validate() error {
+	e.Nome = strings.TrimSpace(e.Nome)
+	e.Descricao = strings.TrimSpace(e.Descricao)
+	if e.Nome == "" || len([]rune(e.Nome)) > 100 {
+		return ErrNomeEstanteInvalido
+	}
+	return nil
+}
+
+// ValidateNotaItem verifica o tamanho da nota de um livro na estante.
+func ValidateNotaItem(nota string) error {
+	if len([]rune(nota)) > 1000 {
+		return ErrNotaItemLonga
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Situações de um exemplar.
+const (
+	ExemplarDisponivel = "disponivel"
+	ExemplarEmprestado = "emprestado"
+	ExemplarPerdido    = "perdido"
+	ExemplarEmReparo   = "em_reparo"
+	// ExemplarReservado indica que o exemplar está separado para a retirada de uma reserva.
+	ExemplarReservado = "reservado"
+)
+
+// Exemplar é uma cópia física de um livro, identificada pelo código de barras.
+type Exemplar struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	LivroID      uint      `json:"livro_id" gorm:"index;not null"`
+	CodigoBarras string    `json:"codigo_barras" gorm:"uniqueIndex;not null"`
+	Condicao     string    `json:"condicao"`
+	Localizacao  string    `json:"localizacao" gorm:"index"`
+	Status       string    `json:"status" gorm:"type:varchar(20);not null;default:disponivel;index"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	Livro Livro `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// ValidExemplarStatus informa se a situação é conhecida.
+func ValidExemplarStatus(status string) bool {
+	switch status {
+	case ExemplarDisponivel, ExemplarEmprestado, ExemplarPerdido, ExemplarEmReparo, ExemplarReservado:
+		return true
+	}
+	return false
+}
+
+// Disponibilidade resume os exemplares de um livro.
+type Disponibilidade struct {
+	Total       int64 `json:"total"`
+	Disponiveis int64 `json:"disponiveis"`
+	Emprestados int64 `json:"emprestados"`
+	Reservados  int64 `json:"reservados"`
+}
+
+// Inventario é uma conferência dos exemplares de uma localização. Ao ser concluído, os exemplares
+// disponíveis da localização que não foram lidos são marcados como perdidos.
+type Inventario struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	Localizacao    string     `json:"localizacao" gorm:"index;not null"`
+	IniciadoPor    uint       `json:"iniciado_por"`
+	IniciadoEm     time.Time  `json:"iniciado_em"`
+	ConcluidoEm    *time.Time `json:"concluido_em"`
+	NaoLocalizados int        `json:"nao_localizados"`
+}
+
+// InventarioLeitura registra a leitura de um exemplar durante um inventário.
+type InventarioLeitura struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	InventarioID uint      `json:"inventario_id" gorm:"not null;uniqueIndex:idx_inventario_leitura"`
+	ExemplarID   uint      `json:"exemplar_id" gorm:"not null;uniqueIndex:idx_inventario_leitura"`
+	LidoEm       time.Time `json:"lido_em"`
+
+	Inventario Inventario `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Exemplar   Exemplar   `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
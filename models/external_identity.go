@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ExternalIdentity vincula um usuário a uma conta em um provedor de identidade OpenID Connect,
+// identificada pelo par provedor + subject (claim sub do id_token).
+type ExternalIdentity struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index;not null"`
+	Provider  string    `json:"provider" gorm:"type:varchar(50);not null;uniqueIndex:idx_external_identity"`
+	Subject   string    `json:"-" gorm:"not null;uniqueIndex:idx_external_identity"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+
+	User User `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
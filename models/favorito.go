@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrAutorInvalido = errors.New("nome do autor deve ter entre 1 e 200 caracteres")
+
+// Favorito é um livro marcado como favorito por um usuário.
+type Favorito struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_favorito_usuario_livro"`
+	LivroID   uint      `json:"livro_id" gorm:"not null;index;uniqueIndex:idx_favorito_usuario_livro"`
+	CreatedAt time.Time `json:"created_at"`
+
+	User  User  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Livro Livro `json:"livro" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// AutorSeguido é um autor acompanhado por um usuário, que é notificado quando um livro dele é cadastrado.
+// Os autores são comparados pela Chave, que ignora maiúsculas e espaços repetidos.
+type AutorSeguido struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_autor_seguido_usuario"`
+	Autor  string `json:"autor" gorm:"not null"`
+	Chave  string `json:"-" gorm:"not null;index;uniqueIndex:idx_autor_seguido_usuario"`
+	// Email indica se, além da notificação no aplicativo, o usuário quer receber um e-mail.
+	Email     bool      `json:"email" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	User User `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// ChaveAutor normaliza o nome do autor para comparação.
+func ChaveAutor(autor string) string {
+	return strings.ToLower(strings.Join(strings.Fields(autor), " "))
+}
+
+// Validate normaliza o nome do autor e calcula a chave de comparação.
+func (a *AutorSeguido) Validate() error {
+	a.Autor = strings.Join(strings.Fields(a.Autor), " ")
+	if a.Autor == "" || len([]rune(a.Autor)) > 200 {
+		return ErrAutorInvalido
+	}
+	a.Chave = ChaveAutor(a.Autor)
+	return nil
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Tipos de interação com um livro registrados para as recomendações.
+const (
+	InteracaoVisualizacao = "visualizacao"
+	InteracaoCurtida      = "curtida"
+)
+
+// InteracaoLivro registra que um usuário viu os detalhes de um livro ou o curtiu. Cada usuário curte
+// um livro no máximo uma vez; visualizações são registradas a cada acesso, com um intervalo mínimo.
+type InteracaoLivro struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index;uniqueIndex:idx_curtida_usuario_livro,where:tipo = 'curtida'"`
+	LivroID   uint      `json:"livro_id" gorm:"not null;index;uniqueIndex:idx_curtida_usuario_livro,where:tipo = 'curtida'"`
+	Tipo      string    `json:"tipo" gorm:"type:varchar(20);not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	User  User  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Livro Livro `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
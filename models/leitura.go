@@ -0,0 +1,99 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Situações de uma leitura, usadas nos filtros.
+const (
+	LeituraEmAndamento = "lendo"
+	LeituraConcluida   = "concluida"
+)
+
+var (
+	ErrPaginasSessaoInvalidas = errors.New("páginas lidas não podem ser negativas")
+	ErrPeriodoSessaoInvalido  = errors.New("o fim da sessão deve ser posterior ao início e não pode estar no futuro")
+	ErrMetaLeituraInvalida    = errors.New("a meta deve ser de 1 a 1000 livros")
+	ErrAnoInvalido            = errors.New("ano inválido")
+)
+
+// Leitura acompanha a leitura de um livro por um usuário, somando as páginas das sessões registradas.
+// A leitura é concluída quando as páginas lidas alcançam o total do livro ou quando o usuário a encerra.
+type Leitura struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_leitura_usuario_livro"`
+	LivroID      uint       `json:"livro_id" gorm:"not null;index;uniqueIndex:idx_leitura_usuario_livro"`
+	PaginasLidas int        `json:"paginas_lidas" gorm:"not null;default:0"`
+	IniciadaEm   time.Time  `json:"iniciada_em"`
+	ConcluidaEm  *time.Time `json:"concluida_em" gorm:"index"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	User    User            `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Livro   Livro           `json:"livro" gorm:"constraint:OnDelete:CASCADE"`
+	Sessoes []SessaoLeitura `json:"sessoes,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	// Progresso é o percentual lido, calculado a partir do número de páginas do livro. Fica vazio
+	// enquanto a leitura não foi concluída e o livro não tem o número de páginas cadastrado.
+	Progresso *float64 `json:"progresso,omitempty" gorm:"-"`
+}
+
+// SessaoLeitura é um período de leitura de um livro.
+type SessaoLeitura struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	LeituraID uint      `json:"leitura_id" gorm:"not null;index"`
+	UserID    uint      `json:"user_id" gorm:"not null;index:idx_sessao_usuario_fim"`
+	Paginas   int       `json:"paginas" gorm:"not null"`
+	InicioEm  time.Time `json:"inicio_em" gorm:"not null"`
+	FimEm     time.Time `json:"fim_em" gorm:"not null;index:idx_sessao_usuario_fim"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MetaLeitura é a quantidade de livros que o usuário pretende concluir no ano.
+type MetaLeitura struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_meta_usuario_ano"`
+	Ano       int       `json:"ano" gorm:"not null;uniqueIndex:idx_meta_usuario_ano"`
+	Livros    int       `json:"livros" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	User User `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Validate verifica as páginas e o período da sessão em relação ao instante now.
+func (s *SessaoLeitura) Validate(now time.Time) error {
+	if s.Paginas < 0 {
+		return ErrPaginasSessaoInvalidas
+	}
+	if s.FimEm.Before(s.InicioEm) || s.FimEm.After(now) {
+		return ErrPeriodoSessaoInvalido
+	}
+	return nil
+}
+
+// Validate verifica o ano e a quantidade de livros da meta.
+func (m *MetaLeitura) Validate() error {
+	if m.Ano < 1900 || m.Ano > 9999 {
+		return ErrAnoInvalido
+	}
+	if m.Livros < 1 || m.Livros > 1000 {
+		return ErrMetaLeituraInvalida
+	}
+	return nil
+}
+
+// PreencherProgresso calcula o percentual lido com base nas páginas do livro carregado, com uma casa decimal.
+func (l *Leitura) PreencherProgresso() {
+	switch {
+	case l.ConcluidaEm != nil:
+		progresso := 100.0
+		l.Progresso = &progresso
+	case l.Livro.Paginas > 0:
+		progresso := math.Min(100, math.Round(float64(l.PaginasLidas)*1000/float64(l.Livro.Paginas))/10)
+		l.Progresso = &progresso
+	default:
+		l.Progresso = nil
+	}
+}
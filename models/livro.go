@@ -3,7 +3,16 @@ package models
 type Livro struct {
 	ID        uint   `json:"id" gorm:"primaryKey"`
 	Titulo    string `json:"titulo"`
-	Autor     string `json:"autor"`
+	Autor     string `json:"autor" gorm:"index"`
+	Genero    string `json:"genero" gorm:"index"`
 	Ano       int    `json:"ano"`
 	ImagePath string `json:"image_path"`
+	// Paginas é usado para calcular o progresso das leituras; 0 indica que não foi informado.
+	Paginas int `json:"paginas" gorm:"not null;default:0"`
+	// MediaAvaliacoes e TotalAvaliacoes resumem as avaliações visíveis do livro e são mantidas
+	// pelo repositório de avaliações.
+	MediaAvaliacoes float64 `json:"media_avaliacoes" gorm:"not null;default:0;index"`
+	TotalAvaliacoes int     `json:"total_avaliacoes" gorm:"not null;default:0"`
+	// Disponibilidade é calculada a partir dos exemplares e não é gravada no banco.
+	Disponibilidade *Disponibilidade `json:"disponibilidade,omitempty" gorm:"-"`
 }
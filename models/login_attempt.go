@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// LoginAttempt registra uma tentativa de login, bem-sucedida ou não.
+type LoginAttempt struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    *uint     `json:"user_id" gorm:"index"`
+	Username  string    `json:"username" gorm:"index"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
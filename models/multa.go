@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Situações de uma multa, usadas nos filtros.
+const (
+	MultaPendente = "pendente"
+	MultaPaga     = "paga"
+)
+
+// Multa é a cobrança por atraso de um empréstimo. Enquanto o empréstimo estiver em aberto, o valor é
+// recalculado a cada verificação de atrasos; com a devolução, passa a ser definitivo.
+type Multa struct {
+	ID           uint `json:"id" gorm:"primaryKey"`
+	UserID       uint `json:"user_id" gorm:"not null;index"`
+	EmprestimoID uint `json:"emprestimo_id" gorm:"not null;uniqueIndex"`
+	DiasAtraso   int  `json:"dias_atraso" gorm:"not null"`
+	// Valor é expresso em centavos.
+	Valor     int64      `json:"valor" gorm:"not null"`
+	PagaEm    *time.Time `json:"paga_em"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	User       User        `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Emprestimo *Emprestimo `json:"emprestimo,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Tipos de notificação no aplicativo.
+const (
+	NotificacaoLivroNovo = "livro-novo"
+)
+
+// Notificacao é um aviso exibido ao usuário no aplicativo.
+type Notificacao struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index:idx_notificacao_usuario"`
+	Tipo      string     `json:"tipo" gorm:"type:varchar(30);not null"`
+	Mensagem  string     `json:"mensagem" gorm:"not null"`
+	LivroID   *uint      `json:"livro_id" gorm:"index"`
+	LidaEm    *time.Time `json:"lida_em"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index:idx_notificacao_usuario"`
+
+	User  User   `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Livro *Livro `json:"livro,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+}
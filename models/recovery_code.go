@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RecoveryCode é um código de recuperação de uso único para contas com 2FA.
+// Apenas o hash do código é armazenado.
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	User User `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
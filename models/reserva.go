@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Situações de uma reserva.
+const (
+	// ReservaAguardando indica que o usuário está na fila aguardando um exemplar.
+	ReservaAguardando = "aguardando"
+	// ReservaDisponivel indica que um exemplar foi separado para o usuário, que deve retirá-lo até o prazo.
+	ReservaDisponivel = "disponivel"
+	ReservaAtendida   = "atendida"
+	ReservaExpirada   = "expirada"
+	ReservaCancelada  = "cancelada"
+)
+
+// Reserva coloca um usuário na fila de espera de um livro indisponível. A fila é atendida por ordem
+// de chegada (ID). Enquanto EncerradaEm estiver vazia a reserva está ativa, e o índice único parcial
+// impede que o usuário tenha duas reservas ativas do mesmo livro.
+type Reserva struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_reserva_ativa,where:encerrada_em IS NULL"`
+	LivroID       uint       `json:"livro_id" gorm:"not null;index;uniqueIndex:idx_reserva_ativa,where:encerrada_em IS NULL"`
+	ExemplarID    *uint      `json:"exemplar_id"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;index"`
+	PrazoRetirada *time.Time `json:"prazo_retirada" gorm:"index"`
+	EncerradaEm   *time.Time `json:"encerrada_em"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	// Posicao é a posição na fila das reservas aguardando; é calculada ao consultar e não é gravada no banco.
+	Posicao int `json:"posicao,omitempty" gorm:"-"`
+
+	User     User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Livro    Livro     `json:"livro,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Exemplar *Exemplar `json:"exemplar,omitempty" gorm:"constraint:OnDelete:SET NULL"`
+}
+
+// ValidReservaStatus informa se a situação é conhecida.
+func ValidReservaStatus(status string) bool {
+	switch status {
+	case ReservaAguardando, ReservaDisponivel, ReservaAtendida, ReservaExpirada, ReservaCancelada:
+		return true
+	}
+	return false
+}
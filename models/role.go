@@ -0,0 +1,66 @@
+package models
+
+// Role define o papel de um usuário no sistema.
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleEditor Role = "editor"
+	RoleReader Role = "reader"
+)
+
+// Permission representa uma ação que pode ser concedida a um papel.
+type Permission string
+
+const (
+	PermLivrosRead     Permission = "livros:read"
+	PermLivrosWrite    Permission = "livros:write"
+	PermUsuariosManage Permission = "usuarios:manage"
+	// PermEmprestimosWrite permite pegar e devolver livros emprestados para si mesmo.
+	PermEmprestimosWrite Permission = "emprestimos:write"
+	// PermEmprestimosManage permite registrar empréstimos e devoluções de qualquer usuário.
+	PermEmprestimosManage Permission = "emprestimos:manage"
+	// PermAvaliacoesWrite permite avaliar livros e sinalizar avaliações de outros usuários.
+	PermAvaliacoesWrite Permission = "avaliacoes:write"
+	// PermAvaliacoesModerate permite ocultar e reexibir avaliações.
+	PermAvaliacoesModerate Permission = "avaliacoes:moderate"
+	// PermLeituraWrite permite manter os dados pessoais de leitura, como as estantes.
+	PermLeituraWrite Permission = "leitura:write"
+)
+
+// rolePermissions mapeia cada papel para as permissões concedidas a ele.
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {PermLivrosRead, PermLivrosWrite, PermUsuariosManage, PermEmprestimosWrite, PermEmprestimosManage,
+		PermAvaliacoesWrite, PermAvaliacoesModerate, PermLeituraWrite},
+	RoleEditor: {PermLivrosRead, PermLivrosWrite, PermEmprestimosWrite, PermEmprestimosManage, PermAvaliacoesWrite,
+		PermLeituraWrite},
+	RoleReader: {PermLivrosRead, PermEmprestimosWrite, PermAvaliacoesWrite, PermLeituraWrite},
+}
+
+// Valid informa se a permissão é conhecida.
+func (p Permission) Valid() bool {
+	for _, perms := range rolePermissions {
+		for _, perm := range perms {
+			if perm == p {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Valid informa se o papel é conhecido.
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can informa se o papel possui a permissão informada.
+func (r Role) Can(p Permission) bool {
+	for _, perm := range rolePermissions[r] {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SigningKey é uma chave privada usada para assinar tokens JWT, identificada pelo kid.
+// Chaves substituídas continuam publicadas até ExpiresAt, para validar tokens já emitidos.
+type SigningKey struct {
+	ID         string     `json:"kid" gorm:"primaryKey;type:varchar(64)"`
+	Algorithm  string     `json:"alg" gorm:"type:varchar(10);not null"`
+	PrivateKey string     `json:"-" gorm:"type:text;not null"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RetiredAt  *time.Time `json:"retired_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
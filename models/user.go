@@ -2,21 +2,46 @@ package models
 
 import (
 	"errors"
+	"net/mail"
 	"regexp"
 	"strings"
+	"time"
+	"unicode/utf8"
 
-	"golang.org/x/crypto/bcrypt"
+	"books_api/passhash"
+	"books_api/passpolicy"
 	"gorm.io/gorm"
 )
 
 type User struct {
-	ID       uint   `json:"id" gorm:"primaryKey"`
-	Username string `json:"username" gorm:"unique;not null"`
-	Password string `json:"-" gorm:"not null"`
+	ID                 uint      `json:"id" gorm:"primaryKey"`
+	Username           string    `json:"username" gorm:"unique;not null"`
+	Password           string    `json:"-" gorm:"not null"`
+	DisplayName        string    `json:"display_name"`
+	Email              string    `json:"email" gorm:"index"`
+	EmailVerified      bool      `json:"email_verified" gorm:"not null;default:false"`
+	Language           string    `json:"language" gorm:"type:varchar(5);not null;default:pt"`
+	Role               Role      `json:"role" gorm:"type:varchar(20);not null;default:reader"`
+	Disabled           bool      `json:"disabled" gorm:"not null;default:false"`
+	MustChangePassword bool      `json:"must_change_password" gorm:"not null;default:false"`
+	SessionVersion     uint      `json:"-" gorm:"not null;default:0"`
+	TOTPSecret         string    `json:"-"`
+	TOTPEnabled        bool      `json:"totp_enabled" gorm:"not null;default:false"`
+	TOTPLastStep       int64     `json:"-" gorm:"not null;default:0"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
 }
 
+// Validate verifica os dados de um novo usuário, incluindo a senha em texto puro.
 func (u *User) Validate() error {
+	if err := u.ValidateAccount(); err != nil {
+		return err
+	}
+	return ValidatePassword(u.Password, u.Username)
+}
 
+// ValidateAccount verifica nome de usuário, papel e perfil, sem validar a senha.
+func (u *User) ValidateAccount() error {
 	u.Username = strings.TrimSpace(u.Username)
 
 	if len(u.Username) < 3 || len(u.Username) > 30 {
@@ -26,36 +51,57 @@ func (u *User) Validate() error {
 		return errors.New("o nome de usuário só pode conter letras, números, pontos e traços")
 	}
 
-	if len(u.Password) < 8 {
-		return errors.New("a senha deve ter pelo menos 8 caracteres")
+	if u.Role != "" && !u.Role.Valid() {
+		return errors.New("papel de usuário inválido")
 	}
 
+	return ValidateProfile(u.DisplayName, u.Email)
+}
+
+// ValidatePassword verifica se a senha atende à política de senhas. As violações são retornadas
+// em um *passpolicy.ValidationError referente ao campo "password".
+func ValidatePassword(password, username string) error {
+	return passpolicy.Check("password", password, username)
+}
+
+// ValidateProfile verifica o nome de exibição e o e-mail informados pelo usuário.
+func ValidateProfile(displayName, email string) error {
+	if utf8.RuneCountInString(displayName) > 100 {
+		return errors.New("o nome de exibição deve ter no máximo 100 caracteres")
+	}
+	if email != "" {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return errors.New("e-mail inválido")
+		}
+	}
 	return nil
 }
 
+// HashPassword substitui a senha em texto puro pelo seu hash, no formato PHC.
 func (u *User) HashPassword() error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	hashedPassword, err := passhash.Hash(u.Password)
 	if err != nil {
 		return err
 	}
-	u.Password = string(hashedPassword)
+	u.Password = hashedPassword
 	return nil
 }
 
 // CheckPassword compara a senha fornecida com o hash armazenado.
 func (u *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
-	if err != nil {
-		// Apenas para debug
-		// fmt.Printf("Erro ao comparar hash: %v\n", err)
-	}
-	return err == nil
+	ok, err := passhash.Verify(password, u.Password)
+	return err == nil && ok
+}
+
+// PasswordNeedsRehash informa se o hash armazenado usa um algoritmo ou parâmetros desatualizados.
+func (u *User) PasswordNeedsRehash() bool {
+	return passhash.NeedsRehash(u.Password)
 }
 
+// BeforeCreate define o papel padrão. A senha já deve chegar com hash (veja HashPassword).
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
-	// Se a senha já tiver 60 caracteres, podemos supor que já foi hasheada.
-	if len(u.Password) != 60 {
-		return u.HashPassword()
+	if u.Role == "" {
+		u.Role = RoleReader
 	}
 	return nil
 }
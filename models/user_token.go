@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Finalidades dos tokens de uso único enviados por e-mail.
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+)
+
+// UserToken é um token de uso único associado a um usuário. Apenas o hash do token é armazenado.
+type UserToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	Purpose   string     `json:"purpose" gorm:"type:varchar(30);not null"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	Email     string     `json:"email"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	User User `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"books_api/mail"
+)
+
+// Eventos enviados aos usuários. Cada evento tem um modelo de e-mail de mesmo nome.
+const (
+	EventEmprestimoVencendo = mail.TemplateEmprestimoVencendo
+	EventEmprestimoAtrasado = mail.TemplateEmprestimoAtrasado
+	EventLivroNovo          = mail.TemplateLivroNovo
+)
+
+// Notification é um aviso destinado a um usuário. Data contém os campos do evento, usados tanto nos
+// modelos de e-mail quanto no corpo enviado aos webhooks.
+type Notification struct {
+	Event     string            `json:"evento"`
+	UserID    uint              `json:"usuario_id"`
+	Name      string            `json:"-"`
+	Email     string            `json:"-"`
+	Language  string            `json:"-"`
+	Data      map[string]string `json:"dados"`
+	CreatedAt time.Time         `json:"criado_em"`
+}
+
+// Notifier entrega notificações aos usuários.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NewNotifierFromEnv cria os canais configurados em NOTIFY_CHANNELS, separados por vírgula
+// ("email", "webhook" e "log"). Sem configuração, as notificações são apenas registradas no log.
+func NewNotifierFromEnv(mailer mail.Mailer) Notifier {
+	var channels Multi
+	for _, name := range strings.Split(os.Getenv("NOTIFY_CHANNELS"), ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+		case "email":
+			channels = append(channels, NewEmailNotifier(mailer))
+		case "webhook":
+			channels = append(channels, NewWebhookNotifier(os.Getenv("NOTIFY_WEBHOOK_URL"), os.Getenv("NOTIFY_WEBHOOK_SECRET"), nil))
+		case "log":
+			channels = append(channels, LogNotifier{})
+		default:
+			log.Printf("Canal de notificação desconhecido em NOTIFY_CHANNELS: %q", name)
+		}
+	}
+	if len(channels) == 0 {
+		return LogNotifier{}
+	}
+	if len(channels) == 1 {
+		return channels[0]
+	}
+	return channels
+}
+
+// Multi entrega a notificação em todos os canais, mesmo que algum deles falhe.
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LogNotifier registra as notificações no log da aplicação. Útil em desenvolvimento.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(_ context.Context, n Notification) error {
+	log.Printf("Notificação %s para o usuário %d: %v", n.Event, n.UserID, n.Data)
+	return nil
+}
+
+// EmailNotifier envia as notificações por e-mail usando os modelos do pacote mail.
+// Usuários sem e-mail cadastrado são ignorados.
+type EmailNotifier struct {
+	mailer mail.Mailer
+}
+
+func NewEmailNotifier(mailer mail.Mailer) *EmailNotifier {
+	return &EmailNotifier{mailer: mailer}
+}
+
+func (e *EmailNotifier) Notify(ctx context.Context, n Notification) error {
+	if n.Email == "" {
+		return nil
+	}
+	data := make(map[string]string, len(n.Data)+1)
+	for k, v := range n.Data {
+		data[k] = v
+	}
+	data["nome"] = n.Name
+
+	msg, err := mail.Render(n.Language, n.Event, n.Email, data)
+	if err != nil {
+		return err
+	}
+	return e.mailer.Send(ctx, msg)
+}
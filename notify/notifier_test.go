@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"books_api/mail"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingMailer struct {
+	sent []mail.Message
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(context.Context, Notification) error {
+	return errors.New("falhou")
+}
+
+func testNotification() Notification {
+	return Notification{
+		Event:    EventEmprestimoAtrasado,
+		UserID:   7,
+		Name:     "Ana",
+		Email:    "ana@example.com",
+		Language: "pt",
+		Data: map[string]string{
+			"livro":         "Dom Casmurro",
+			"data_prevista": "01/03/2026",
+			"dias_atraso":   "3",
+			"multa":         "R$ 3,00",
+		},
+	}
+}
+
+func TestEmailNotifierRendersTemplate(t *testing.T) {
+	mailer := &recordingMailer{}
+	require.NoError(t, NewEmailNotifier(mailer).Notify(context.Background(), testNotification()))
+
+	require.Len(t, mailer.sent, 1)
+	msg := mailer.sent[0]
+	assert.Equal(t, "ana@example.com", msg.To)
+	assert.Equal(t, "Empréstimo em atraso", msg.Subject)
+	assert.Contains(t, msg.Body, "Olá, Ana!")
+	assert.Contains(t, msg.Body, `"Dom Casmurro"`)
+	assert.Contains(t, msg.Body, "3 dia(s) em atraso")
+	assert.Contains(t, msg.Body, "R$ 3,00")
+}
+
+func TestEmailNotifierSkipsUsersWithoutEmail(t *testing.T) {
+	mailer := &recordingMailer{}
+	n := testNotification()
+	n.Email = ""
+	require.NoError(t, NewEmailNotifier(mailer).Notify(context.Background(), n))
+	assert.Empty(t, mailer.sent)
+}
+
+func TestWebhookNotifierSignsBody(t *testing.T) {
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	require.NoError(t, NewWebhookNotifier(server.URL, "segredo", nil).Notify(context.Background(), testNotification()))
+
+	assert.Equal(t, "sha256="+Sign("segredo", body), signature)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, EventEmprestimoAtrasado, payload["evento"])
+	assert.EqualValues(t, 7, payload["usuario_id"])
+	assert.NotContains(t, payload, "Email")
+}
+
+func TestWebhookNotifierFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	assert.Error(t, NewWebhookNotifier(server.URL, "", nil).Notify(context.Background(), testNotification()))
+}
+
+func TestMultiDeliversToAllChannels(t *testing.T) {
+	mailer := &recordingMailer{}
+	err := Multi{failingNotifier{}, NewEmailNotifier(mailer)}.Notify(context.Background(), testNotification())
+
+	assert.Error(t, err)
+	assert.Len(t, mailer.sent, 1)
+}
+
+func TestNewNotifierFromEnv(t *testing.T) {
+	t.Setenv("NOTIFY_CHANNELS", "")
+	assert.IsType(t, LogNotifier{}, NewNotifierFromEnv(&recordingMailer{}))
+
+	t.Setenv("NOTIFY_CHANNELS", "email")
+	assert.IsType(t, &EmailNotifier{}, NewNotifierFromEnv(&recordingMailer{}))
+
+	t.Setenv("NOTIFY_CHANNELS", "email, webhook")
+	assert.IsType(t, Multi{}, NewNotifierFromEnv(&recordingMailer{}))
+}
@@ -0,0 +1,190 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// mockServer é um provedor OIDC mínimo: descoberta, JWKS e endpoint de token com PKCE.
+type mockServer struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+	// challenge e nonce são registrados a partir da URL de autorização.
+	challenge string
+	nonce     string
+	audience  string
+}
+
+func newMockServer(t *testing.T) *mockServer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockServer{key: key, clientID: "client-id"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "good-code" || codeChallenge(r.Form.Get("code_verifier")) != m.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != m.clientID || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		aud := m.audience
+		if aud == "" {
+			aud = m.clientID
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            m.URL,
+			"aud":            aud,
+			"sub":            "user-123",
+			"email":          "ana@example.com",
+			"email_verified": true,
+			"name":           "Ana",
+			"nonce":          m.nonce,
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"iat":            time.Now().Unix(),
+		})
+		token.Header["kid"] = "k1"
+		signed, _ := token.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "x", "id_token": signed})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// authorize simula o redirecionamento ao provedor, registrando desafio PKCE e nonce.
+func (m *mockServer) authorize(t *testing.T, p *Provider, verifier, nonce string) {
+	t.Helper()
+	raw, err := p.AuthCodeURL(context.Background(), "state", nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	u, _ := url.Parse(raw)
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != m.clientID || q.Get("state") != "state" {
+		t.Fatalf("URL de autorização inesperada: %s", raw)
+	}
+	m.challenge = q.Get("code_challenge")
+	m.nonce = q.Get("nonce")
+}
+
+func newTestProvider(m *mockServer) *Provider {
+	return NewProvider(Config{
+		Name:         "mock",
+		Issuer:       m.URL,
+		ClientID:     m.clientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/callback",
+	}, m.Client())
+}
+
+func TestExchange(t *testing.T) {
+	m := newMockServer(t)
+	p := newTestProvider(m)
+	m.authorize(t, p, "verifier", "nonce")
+
+	claims, err := p.Exchange(context.Background(), "good-code", "verifier", "nonce")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if claims.Subject != "user-123" || claims.Email != "ana@example.com" || !claims.EmailVerified || claims.Name != "Ana" {
+		t.Errorf("claims inesperadas: %+v", claims)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	m := newMockServer(t)
+	p := newTestProvider(m)
+	m.authorize(t, p, "verifier", "nonce")
+
+	if _, err := p.Exchange(context.Background(), "good-code", "outro", "nonce"); err == nil {
+		t.Fatal("esperava erro com verificador PKCE incorreto")
+	}
+}
+
+func TestExchangeRejectsWrongNonce(t *testing.T) {
+	m := newMockServer(t)
+	p := newTestProvider(m)
+	m.authorize(t, p, "verifier", "nonce")
+
+	_, err := p.Exchange(context.Background(), "good-code", "verifier", "outro-nonce")
+	if !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("esperava ErrInvalidIDToken, obteve %v", err)
+	}
+}
+
+func TestExchangeRejectsWrongAudience(t *testing.T) {
+	m := newMockServer(t)
+	m.audience = "outro-cliente"
+	p := newTestProvider(m)
+	m.authorize(t, p, "verifier", "nonce")
+
+	_, err := p.Exchange(context.Background(), "good-code", "verifier", "nonce")
+	if !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("esperava ErrInvalidIDToken, obteve %v", err)
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	m := newMockServer(t)
+	p := NewProvider(Config{Name: "mock", Issuer: m.URL + "/outro", ClientID: m.clientID}, m.Client())
+
+	if _, err := p.AuthCodeURL(context.Background(), "s", "n", "v"); err == nil {
+		t.Fatal("esperava erro com emissor divergente")
+	}
+}
+
+func TestLoadProvidersFromEnv(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", "empresa, incompleto")
+	t.Setenv("OIDC_EMPRESA_ISSUER", "https://sso.example.com/")
+	t.Setenv("OIDC_EMPRESA_CLIENT_ID", "books")
+	t.Setenv("OIDC_EMPRESA_AUTO_CREATE", "false")
+	t.Setenv("OIDC_EMPRESA_DEFAULT_ROLE", " Editor")
+
+	providers := LoadProvidersFromEnv("https://api.example.com")
+	if len(providers) != 1 {
+		t.Fatalf("esperava 1 provedor, obteve %d", len(providers))
+	}
+	p := providers["empresa"]
+	if p.Issuer != "https://sso.example.com" || p.AutoCreate || p.DefaultRole != "editor" || p.RedirectURL != "https://api.example.com/auth/oidc/empresa/callback" {
+		t.Errorf("configuração inesperada: %+v", p.Config)
+	}
+}
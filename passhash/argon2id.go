@@ -0,0 +1,115 @@
+package passhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Params são os parâmetros de custo do argon2id.
+type Params struct {
+	// Memory em KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams seguem a recomendação da OWASP para argon2id (19 MiB, 2 iterações).
+var DefaultParams = Params{Memory: 19 * 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+// ParamsFromEnv lê PASSWORD_ARGON2_MEMORY (KiB), PASSWORD_ARGON2_ITERATIONS e PASSWORD_ARGON2_PARALLELISM.
+func ParamsFromEnv() Params {
+	p := DefaultParams
+	p.Memory = envUint("PASSWORD_ARGON2_MEMORY", p.Memory)
+	p.Iterations = envUint("PASSWORD_ARGON2_ITERATIONS", p.Iterations)
+	p.Parallelism = uint8(envUint("PASSWORD_ARGON2_PARALLELISM", uint32(p.Parallelism)))
+	return p
+}
+
+// Argon2id gera hashes no formato PHC: $argon2id$v=19$m=...,t=...,p=...$salt$hash.
+type Argon2id struct {
+	Params Params
+}
+
+func NewArgon2id(p Params) *Argon2id {
+	return &Argon2id{Params: p}
+}
+
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.Params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Params.Iterations, a.Params.Memory, a.Params.Parallelism, a.Params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		a.Params.Memory, a.Params.Iterations, a.Params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (a *Argon2id) Verify(password, encoded string) (bool, error) {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (a *Argon2id) Identifies(encoded string) bool {
+	_, _, _, err := decodeArgon2id(encoded)
+	return err == nil
+}
+
+func (a *Argon2id) NeedsRehash(encoded string) bool {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return p.Memory != a.Params.Memory || p.Iterations != a.Params.Iterations ||
+		p.Parallelism != a.Params.Parallelism || uint32(len(salt)) != a.Params.SaltLength ||
+		uint32(len(key)) != a.Params.KeyLength
+}
+
+// decodeArgon2id lê os parâmetros, o salt e a chave de um hash argon2id em formato PHC.
+func decodeArgon2id(encoded string) (Params, []byte, []byte, error) {
+	var p Params
+	if !strings.HasPrefix(encoded, argon2idPrefix) {
+		return p, nil, nil, ErrUnknownFormat
+	}
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrUnknownFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("versão de argon2 não suportada: %s", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	if p.Memory == 0 || p.Iterations == 0 || p.Parallelism == 0 {
+		return p, nil, nil, ErrUnknownFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) == 0 {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,96 @@
+// Package passhash gera e verifica hashes de senha no formato PHC. Novos hashes usam argon2id;
+// hashes bcrypt antigos continuam aceitos e são substituídos no próximo login.
+package passhash
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+)
+
+var ErrUnknownFormat = errors.New("formato de hash de senha desconhecido")
+
+// Hasher é um algoritmo de hash de senha.
+type Hasher interface {
+	// Hash gera o hash codificado da senha.
+	Hash(password string) (string, error)
+	// Verify compara a senha com um hash gerado por este algoritmo.
+	Verify(password, encoded string) (bool, error)
+	// Identifies informa se o hash foi gerado por este algoritmo.
+	Identifies(encoded string) bool
+	// NeedsRehash informa se o hash usa parâmetros diferentes dos atuais.
+	NeedsRehash(encoded string) bool
+}
+
+var (
+	mu sync.RWMutex
+	// current gera os novos hashes; os demais formatos conhecidos são usados apenas na verificação.
+	current Hasher = NewArgon2id(DefaultParams)
+)
+
+// SetDefault substitui o algoritmo usado para gerar novos hashes.
+func SetDefault(h Hasher) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = h
+}
+
+// Hash gera o hash da senha com o algoritmo padrão.
+func Hash(password string) (string, error) {
+	return defaultHasher().Hash(password)
+}
+
+// Verify compara a senha com o hash armazenado, seja qual for o algoritmo que o gerou.
+func Verify(password, encoded string) (bool, error) {
+	h := find(encoded)
+	if h == nil {
+		return false, ErrUnknownFormat
+	}
+	return h.Verify(password, encoded)
+}
+
+// NeedsRehash informa se o hash deve ser regerado com o algoritmo e os parâmetros atuais.
+func NeedsRehash(encoded string) bool {
+	h := defaultHasher()
+	if !h.Identifies(encoded) {
+		return true
+	}
+	return h.NeedsRehash(encoded)
+}
+
+// IsHash informa se o valor é um hash em um formato conhecido.
+func IsHash(encoded string) bool {
+	return find(encoded) != nil
+}
+
+func defaultHasher() Hasher {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// find retorna o algoritmo capaz de verificar o hash. A verificação usa os parâmetros gravados
+// no próprio hash, e não os configurados.
+func find(encoded string) Hasher {
+	for _, h := range []Hasher{defaultHasher(), &Argon2id{}, Bcrypt{}} {
+		if h.Identifies(encoded) {
+			return h
+		}
+	}
+	return nil
+}
+
+func envUint(name string, def uint32) uint32 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || n == 0 {
+		log.Printf("Valor inválido para %s: %q, usando %d", name, value, def)
+		return def
+	}
+	return uint32(n)
+}
@@ -0,0 +1,83 @@
+package passhash
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var fastParams = Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHashAndVerify(t *testing.T) {
+	h := NewArgon2id(fastParams)
+	hash, err := h.Hash("senha-secreta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("hash fora do formato PHC: %s", hash)
+	}
+
+	if ok, err := h.Verify("senha-secreta", hash); err != nil || !ok {
+		t.Errorf("senha correta recusada: %v", err)
+	}
+	if ok, _ := h.Verify("outra", hash); ok {
+		t.Error("senha incorreta aceita")
+	}
+}
+
+func TestVerifyBcrypt(t *testing.T) {
+	SetDefault(NewArgon2id(fastParams))
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("senha-antiga"), bcrypt.MinCost)
+
+	if ok, err := Verify("senha-antiga", string(legacy)); err != nil || !ok {
+		t.Errorf("hash bcrypt não verificado: %v", err)
+	}
+	if ok, _ := Verify("errada", string(legacy)); ok {
+		t.Error("senha incorreta aceita em hash bcrypt")
+	}
+	if !NeedsRehash(string(legacy)) {
+		t.Error("hash bcrypt deveria ser regerado")
+	}
+}
+
+func TestNeedsRehashOnParamChange(t *testing.T) {
+	SetDefault(NewArgon2id(fastParams))
+	hash, _ := Hash("senha-secreta")
+	if NeedsRehash(hash) {
+		t.Fatal("hash com parâmetros atuais não deveria ser regerado")
+	}
+
+	stronger := fastParams
+	stronger.Iterations = 2
+	SetDefault(NewArgon2id(stronger))
+	defer SetDefault(NewArgon2id(fastParams))
+
+	if !NeedsRehash(hash) {
+		t.Error("hash com parâmetros antigos deveria ser regerado")
+	}
+	// Hashes antigos continuam válidos até serem regerados.
+	if ok, _ := Verify("senha-secreta", hash); !ok {
+		t.Error("hash com parâmetros antigos recusado")
+	}
+}
+
+func TestIsHash(t *testing.T) {
+	argon, _ := NewArgon2id(fastParams).Hash("x")
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("x"), bcrypt.MinCost)
+
+	for _, value := range []string{argon, string(legacy)} {
+		if !IsHash(value) {
+			t.Errorf("hash não reconhecido: %s", value)
+		}
+	}
+	for _, value := range []string{"", "senha-com-60-caracteres-aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "$argon2id$v=19$invalido"} {
+		if IsHash(value) {
+			t.Errorf("texto puro reconhecido como hash: %q", value)
+		}
+	}
+	if _, err := Verify("x", "texto-puro"); err != ErrUnknownFormat {
+		t.Errorf("esperava ErrUnknownFormat, obteve %v", err)
+	}
+}
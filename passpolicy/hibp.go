@@ -0,0 +1,174 @@
+package passpolicy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	hibpPrefixLength = 5
+	sha1HexLength    = 40
+)
+
+// HIBPFile consulta, sem acesso à rede, uma cópia local da base Pwned Passwords (SHA-1).
+// Dois formatos são aceitos:
+//   - um diretório com um arquivo por prefixo de 5 caracteres (ex.: 21BD1.txt), cada linha no
+//     formato da API de intervalos "SUFIXO:CONTAGEM";
+//   - um único arquivo ordenado com linhas "HASH:CONTAGEM", como o gerado pelo
+//     haveibeenpwned-downloader, consultado por busca binária.
+type HIBPFile struct {
+	Path string
+	// MinCount é a quantidade mínima de ocorrências para considerar a senha vazada.
+	MinCount int
+	dir      bool
+}
+
+// NewHIBPFile abre a base em path, que pode ser um diretório de intervalos ou um arquivo ordenado.
+func NewHIBPFile(path string, minCount int) (*HIBPFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if minCount < 1 {
+		minCount = 1
+	}
+	return &HIBPFile{Path: path, MinCount: minCount, dir: info.IsDir()}, nil
+}
+
+// Breached informa se o SHA-1 da senha consta na base com pelo menos MinCount ocorrências.
+func (h *HIBPFile) Breached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	var count int
+	var err error
+	if h.dir {
+		count, err = h.lookupRange(hash)
+	} else {
+		count, err = h.lookupSorted(hash)
+	}
+	if err != nil {
+		return false, err
+	}
+	return count >= h.MinCount, nil
+}
+
+// lookupRange procura o sufixo do hash no arquivo do seu prefixo (k-anonimato).
+func (h *HIBPFile) lookupRange(hash string) (int, error) {
+	prefix, suffix := hash[:hibpPrefixLength], hash[hibpPrefixLength:]
+
+	f, err := os.Open(filepath.Join(h.Path, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		f, err = os.Open(filepath.Join(h.Path, prefix))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineSuffix, count, ok := parseLine(scanner.Text())
+		if ok && strings.EqualFold(lineSuffix, suffix) {
+			return count, nil
+		}
+	}
+	return 0, scanner.Err()
+}
+
+// lookupSorted faz uma busca binária por linhas no arquivo ordenado pelo hash.
+func (h *HIBPFile) lookupSorted(hash string) (int, error) {
+	f, err := os.Open(h.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	lo, hi := int64(0), info.Size()
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, line, err := lineAfter(f, mid)
+		if err != nil {
+			return 0, err
+		}
+		if start >= hi || line == "" {
+			hi = mid
+			continue
+		}
+
+		lineHash, count, ok := parseLine(line)
+		if !ok || len(lineHash) != sha1HexLength {
+			return 0, fmt.Errorf("linha inválida na base de senhas vazadas na posição %d", start)
+		}
+		switch cmp := strings.Compare(strings.ToUpper(lineHash), hash); {
+		case cmp == 0:
+			return count, nil
+		case cmp < 0:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = mid
+		}
+	}
+	return 0, nil
+}
+
+// lineAfter retorna a primeira linha completa que começa em offset ou depois dele.
+func lineAfter(f *os.File, offset int64) (int64, string, error) {
+	start := offset
+	if offset > 0 {
+		// Lê a partir do byte anterior para saber se offset já é início de linha.
+		start = offset - 1
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(f, start, 1<<62))
+	if offset > 0 {
+		skipped, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return start + int64(len(skipped)), "", nil
+		}
+		if err != nil {
+			return 0, "", err
+		}
+		start += int64(len(skipped))
+	}
+
+	line, err := reader.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return 0, "", err
+	}
+	return start, string(bytes.TrimRight(line, "\r\n")), nil
+}
+
+// parseLine lê uma linha "HASH:CONTAGEM". A contagem é opcional.
+func parseLine(line string) (string, int, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", 0, false
+	}
+	hash, rawCount, found := strings.Cut(line, ":")
+	if !found {
+		return hash, 1, true
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(rawCount))
+	if err != nil {
+		return "", 0, false
+	}
+	return hash, count, true
+}
@@ -0,0 +1,232 @@
+// Package passpolicy define os requisitos para novas senhas: tamanho, variedade de caracteres,
+// entropia estimada, ausência do nome de usuário e ausência em listas de senhas vazadas.
+package passpolicy
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Códigos das violações retornadas em ValidationError.
+const (
+	CodeTooShort         = "too_short"
+	CodeTooLong          = "too_long"
+	CodeMissingClasses   = "missing_classes"
+	CodeLowEntropy       = "low_entropy"
+	CodeContainsUsername = "contains_username"
+	CodeBreached         = "breached"
+)
+
+// Violation é um requisito não atendido por um campo.
+type Violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError reúne todas as violações encontradas em uma senha.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// BreachChecker informa se uma senha consta em uma base de senhas vazadas.
+type BreachChecker interface {
+	Breached(password string) (bool, error)
+}
+
+// Policy são os requisitos aplicados às novas senhas. Valores zero desativam a regra correspondente.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	// MinClasses é a quantidade mínima de classes de caracteres distintas
+	// (minúsculas, maiúsculas, dígitos e símbolos).
+	MinClasses int
+	// MinEntropy é a entropia mínima estimada, em bits.
+	MinEntropy float64
+	// DisallowUsername recusa senhas que contenham o nome de usuário.
+	DisallowUsername bool
+	// Breached é opcional; sem ele a senha não é comparada com senhas vazadas.
+	Breached BreachChecker
+}
+
+// DefaultPolicy mantém o requisito histórico de 8 caracteres e proíbe o nome de usuário na senha.
+var DefaultPolicy = Policy{MinLength: 8, MaxLength: 128, DisallowUsername: true}
+
+// FromEnv lê PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH, PASSWORD_MIN_CLASSES, PASSWORD_MIN_ENTROPY,
+// PASSWORD_DISALLOW_USERNAME, PASSWORD_BREACHED_PATH e PASSWORD_BREACHED_MIN_COUNT.
+func FromEnv() Policy {
+	p := DefaultPolicy
+	p.MinLength = envInt("PASSWORD_MIN_LENGTH", p.MinLength)
+	p.MaxLength = envInt("PASSWORD_MAX_LENGTH", p.MaxLength)
+	p.MinClasses = envInt("PASSWORD_MIN_CLASSES", p.MinClasses)
+	p.MinEntropy = float64(envInt("PASSWORD_MIN_ENTROPY", int(p.MinEntropy)))
+	if value := os.Getenv("PASSWORD_DISALLOW_USERNAME"); value != "" {
+		p.DisallowUsername, _ = strconv.ParseBool(value)
+	}
+
+	if path := os.Getenv("PASSWORD_BREACHED_PATH"); path != "" {
+		checker, err := NewHIBPFile(path, envInt("PASSWORD_BREACHED_MIN_COUNT", 1))
+		if err != nil {
+			log.Printf("Base de senhas vazadas indisponível: %v", err)
+		} else {
+			p.Breached = checker
+		}
+	}
+	return p
+}
+
+var (
+	mu      sync.RWMutex
+	current = DefaultPolicy
+)
+
+// SetDefault substitui a política usada por Check.
+func SetDefault(p Policy) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = p
+}
+
+// Check valida a senha com a política padrão. field é o nome do campo usado nas violações.
+func Check(field, password, username string) error {
+	mu.RLock()
+	p := current
+	mu.RUnlock()
+	return p.Check(field, password, username)
+}
+
+// Check retorna um *ValidationError com todas as regras não atendidas ou nil se a senha for aceita.
+func (p Policy) Check(field, password, username string) error {
+	var violations []Violation
+	add := func(code, message string) {
+		violations = append(violations, Violation{Field: field, Code: code, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		add(CodeTooShort, fmt.Sprintf("a senha deve ter pelo menos %d caracteres", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		add(CodeTooLong, fmt.Sprintf("a senha deve ter no máximo %d caracteres", p.MaxLength))
+	}
+	if p.MinClasses > 0 && characterClasses(password) < p.MinClasses {
+		add(CodeMissingClasses, fmt.Sprintf("a senha deve combinar pelo menos %d tipos de caracteres entre minúsculas, maiúsculas, números e símbolos", p.MinClasses))
+	}
+	if p.MinEntropy > 0 && Entropy(password) < p.MinEntropy {
+		add(CodeLowEntropy, "a senha é fácil de adivinhar; use uma senha mais longa ou variada")
+	}
+
+	username = strings.TrimSpace(username)
+	if p.DisallowUsername && len(username) >= 3 && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		add(CodeContainsUsername, "a senha não pode conter o nome de usuário")
+	}
+
+	// A consulta à base de vazamentos é a regra mais custosa e só é feita se as demais passarem.
+	if len(violations) == 0 && p.Breached != nil {
+		breached, err := p.Breached.Breached(password)
+		if err != nil {
+			log.Printf("Erro ao consultar base de senhas vazadas: %v", err)
+		} else if breached {
+			add(CodeBreached, "esta senha aparece em vazamentos de dados conhecidos; escolha outra")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// Entropy estima a entropia da senha em bits a partir do tamanho do alfabeto das classes usadas.
+// Caracteres repetidos contam apenas uma vez além da primeira ocorrência, penalizando senhas
+// como "aaaaaaaa".
+func Entropy(password string) float64 {
+	pool := 0
+	var lower, upper, digit, symbol, other bool
+	unique := make(map[rune]bool)
+	for _, r := range password {
+		unique[r] = true
+		switch {
+		case r < utf8.RuneSelf && unicode.IsLower(r):
+			lower = true
+		case r < utf8.RuneSelf && unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case r < utf8.RuneSelf:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+	for _, used := range []struct {
+		ok   bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if used.ok {
+			pool += used.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	length := utf8.RuneCountInString(password)
+	if effective := 2 * len(unique); effective < length {
+		length = effective
+	}
+	return float64(length) * math.Log2(float64(pool))
+}
+
+// characterClasses conta as classes de caracteres presentes na senha.
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			count++
+		}
+	}
+	return count
+}
+
+func envInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("Valor inválido para %s: %q, usando %d", name, value, def)
+		return def
+	}
+	return n
+}
@@ -0,0 +1,129 @@
+package passpolicy
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func codes(err error) []string {
+	var policyErr *ValidationError
+	if !errors.As(err, &policyErr) {
+		return nil
+	}
+	var result []string
+	for _, v := range policyErr.Violations {
+		result = append(result, v.Code)
+	}
+	return result
+}
+
+func TestPolicyCheck(t *testing.T) {
+	p := Policy{MinLength: 10, MaxLength: 20, MinClasses: 3, MinEntropy: 40, DisallowUsername: true}
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"válida", "Livros-de-2024", nil},
+		{"curta", "Ab1-", []string{CodeTooShort, CodeLowEntropy}},
+		{"longa", "Abcdefghij-1234567890x", []string{CodeTooLong}},
+		{"poucas classes", "somenteminusculas", []string{CodeMissingClasses}},
+		{"repetitiva", "aaaaaaaaaaA1", []string{CodeLowEntropy}},
+		{"contém usuário", "xMariaSilva-9", []string{CodeContainsUsername}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := codes(p.Check("password", tt.password, "mariasilva"))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("violações = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViolationField(t *testing.T) {
+	err := DefaultPolicy.Check("nova_senha", "curta", "")
+	var policyErr *ValidationError
+	if !errors.As(err, &policyErr) || policyErr.Violations[0].Field != "nova_senha" {
+		t.Fatalf("esperava violação no campo nova_senha, obteve %v", err)
+	}
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestHIBPRangeDirectory(t *testing.T) {
+	dir := t.TempDir()
+	hash := sha1Hex("password123")
+	content := "0000000000000000000000000000000000A:3\r\n" + hash[5:] + ":12\r\n"
+	if err := os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	checker, err := NewHIBPFile(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBreached(t, checker, "password123", true)
+	assertBreached(t, checker, "outra-senha-qualquer", false)
+
+	checker.MinCount = 20
+	assertBreached(t, checker, "password123", false)
+}
+
+func TestHIBPSortedFile(t *testing.T) {
+	passwords := []string{"123456", "password", "qwerty", "letmein", "dragon", "monkey", "abc123", "iloveyou"}
+	var lines []string
+	for i, p := range passwords {
+		lines = append(lines, sha1Hex(p)+":"+strings.Repeat("9", i+1))
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	checker, err := NewHIBPFile(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range passwords {
+		assertBreached(t, checker, p, true)
+	}
+	for _, p := range []string{"não-vazada", "", "Livros-de-2024"} {
+		assertBreached(t, checker, p, false)
+	}
+}
+
+func TestBreachedRuleRunsLast(t *testing.T) {
+	dir := t.TempDir()
+	hash := sha1Hex("senha-vazada")
+	os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(hash[5:]+":5\n"), 0o600)
+	checker, _ := NewHIBPFile(dir, 1)
+
+	p := Policy{MinLength: 8, Breached: checker}
+	if got := codes(p.Check("password", "senha-vazada", "")); len(got) != 1 || got[0] != CodeBreached {
+		t.Errorf("violações = %v, esperado [%s]", got, CodeBreached)
+	}
+}
+
+func assertBreached(t *testing.T, checker *HIBPFile, password string, want bool) {
+	t.Helper()
+	got, err := checker.Breached(password)
+	if err != nil {
+		t.Fatalf("Breached(%q): %v", password, err)
+	}
+	if got != want {
+		t.Errorf("Breached(%q) = %v, esperado %v", password, got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"books_api/models"
+	"gorm.io/gorm"
+)
+
+var ErrAPIKeyNotFound = errors.New("chave de API não encontrada")
+
+type APIKeyRepository struct {
+	DB *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{DB: db}
+}
+
+// Create grava uma nova chave de API.
+func (r *APIKeyRepository) Create(key *models.APIKey) error {
+	return r.DB.Create(key).Error
+}
+
+// FindByHash busca uma chave de API pelo hash.
+func (r *APIKeyRepository) FindByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.DB.Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByUser retorna as chaves de API de um usuário, da mais recente para a mais antiga.
+func (r *APIKeyRepository) ListByUser(userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// Revoke revoga uma chave de API do usuário.
+func (r *APIKeyRepository) Revoke(userID, id uint) error {
+	result := r.DB.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed registra o uso da chave.
+func (r *APIKeyRepository) TouchLastUsed(id uint, at time.Time) error {
+	return r.DB.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"books_api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAvaliacaoNotFound  = errors.New("avaliação não encontrada")
+	ErrAvaliacaoDuplicada = errors.New("usuário já avaliou este livro")
+)
+
+// Ordenações aceitas na listagem de avaliações.
+var avaliacaoOrdens = map[string]string{
+	"":          "created_at DESC",
+	"recentes":  "created_at DESC",
+	"nota":      "nota DESC, created_at DESC",
+	"nota_asc":  "nota ASC, created_at DESC",
+	"antigas":   "created_at ASC",
+	"denuncias": "sinalizacoes DESC, created_at ASC",
+}
+
+type AvaliacaoRepository struct {
+	DB *gorm.DB
+	// Livros, se definido, tem o cache do livro descartado quando a média de avaliações muda.
+	Livros InvalidadorLivros
+}
+
+// AvaliacaoFilter define os critérios de busca e paginação da listagem de avaliações.
+type AvaliacaoFilter struct {
+	LivroID *uint
+	UserID  *uint
+	// IncluirOcultas inclui as avaliações ocultas pela moderação.
+	IncluirOcultas bool
+	// Sinalizadas restringe a listagem às avaliações denunciadas e ainda não moderadas.
+	Sinalizadas bool
+	Ordenar     string
+	Page        int
+	Limit       int
+}
+
+func NewAvaliacaoRepository(db *gorm.DB) *AvaliacaoRepository {
+	return &AvaliacaoRepository{DB: db}
+}
+
+// ValidAvaliacaoOrdem informa se a ordenação da listagem de avaliações é conhecida.
+func ValidAvaliacaoOrdem(ordem string) bool {
+	_, ok := avaliacaoOrdens[ordem]
+	return ok
+}
+
+// Create grava a avaliação e atualiza a média do livro. Cada usuário avalia um livro uma única vez.
+func (r *AvaliacaoRepository) Create(avaliacao *models.Avaliacao) error {
+	return r.alterarLivro(avaliacao.LivroID, func(tx *gorm.DB) error {
+		var existentes int64
+		if err := tx.Model(&models.Avaliacao{}).
+			Where("user_id = ? AND livro_id = ?", avaliacao.UserID, avaliacao.LivroID).
+			Count(&existentes).Error; err != nil {
+			return err
+		}
+		if existentes > 0 {
+			return ErrAvaliacaoDuplicada
+		}
+		return tx.Create(avaliacao).Error
+	})
+}
+
+// FindByID busca uma avaliação pelo ID.
+func (r *AvaliacaoRepository) FindByID(id uint) (*models.Avaliacao, error) {
+	var avaliacao models.Avaliacao
+	if err := r.DB.Preload("User").First(&avaliacao, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAvaliacaoNotFound
+		}
+		return nil, err
+	}
+	preencherAutor(&avaliacao)
+	return &avaliacao, nil
+}
+
+// Update altera a nota e o texto de uma avaliação, registrando a edição.
+func (r *AvaliacaoRepository) Update(id uint, nota int, texto string, at time.Time) (*models.Avaliacao, error) {
+	atual, err := r.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	err = r.alterarLivro(atual.LivroID, func(tx *gorm.DB) error {
+		return tx.Model(&models.Avaliacao{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"nota":       nota,
+			"texto":      texto,
+			"editada_em": at,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(id)
+}
+
+// Delete remove uma avaliação e atualiza a média do livro.
+func (r *AvaliacaoRepository) Delete(id uint) error {
+	atual, err := r.FindByID(id)
+	if err != nil {
+		return err
+	}
+	return r.alterarLivro(atual.LivroID, func(tx *gorm.DB) error {
+		return tx.Delete(&models.Avaliacao{}, id).Error
+	})
+}
+
+// Moderar oculta ou reexibe uma avaliação. As denúncias são zeradas, pois já foram analisadas.
+func (r *AvaliacaoRepository) Moderar(id uint, oculta bool, motivo string) (*models.Avaliacao, error) {
+	atual, err := r.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !oculta {
+		motivo = ""
+	}
+	err = r.alterarLivro(atual.LivroID, func(tx *gorm.DB) error {
+		return tx.Model(&models.Avaliacao{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"oculta":        oculta,
+			"motivo_oculta": motivo,
+			"sinalizacoes":  0,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(id)
+}
+
+// Sinalizar registra a denúncia da avaliação pelo usuário. Denúncias repetidas do mesmo usuário são ignoradas.
+func (r *AvaliacaoRepository) Sinalizar(avaliacaoID, userID uint, motivo string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SinalizacaoAvaliacao{
+			AvaliacaoID: avaliacaoID,
+			UserID:      userID,
+			Motivo:      motivo,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return tx.Model(&models.Avaliacao{}).Where("id = ?", avaliacaoID).
+			Update("sinalizacoes", gorm.Expr("sinalizacoes + 1")).Error
+	})
+}
+
+// List retorna uma página de avaliações que atendem ao filtro e o total de registros encontrados.
+func (r *AvaliacaoRepository) List(filter AvaliacaoFilter) ([]models.Avaliacao, int64, error) {
+	query := r.DB.Model(&models.Avaliacao{})
+	if filter.LivroID != nil {
+		query = query.Where("livro_id = ?", *filter.LivroID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if !filter.IncluirOcultas {
+		query = query.Where("oculta = ?", false)
+	}
+	if filter.Sinalizadas {
+		query = query.Where("sinalizacoes > 0")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var avaliacoes []models.Avaliacao
+	err := query.Preload("User").
+		Order(avaliacaoOrdens[filter.Ordenar]).
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&avaliacoes).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range avaliacoes {
+		preencherAutor(&avaliacoes[i])
+	}
+	return avaliacoes, total, nil
+}
+
+// alterarLivro executa a alteração com o livro bloqueado e recalcula a média e o total de avaliações
+// visíveis na mesma transação. Em seguida, descarta o livro do cache.
+func (r *AvaliacaoRepository) alterarLivro(livroID uint, fn func(tx *gorm.DB) error) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockLivro(tx, livroID); err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		var resumo struct {
+			Media float64
+			Total int
+		}
+		if err := tx.Model(&models.Avaliacao{}).
+			Select("COALESCE(ROUND(AVG(nota), 2), 0) AS media, COUNT(*) AS total").
+			Where("livro_id = ? AND oculta = ?", livroID, false).
+			Scan(&resumo).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Livro{}).Where("id = ?", livroID).Updates(map[string]interface{}{
+			"media_avaliacoes": resumo.Media,
+			"total_avaliacoes": resumo.Total,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if r.Livros != nil {
+		if err := r.Livros.InvalidarLivro(context.Background(), livroID); err != nil {
+			log.Printf("Erro ao invalidar cache do livro %d: %v", livroID, err)
+		}
+	}
+	return nil
+}
+
+// preencherAutor define o nome exibido de quem fez a avaliação.
+func preencherAutor(avaliacao *models.Avaliacao) {
+	avaliacao.Autor = avaliacao.User.DisplayName
+	if avaliacao.Autor == "" {
+		avaliacao.Autor = avaliacao.User.Username
+	}
+}
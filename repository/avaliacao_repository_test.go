@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"books_api/models"
+	"books_api/repository/repotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// livrosInvalidados registra os livros descartados do cache.
+type livrosInvalidados []uint
+
+func (l *livrosInvalidados) InvalidarLivro(_ context.Context, id uint) error {
+	*l = append(*l, id)
+	return nil
+}
+
+// resumoLivro retorna a média e o total de avaliações gravados no livro.
+func resumoLivro(t *testing.T, db *gorm.DB, id uint) (float64, int) {
+	t.Helper()
+	var livro models.Livro
+	require.NoError(t, db.First(&livro, id).Error)
+	return livro.MediaAvaliacoes, livro.TotalAvaliacoes
+}
+
+func TestAvaliacaoRepositoryAtualizaMedia(t *testing.T) {
+	db := repotest.NewDB(t)
+	invalidados := &livrosInvalidados{}
+	repo := NewAvaliacaoRepository(db)
+	repo.Livros = invalidados
+
+	livro := &models.Livro{Titulo: "Dom Casmurro"}
+	require.NoError(t, db.Create(livro).Error)
+	var usuarios []*models.User
+	for _, username := range []string{"ana", "bia", "caio"} {
+		user := &models.User{Username: username, Password: "hash"}
+		require.NoError(t, db.Create(user).Error)
+		usuarios = append(usuarios, user)
+	}
+
+	notas := []int{5, 4, 2}
+	avaliacoes := make([]*models.Avaliacao, len(notas))
+	for i, nota := range notas {
+		avaliacoes[i] = &models.Avaliacao{UserID: usuarios[i].ID, LivroID: livro.ID, Nota: nota}
+		require.NoError(t, repo.Create(avaliacoes[i]))
+	}
+	media, total := resumoLivro(t, db, livro.ID)
+	assert.Equal(t, 3.67, media)
+	assert.Equal(t, 3, total)
+
+	_, err := repo.Update(avaliacoes[2].ID, 3, "reli e gostei mais", time.Now())
+	require.NoError(t, err)
+	media, total = resumoLivro(t, db, livro.ID)
+	assert.Equal(t, 4.0, media)
+	assert.Equal(t, 3, total)
+
+	// Avaliações ocultas pela moderação não entram na média.
+	_, err = repo.Moderar(avaliacoes[0].ID, true, "spoiler")
+	require.NoError(t, err)
+	media, total = resumoLivro(t, db, livro.ID)
+	assert.Equal(t, 3.5, media)
+	assert.Equal(t, 2, total)
+
+	require.NoError(t, repo.Delete(avaliacoes[1].ID))
+	media, total = resumoLivro(t, db, livro.ID)
+	assert.Equal(t, 3.0, media)
+	assert.Equal(t, 1, total)
+
+	require.NoError(t, repo.Delete(avaliacoes[2].ID))
+	media, total = resumoLivro(t, db, livro.ID)
+	assert.Equal(t, 0.0, media)
+	assert.Equal(t, 0, total)
+
+	assert.Len(t, *invalidados, 7)
+	for _, id := range *invalidados {
+		assert.Equal(t, livro.ID, id)
+	}
+}
+
+func TestAvaliacaoRepositoryUmaPorUsuario(t *testing.T) {
+	db := repotest.NewDB(t)
+	repo := NewAvaliacaoRepository(db)
+
+	livro := &models.Livro{Titulo: "Dom Casmurro"}
+	require.NoError(t, db.Create(livro).Error)
+	user := &models.User{Username: "ana", Password: "hash"}
+	require.NoError(t, db.Create(user).Error)
+
+	require.NoError(t, repo.Create(&models.Avaliacao{UserID: user.ID, LivroID: livro.ID, Nota: 5}))
+	err := repo.Create(&models.Avaliacao{UserID: user.ID, LivroID: livro.ID, Nota: 1})
+	assert.ErrorIs(t, err, ErrAvaliacaoDuplicada)
+
+	// A tentativa recusada não altera a média.
+	media, total := resumoLivro(t, db, livro.ID)
+	assert.Equal(t, 5.0, media)
+	assert.Equal(t, 1, total)
+
+	err = repo.Create(&models.Avaliacao{UserID: user.ID, LivroID: 9999, Nota: 5})
+	assert.ErrorIs(t, err, ErrLivroNotFound)
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"books_api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEmprestimoNotFound  = errors.New("empréstimo não encontrado")
+	ErrLivroNotFound       = errors.New("livro não encontrado")
+	ErrLivroIndisponivel   = errors.New("nenhum exemplar disponível para empréstimo")
+	ErrLimiteEmprestimos   = errors.New("limite de empréstimos simultâneos atingido")
+	ErrEmprestimoDevolvido = errors.New("empréstimo já foi devolvido")
+	ErrLivroReservado      = errors.New("os exemplares do livro estão reservados para a fila de espera")
+	ErrExemplarNaoSeparado = errors.New("o exemplar informado não é o separado para a reserva do usuário")
+	ErrEmprestimosAtivos   = errors.New("há empréstimos não devolvidos")
+)
+
+type EmprestimoRepository struct {
+	DB *gorm.DB
+}
+
+// EmprestimoFilter define os critérios de busca e paginação da listagem de empréstimos.
+type EmprestimoFilter struct {
+	UserID  *uint
+	LivroID *uint
+	// Status é um de models.EmprestimoAtivo, EmprestimoAtrasado ou EmprestimoDevolvido.
+	// Empréstimos atrasados também são ativos.
+	Status string
+	Page   int
+	Limit  int
+}
+
+// contarEmprestimosAtivos conta os empréstimos não devolvidos que atendem à condição.
+func contarEmprestimosAtivos(tx *gorm.DB, query string, args ...interface{}) (int64, error) {
+	var ativos int64
+	err := tx.Model(&models.Emprestimo{}).Where("data_devolucao IS NULL").Where(query, args...).Count(&ativos).Error
+	return ativos, err
+}
+
+func NewEmprestimoRepository(db *gorm.DB) *EmprestimoRepository {
+	return &EmprestimoRepository{DB: db}
+}
+
+// Checkout registra o empréstimo de um exemplar disponível do livro. Se codigoBarras for informado,
+// empresta aquele exemplar; caso contrário, qualquer exemplar disponível. Se o usuário tiver uma
+// reserva pronta para retirada, empresta o exemplar separado para ele e a reserva é atendida; sem
+// reserva, o livro só é emprestado se ninguém estiver na fila de espera. Usuários com multas pendentes
+// acima de limiteMultas (em centavos; 0 desativa) não podem retirar livros. A transação bloqueia o livro
+// e o usuário, garantindo que o exemplar não seja emprestado duas vezes e que o limite por usuário
+// seja respeitado mesmo com requisições concorrentes.
+func (r *EmprestimoRepository) Checkout(emprestimo *models.Emprestimo, codigoBarras string, limite int, limiteMultas int64) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var livro models.Livro
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&livro, emprestimo.LivroID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrLivroNotFound
+			}
+			return err
+		}
+
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, emprestimo.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		if limiteMultas > 0 {
+			pendentes, err := totalMultasPendentes(tx, emprestimo.UserID)
+			if err != nil {
+				return err
+			}
+			if pendentes > limiteMultas {
+				return ErrMultasPendentes
+			}
+		}
+
+		if limite > 0 {
+			var ativos int64
+			if err := tx.Model(&models.Emprestimo{}).
+				Where("user_id = ? AND data_devolucao IS NULL", emprestimo.UserID).
+				Count(&ativos).Error; err != nil {
+				return err
+			}
+			if ativos >= int64(limite) {
+				return ErrLimiteEmprestimos
+			}
+		}
+
+		var reserva models.Reserva
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND livro_id = ? AND status = ?", emprestimo.UserID, livro.ID, models.ReservaDisponivel).
+			First(&reserva).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		temReserva := err == nil && reserva.ExemplarID != nil
+
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		if temReserva {
+			query = query.Where("id = ? AND status = ?", *reserva.ExemplarID, models.ExemplarReservado)
+		} else {
+			fila, err := tamanhoFila(tx, livro.ID)
+			if err != nil {
+				return err
+			}
+			if fila > 0 {
+				return ErrLivroReservado
+			}
+			query = query.Where("livro_id = ? AND status = ?", livro.ID, models.ExemplarDisponivel)
+		}
+		if codigoBarras != "" {
+			query = query.Where("codigo_barras = ?", codigoBarras)
+		}
+		var exemplar models.Exemplar
+		if err := query.Order("id").First(&exemplar).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if temReserva {
+					return ErrExemplarNaoSeparado
+				}
+				return ErrLivroIndisponivel
+			}
+			return err
+		}
+
+		if err := tx.Model(&exemplar).Update("status", models.ExemplarEmprestado).Error; err != nil {
+			return err
+		}
+		emprestimo.ExemplarID = &exemplar.ID
+		if err := tx.Create(emprestimo).Error; err != nil {
+			return err
+		}
+		if temReserva {
+			if err := tx.Model(&reserva).Updates(map[string]interface{}{
+				"status":       models.ReservaAtendida,
+				"encerrada_em": emprestimo.DataEmprestimo,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		emprestimo.Livro = livro
+		emprestimo.Exemplar = &exemplar
+		return nil
+	})
+}
+
+// Return registra a devolução de um empréstimo ainda ativo e libera o exemplar.
+func (r *EmprestimoRepository) Return(id uint, at time.Time) (*models.Emprestimo, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var emprestimo models.Emprestimo
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&emprestimo, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEmprestimoNotFound
+			}
+			return err
+		}
+		if emprestimo.DataDevolucao != nil {
+			return ErrEmprestimoDevolvido
+		}
+
+		if err := tx.Model(&emprestimo).Update("data_devolucao", at).Error; err != nil {
+			return err
+		}
+		if emprestimo.ExemplarID == nil {
+			return nil
+		}
+		return tx.Model(&models.Exemplar{}).
+			Where("id = ? AND status = ?", *emprestimo.ExemplarID, models.ExemplarEmprestado).
+			Update("status", models.ExemplarDisponivel).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(id)
+}
+
+// Vencendo retorna os empréstimos em aberto que vencem entre de e ate e ainda não receberam o
+// lembrete de vencimento, incluindo o usuário e o livro.
+func (r *EmprestimoRepository) Vencendo(de, ate time.Time) ([]models.Emprestimo, error) {
+	var emprestimos []models.Emprestimo
+	err := r.DB.Preload("User").Preload("Livro").
+		Where("data_devolucao IS NULL AND lembrete_enviado_em IS NULL").
+		Where("data_devolucao_prevista BETWEEN ? AND ?", de, ate).
+		Order("id").
+		Find(&emprestimos).Error
+	return emprestimos, err
+}
+
+// Atrasados retorna os empréstimos em aberto vencidos antes de at, incluindo o usuário e o livro.
+func (r *EmprestimoRepository) Atrasados(at time.Time) ([]models.Emprestimo, error) {
+	var emprestimos []models.Emprestimo
+	err := r.DB.Preload("User").Preload("Livro").
+		Where("data_devolucao IS NULL AND data_devolucao_prevista < ?", at).
+		Order("id").
+		Find(&emprestimos).Error
+	return emprestimos, err
+}
+
+// MarcarLembrete registra o envio do lembrete de vencimento. Retorna false se outro processo já o
+// registrou, evitando lembretes repetidos quando há várias instâncias.
+func (r *EmprestimoRepository) MarcarLembrete(id uint, at time.Time) (bool, error) {
+	result := r.DB.Model(&models.Emprestimo{}).
+		Where("id = ? AND lembrete_enviado_em IS NULL", id).
+		Update("lembrete_enviado_em", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarcarAvisoAtraso registra o envio do aviso de atraso, desde que o último aviso seja anterior a
+// desde. Retorna false se o aviso não deve ser enviado.
+func (r *EmprestimoRepository) MarcarAvisoAtraso(id uint, at, desde time.Time) (bool, error) {
+	result := r.DB.Model(&models.Emprestimo{}).
+		Where("id = ? AND (aviso_atraso_em IS NULL OR aviso_atraso_em <= ?)", id, desde).
+		Update("aviso_atraso_em", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindByID busca um empréstimo pelo ID, incluindo o livro.
+func (r *EmprestimoRepository) FindByID(id uint) (*models.Emprestimo, error) {
+	var emprestimo models.Emprestimo
+	if err := r.DB.Preload("Livro").Preload("Exemplar").First(&emprestimo, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEmprestimoNotFound
+		}
+		return nil, err
+	}
+	return &emprestimo, nil
+}
+
+// List retorna uma página de empréstimos que atendem ao filtro, do mais recente para o mais antigo,
+// e o total de registros encontrados.
+func (r *EmprestimoRepository) List(filter EmprestimoFilter) ([]models.Emprestimo, int64, error) {
+	query := r.DB.Model(&models.Emprestimo{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.LivroID != nil {
+		query = query.Where("livro_id = ?", *filter.LivroID)
+	}
+	switch filter.Status {
+	case models.EmprestimoAtivo:
+		query = query.Where("data_devolucao IS NULL")
+	case models.EmprestimoAtrasado:
+		query = query.Where("data_devolucao IS NULL AND data_devolucao_prevista < ?", time.Now())
+	case models.EmprestimoDevolvido:
+		query = query.Where("data_devolucao IS NOT NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var emprestimos []models.Emprestimo
+	err := query.Preload("Livro").Preload("Exemplar").
+		Order("data_emprestimo DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&emprestimos).Error
+	return emprestimos, total, err
+}
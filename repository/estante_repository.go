@@ -0,0 +1,394 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"books_api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEstanteNotFound     = errors.New("estante não encontrada")
+	ErrNomeEstanteEmUso    = errors.New("já existe uma estante com este nome")
+	ErrLivroNaEstante      = errors.New("livro já está na estante")
+	ErrItemEstanteNotFound = errors.New("livro não está na estante")
+)
+
+type EstanteRepository struct {
+	DB *gorm.DB
+}
+
+func NewEstanteRepository(db *gorm.DB) *EstanteRepository {
+	return &EstanteRepository{DB: db}
+}
+
+// GarantirPadrao cria as estantes padrão que o usuário ainda não tem. As estantes padrão são
+// identificadas pelo tipo: se o usuário já tiver uma estante personalizada com o nome padrão, a
+// estante padrão recebe o nome com um sufixo numérico.
+func (r *EstanteRepository) GarantirPadrao(userID uint) error {
+	var tipos []string
+	if err := r.DB.Model(&models.Estante{}).
+		Where("user_id = ? AND tipo <> ?", userID, models.EstantePersonalizada).
+		Pluck("tipo", &tipos).Error; err != nil {
+		return err
+	}
+	existentes := make(map[string]bool, len(tipos))
+	for _, tipo := range tipos {
+		existentes[tipo] = true
+	}
+
+	for _, padrao := range models.EstantesPadrao {
+		if existentes[padrao.Tipo] {
+			continue
+		}
+		nome, err := r.nomeLivre(userID, padrao.Nome)
+		if err != nil {
+			return err
+		}
+		estante := &models.Estante{UserID: userID, Nome: nome, Tipo: padrao.Tipo}
+		// Requisições simultâneas podem criar a mesma estante; o índice único por tipo descarta a repetida.
+		if err := r.DB.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "tipo"}},
+			// O predicado repete o do índice parcial, para que o banco o reconheça como alvo do conflito.
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "tipo <> 'personalizada'"}}},
+			DoNothing:   true,
+		}).Create(estante).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListByUser retorna as estantes do usuário com a quantidade de livros de cada uma. Se somentePublicas
+// for verdadeiro, as estantes privadas são omitidas.
+func (r *EstanteRepository) ListByUser(userID uint, somentePublicas bool) ([]models.Estante, error) {
+	query := r.DB.Where("user_id = ?", userID)
+	if somentePublicas {
+		query = query.Where("publica = ?", true)
+	}
+	var estantes []models.Estante
+	if err := query.Order("id").Find(&estantes).Error; err != nil {
+		return nil, err
+	}
+	if len(estantes) == 0 {
+		return estantes, nil
+	}
+
+	ids := make([]uint, len(estantes))
+	for i := range estantes {
+		ids[i] = estantes[i].ID
+	}
+	var totais []struct {
+		EstanteID uint
+		Total     int64
+	}
+	if err := r.DB.Model(&models.EstanteItem{}).
+		Select("estante_id, COUNT(*) AS total").
+		Where("estante_id IN ?", ids).
+		Group("estante_id").
+		Scan(&totais).Error; err != nil {
+		return nil, err
+	}
+	porEstante := make(map[uint]int64, len(totais))
+	for _, t := range totais {
+		porEstante[t.EstanteID] = t.Total
+	}
+	for i := range estantes {
+		estantes[i].TotalItens = porEstante[estantes[i].ID]
+	}
+	return estantes, nil
+}
+
+// FindByID busca uma estante pelo ID, sem os livros.
+func (r *EstanteRepository) FindByID(id uint) (*models.Estante, error) {
+	var estante models.Estante
+	if err := r.DB.First(&estante, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEstanteNotFound
+		}
+		return nil, err
+	}
+	return &estante, nil
+}
+
+// FindPadrao busca a estante padrão do tipo informado do usuário.
+func (r *EstanteRepository) FindPadrao(userID uint, tipo string) (*models.Estante, error) {
+	var estante models.Estante
+	if err := r.DB.Where("user_id = ? AND tipo = ?", userID, tipo).First(&estante).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEstanteNotFound
+		}
+		return nil, err
+	}
+	return &estante, nil
+}
+
+// FindComItens busca uma estante com os livros na ordem da lista.
+func (r *EstanteRepository) FindComItens(id uint) (*models.Estante, error) {
+	var estante models.Estante
+	err := r.DB.Preload("Itens", func(db *gorm.DB) *gorm.DB { return db.Order("posicao") }).
+		Preload("Itens.Livro").
+		First(&estante, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEstanteNotFound
+		}
+		return nil, err
+	}
+	estante.TotalItens = int64(len(estante.Itens))
+	return &estante, nil
+}
+
+// FindByToken busca, com os livros, a estante compartilhada pelo token informado.
+func (r *EstanteRepository) FindByToken(token string) (*models.Estante, error) {
+	var estante models.Estante
+	if err := r.DB.Select("id").Where("token_compartilhamento = ?", token).First(&estante).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEstanteNotFound
+		}
+		return nil, err
+	}
+	return r.FindComItens(estante.ID)
+}
+
+// Create grava uma estante personalizada, recusando nomes repetidos para o mesmo usuário.
+func (r *EstanteRepository) Create(estante *models.Estante) error {
+	if err := r.nomeDisponivel(estante.UserID, estante.Nome, 0); err != nil {
+		return err
+	}
+	return r.DB.Create(estante).Error
+}
+
+// Update altera o nome, a descrição e a visibilidade de uma estante.
+func (r *EstanteRepository) Update(estante *models.Estante) error {
+	if err := r.nomeDisponivel(estante.UserID, estante.Nome, estante.ID); err != nil {
+		return err
+	}
+	return r.DB.Model(estante).Select("Nome", "Descricao", "Publica").Updates(estante).Error
+}
+
+// Delete remove uma estante e os livros dela.
+func (r *EstanteRepository) Delete(id uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("estante_id = ?", id).Delete(&models.EstanteItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Estante{}, id).Error
+	})
+}
+
+// DefinirToken grava ou, com nil, remove o token de compartilhamento da estante.
+func (r *EstanteRepository) DefinirToken(id uint, token *string) error {
+	return r.DB.Model(&models.Estante{}).Where("id = ?", id).Update("token_compartilhamento", token).Error
+}
+
+// AdicionarLivro coloca o livro na estante na posição informada, deslocando os seguintes; posição 0
+// coloca o livro no fim. As estantes padrão são exclusivas entre si: ao entrar em uma delas, o livro
+// sai das outras estantes padrão do usuário.
+func (r *EstanteRepository) AdicionarLivro(estante *models.Estante, livroID uint, nota string, posicao int) (*models.EstanteItem, error) {
+	item := &models.EstanteItem{EstanteID: estante.ID, LivroID: livroID, Nota: nota}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if estante.Padrao() {
+			// Bloqueia todas as estantes padrão do usuário, sempre na mesma ordem, pois o livro pode sair de outra delas.
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("user_id = ? AND tipo <> ?", estante.UserID, models.EstantePersonalizada).
+				Order("id").
+				Find(&[]models.Estante{}).Error; err != nil {
+				return err
+			}
+		} else if err := lockEstante(tx, estante.ID); err != nil {
+			return err
+		}
+		if err := tx.First(&models.Livro{}, livroID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrLivroNotFound
+			}
+			return err
+		}
+
+		var existentes int64
+		if err := tx.Model(&models.EstanteItem{}).
+			Where("estante_id = ? AND livro_id = ?", estante.ID, livroID).
+			Count(&existentes).Error; err != nil {
+			return err
+		}
+		if existentes > 0 {
+			return ErrLivroNaEstante
+		}
+
+		if estante.Padrao() {
+			if err := removerDasOutrasPadrao(tx, estante, livroID); err != nil {
+				return err
+			}
+		}
+
+		var total int64
+		if err := tx.Model(&models.EstanteItem{}).Where("estante_id = ?", estante.ID).Count(&total).Error; err != nil {
+			return err
+		}
+		if posicao < 1 || posicao > int(total)+1 {
+			posicao = int(total) + 1
+		}
+		if err := tx.Model(&models.EstanteItem{}).
+			Where("estante_id = ? AND posicao >= ?", estante.ID, posicao).
+			Update("posicao", gorm.Expr("posicao + 1")).Error; err != nil {
+			return err
+		}
+		item.Posicao = posicao
+		return tx.Create(item).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.findItem(estante.ID, livroID)
+}
+
+// AtualizarItem altera a nota (se informada) e move o livro para a posição informada (se maior que 0).
+func (r *EstanteRepository) AtualizarItem(estanteID, livroID uint, nota *string, posicao int) (*models.EstanteItem, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockEstante(tx, estanteID); err != nil {
+			return err
+		}
+		var item models.EstanteItem
+		if err := tx.Where("estante_id = ? AND livro_id = ?", estanteID, livroID).First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrItemEstanteNotFound
+			}
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if nota != nil {
+			updates["nota"] = *nota
+		}
+		if posicao > 0 && posicao != item.Posicao {
+			var total int64
+			if err := tx.Model(&models.EstanteItem{}).Where("estante_id = ?", estanteID).Count(&total).Error; err != nil {
+				return err
+			}
+			if posicao > int(total) {
+				posicao = int(total)
+			}
+			shift := tx.Model(&models.EstanteItem{}).Where("estante_id = ?", estanteID)
+			if posicao < item.Posicao {
+				shift = shift.Where("posicao >= ? AND posicao < ?", posicao, item.Posicao).
+					Update("posicao", gorm.Expr("posicao + 1"))
+			} else {
+				shift = shift.Where("posicao > ? AND posicao <= ?", item.Posicao, posicao).
+					Update("posicao", gorm.Expr("posicao - 1"))
+			}
+			if shift.Error != nil {
+				return shift.Error
+			}
+			updates["posicao"] = posicao
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&item).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.findItem(estanteID, livroID)
+}
+
+// RemoverLivro tira o livro da estante, fechando o espaço na ordem da lista.
+func (r *EstanteRepository) RemoverLivro(estanteID, livroID uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockEstante(tx, estanteID); err != nil {
+			return err
+		}
+		var item models.EstanteItem
+		if err := tx.Where("estante_id = ? AND livro_id = ?", estanteID, livroID).First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrItemEstanteNotFound
+			}
+			return err
+		}
+		return removerItem(tx, &item)
+	})
+}
+
+func (r *EstanteRepository) findItem(estanteID, livroID uint) (*models.EstanteItem, error) {
+	var item models.EstanteItem
+	if err := r.DB.Preload("Livro").Where("estante_id = ? AND livro_id = ?", estanteID, livroID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrItemEstanteNotFound
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// nomeDisponivel verifica se o usuário já tem outra estante com o mesmo nome.
+func (r *EstanteRepository) nomeDisponivel(userID uint, nome string, exceto uint) error {
+	var total int64
+	if err := r.DB.Model(&models.Estante{}).
+		Where("user_id = ? AND LOWER(nome) = LOWER(?) AND id <> ?", userID, nome, exceto).
+		Count(&total).Error; err != nil {
+		return err
+	}
+	if total > 0 {
+		return ErrNomeEstanteEmUso
+	}
+	return nil
+}
+
+// nomeLivre retorna o nome ou, se o usuário já tiver uma estante com ele, o nome com um sufixo numérico.
+func (r *EstanteRepository) nomeLivre(userID uint, nome string) (string, error) {
+	for i := 1; i <= 100; i++ {
+		candidato := nome
+		if i > 1 {
+			candidato = fmt.Sprintf("%s %d", nome, i)
+		}
+		err := r.nomeDisponivel(userID, candidato, 0)
+		if err == nil {
+			return candidato, nil
+		}
+		if !errors.Is(err, ErrNomeEstanteEmUso) {
+			return "", err
+		}
+	}
+	return "", ErrNomeEstanteEmUso
+}
+
+// removerDasOutrasPadrao tira o livro das demais estantes padrão do dono da estante, que já devem estar bloqueadas.
+func removerDasOutrasPadrao(tx *gorm.DB, estante *models.Estante, livroID uint) error {
+	var itens []models.EstanteItem
+	if err := tx.Joins("JOIN estantes ON estantes.id = estante_items.estante_id").
+		Where("estantes.user_id = ? AND estantes.tipo <> ? AND estantes.id <> ?", estante.UserID, models.EstantePersonalizada, estante.ID).
+		Where("estante_items.livro_id = ?", livroID).
+		Find(&itens).Error; err != nil {
+		return err
+	}
+	for i := range itens {
+		if err := removerItem(tx, &itens[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removerItem exclui o item e puxa uma posição para cima os livros seguintes da estante.
+func removerItem(tx *gorm.DB, item *models.EstanteItem) error {
+	if err := tx.Delete(item).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.EstanteItem{}).
+		Where("estante_id = ? AND posicao > ?", item.EstanteID, item.Posicao).
+		Update("posicao", gorm.Expr("posicao - 1")).Error
+}
+
+// lockEstante bloqueia a estante até o fim da transação, serializando as alterações na ordem dos livros.
+func lockEstante(tx *gorm.DB, id uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Estante{}, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrEstanteNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"testing"
+
+	"books_api/models"
+	"books_api/repository/repotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func novoEstanteRepository(t *testing.T) (*EstanteRepository, *gorm.DB, *models.User) {
+	t.Helper()
+	db := repotest.NewDB(t)
+	user := &models.User{Username: "ana", Password: "hash"}
+	require.NoError(t, db.Create(user).Error)
+	return NewEstanteRepository(db), db, user
+}
+
+func TestEstanteRepositoryGarantirPadrao(t *testing.T) {
+	repo, _, user := novoEstanteRepository(t)
+
+	require.NoError(t, repo.GarantirPadrao(user.ID))
+	require.NoError(t, repo.GarantirPadrao(user.ID))
+
+	estantes, err := repo.ListByUser(user.ID, false)
+	require.NoError(t, err)
+	require.Len(t, estantes, len(models.EstantesPadrao))
+	for i, padrao := range models.EstantesPadrao {
+		assert.Equal(t, padrao.Tipo, estantes[i].Tipo)
+		assert.Equal(t, padrao.Nome, estantes[i].Nome)
+	}
+
+	// Renomeada, a estante padrão continua sendo reconhecida pelo tipo.
+	lendo, err := repo.FindPadrao(user.ID, models.EstanteLendo)
+	require.NoError(t, err)
+	lendo.Nome = "Na cabeceira"
+	require.NoError(t, repo.Update(lendo))
+	require.NoError(t, repo.GarantirPadrao(user.ID))
+	estantes, err = repo.ListByUser(user.ID, false)
+	require.NoError(t, err)
+	assert.Len(t, estantes, len(models.EstantesPadrao))
+}
+
+func TestEstanteRepositoryGarantirPadraoComNomeEmUso(t *testing.T) {
+	repo, db, user := novoEstanteRepository(t)
+
+	personalizada := &models.Estante{UserID: user.ID, Nome: "Lendo", Tipo: models.EstantePersonalizada}
+	require.NoError(t, repo.Create(personalizada))
+	require.NoError(t, repo.GarantirPadrao(user.ID))
+
+	lendo, err := repo.FindPadrao(user.ID, models.EstanteLendo)
+	require.NoError(t, err)
+	assert.NotEqual(t, personalizada.ID, lendo.ID)
+	assert.Equal(t, "Lendo 2", lendo.Nome)
+
+	atual, err := repo.FindByID(personalizada.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.EstantePersonalizada, atual.Tipo)
+	assert.Equal(t, "Lendo", atual.Nome)
+
+	// O índice único impede uma segunda estante padrão do mesmo tipo.
+	err = db.Create(&models.Estante{UserID: user.ID, Nome: "Outra", Tipo: models.EstanteLendo}).Error
+	assert.Error(t, err)
+}
+
+func TestEstanteRepositoryPadraoExclusivas(t *testing.T) {
+	repo, db, user := novoEstanteRepository(t)
+	require.NoError(t, repo.GarantirPadrao(user.ID))
+	livro := &models.Livro{Titulo: "Dom Casmurro"}
+	require.NoError(t, db.Create(livro).Error)
+
+	queroLer, err := repo.FindPadrao(user.ID, models.EstanteQueroLer)
+	require.NoError(t, err)
+	lendo, err := repo.FindPadrao(user.ID, models.EstanteLendo)
+	require.NoError(t, err)
+	personalizada := &models.Estante{UserID: user.ID, Nome: "Clássicos", Tipo: models.EstantePersonalizada}
+	require.NoError(t, repo.Create(personalizada))
+
+	_, err = repo.AdicionarLivro(queroLer, livro.ID, "", 0)
+	require.NoError(t, err)
+	_, err = repo.AdicionarLivro(queroLer, livro.ID, "", 0)
+	assert.ErrorIs(t, err, ErrLivroNaEstante)
+	_, err = repo.AdicionarLivro(personalizada, livro.ID, "", 0)
+	require.NoError(t, err)
+
+	// Ao entrar em outra estante padrão, o livro sai da anterior, mas continua nas personalizadas.
+	_, err = repo.AdicionarLivro(lendo, livro.ID, "", 0)
+	require.NoError(t, err)
+	for estante, total := range map[*models.Estante]int64{queroLer: 0, lendo: 1, personalizada: 1} {
+		comItens, err := repo.FindComItens(estante.ID)
+		require.NoError(t, err)
+		assert.Equal(t, total, comItens.TotalItens, estante.Nome)
+	}
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"errors"
+
+	"books_api/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExemplarNotFound   = errors.New("exemplar não encontrado")
+	ErrCodigoBarrasInUse  = errors.New("código de barras já cadastrado")
+	ErrExemplarEmprestado = errors.New("exemplar está emprestado")
+	ErrExemplarReservado  = errors.New("exemplar está separado para uma reserva")
+)
+
+type ExemplarRepository struct {
+	DB *gorm.DB
+}
+
+// ExemplarFilter define os critérios de busca e paginação da listagem de exemplares.
+type ExemplarFilter struct {
+	LivroID     *uint
+	Localizacao string
+	Status      string
+	Page        int
+	Limit       int
+}
+
+// ResumoInventario é a quantidade de exemplares em uma situação em uma localização.
+type ResumoInventario struct {
+	Localizacao string `json:"localizacao"`
+	Status      string `json:"status"`
+	Total       int64  `json:"total"`
+}
+
+func NewExemplarRepository(db *gorm.DB) *ExemplarRepository {
+	return &ExemplarRepository{DB: db}
+}
+
+// Create grava um novo exemplar, recusando códigos de barras repetidos.
+func (r *ExemplarRepository) Create(exemplar *models.Exemplar) error {
+	if existing, _ := r.FindByCodigoBarras(exemplar.CodigoBarras); existing != nil {
+		return ErrCodigoBarrasInUse
+	}
+	if err := r.DB.First(&models.Livro{}, exemplar.LivroID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrLivroNotFound
+		}
+		return err
+	}
+	return r.DB.Create(exemplar).Error
+}
+
+// FindByID busca um exemplar pelo ID.
+func (r *ExemplarRepository) FindByID(id uint) (*models.Exemplar, error) {
+	var exemplar models.Exemplar
+	if err := r.DB.First(&exemplar, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExemplarNotFound
+		}
+		return nil, err
+	}
+	return &exemplar, nil
+}
+
+// FindByCodigoBarras busca um exemplar pelo código de barras.
+func (r *ExemplarRepository) FindByCodigoBarras(codigo string) (*models.Exemplar, error) {
+	var exemplar models.Exemplar
+	if err := r.DB.Where("codigo_barras = ?", codigo).First(&exemplar).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExemplarNotFound
+		}
+		return nil, err
+	}
+	return &exemplar, nil
+}
+
+// Update altera condição, localização e situação de um exemplar; valores vazios mantêm os atuais.
+// A situação de um exemplar emprestado só muda pela devolução, e a de um reservado, pela retirada
+// ou expiração da reserva.
+func (r *ExemplarRepository) Update(id uint, condicao, localizacao, status string) (*models.Exemplar, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var exemplar models.Exemplar
+		if err := tx.First(&exemplar, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrExemplarNotFound
+			}
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if condicao != "" {
+			updates["condicao"] = condicao
+		}
+		if localizacao != "" {
+			updates["localizacao"] = localizacao
+		}
+		if status != "" {
+			if status != exemplar.Status {
+				if err := exemplarOcupado(exemplar.Status); err != nil {
+					return err
+				}
+			}
+			updates["status"] = status
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&exemplar).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(id)
+}
+
+// Delete remove um exemplar que não esteja emprestado nem reservado.
+func (r *ExemplarRepository) Delete(id uint) error {
+	result := r.DB.Where("id = ? AND status NOT IN ?", id, []string{models.ExemplarEmprestado, models.ExemplarReservado}).
+		Delete(&models.Exemplar{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		exemplar, err := r.FindByID(id)
+		if err != nil {
+			return err
+		}
+		return exemplarOcupado(exemplar.Status)
+	}
+	return nil
+}
+
+// List retorna uma página de exemplares que atendem ao filtro e o total de registros encontrados.
+func (r *ExemplarRepository) List(filter ExemplarFilter) ([]models.Exemplar, int64, error) {
+	query := r.DB.Model(&models.Exemplar{})
+	if filter.LivroID != nil {
+		query = query.Where("livro_id = ?", *filter.LivroID)
+	}
+	if filter.Localizacao != "" {
+		query = query.Where("localizacao = ?", filter.Localizacao)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var exemplares []models.Exemplar
+	err := query.Order("localizacao, codigo_barras").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&exemplares).Error
+	return exemplares, total, err
+}
+
+// Disponibilidade conta os exemplares de cada livro informado. Livros sem exemplares ficam fora do mapa.
+func (r *ExemplarRepository) Disponibilidade(livroIDs []uint) (map[uint]*models.Disponibilidade, error) {
+	result := make(map[uint]*models.Disponibilidade)
+	if len(livroIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		LivroID uint
+		Status  string
+		Total   int64
+	}
+	if err := r.DB.Model(&models.Exemplar{}).
+		Select("livro_id, status, COUNT(*) AS total").
+		Where("livro_id IN ?", livroIDs).
+		Group("livro_id, status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		d, ok := result[row.LivroID]
+		if !ok {
+			d = &models.Disponibilidade{}
+			result[row.LivroID] = d
+		}
+		d.Total += row.Total
+		switch row.Status {
+		case models.ExemplarDisponivel:
+			d.Disponiveis += row.Total
+		case models.ExemplarEmprestado:
+			d.Emprestados += row.Total
+		case models.ExemplarReservado:
+			d.Reservados += row.Total
+		}
+	}
+	return result, nil
+}
+
+// Resumo conta os exemplares por localização e situação.
+func (r *ExemplarRepository) Resumo() ([]ResumoInventario, error) {
+	var resumo []ResumoInventario
+	err := r.DB.Model(&models.Exemplar{}).
+		Select("localizacao, status, COUNT(*) AS total").
+		Group("localizacao, status").
+		Order("localizacao, status").
+		Scan(&resumo).Error
+	return resumo, err
+}
+
+// exemplarOcupado retorna o erro correspondente a um exemplar emprestado ou reservado, cuja situação
+// não pode ser alterada diretamente.
+func exemplarOcupado(status string) error {
+	switch status {
+	case models.ExemplarEmprestado:
+		return ErrExemplarEmprestado
+	case models.ExemplarReservado:
+		return ErrExemplarReservado
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"errors"
+
+	"books_api/models"
+	"gorm.io/gorm"
+)
+
+var ErrIdentityNotFound = errors.New("identidade externa não encontrada")
+
+type ExternalIdentityRepository struct {
+	DB *gorm.DB
+}
+
+func NewExternalIdentityRepository(db *gorm.DB) *ExternalIdentityRepository {
+	return &ExternalIdentityRepository{DB: db}
+}
+
+// Find busca a identidade de um provedor pelo subject.
+func (r *ExternalIdentityRepository) Find(provider, subject string) (*models.ExternalIdentity, error) {
+	var identity models.ExternalIdentity
+	if err := r.DB.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIdentityNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// Create vincula uma identidade externa a um usuário existente.
+func (r *ExternalIdentityRepository) Create(identity *models.ExternalIdentity) error {
+	return r.DB.Create(identity).Error
+}
+
+// CreateWithUser cria o usuário e a identidade externa na mesma transação.
+func (r *ExternalIdentityRepository) CreateWithUser(user *models.User, identity *models.ExternalIdentity) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+// ListByUser retorna as identidades externas vinculadas a um usuário.
+func (r *ExternalIdentityRepository) ListByUser(userID uint) ([]models.ExternalIdentity, error) {
+	var identities []models.ExternalIdentity
+	err := r.DB.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"errors"
+
+	"books_api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrFavoritoNotFound     = errors.New("livro não está nos favoritos")
+	ErrAutorSeguidoNotFound = errors.New("autor não está sendo seguido")
+)
+
+type FavoritoRepository struct {
+	DB *gorm.DB
+}
+
+func NewFavoritoRepository(db *gorm.DB) *FavoritoRepository {
+	return &FavoritoRepository{DB: db}
+}
+
+// Favoritar adiciona o livro aos favoritos do usuário. Favoritar de novo não tem efeito.
+func (r *FavoritoRepository) Favoritar(userID, livroID uint) (*models.Favorito, error) {
+	if err := r.DB.Select("id").First(&models.Livro{}, livroID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLivroNotFound
+		}
+		return nil, err
+	}
+	favorito := &models.Favorito{UserID: userID, LivroID: livroID}
+	if err := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(favorito).Error; err != nil {
+		return nil, err
+	}
+
+	var salvo models.Favorito
+	if err := r.DB.Preload("Livro").Where("user_id = ? AND livro_id = ?", userID, livroID).First(&salvo).Error; err != nil {
+		return nil, err
+	}
+	return &salvo, nil
+}
+
+// Desfavoritar remove o livro dos favoritos do usuário.
+func (r *FavoritoRepository) Desfavoritar(userID, livroID uint) error {
+	result := r.DB.Where("user_id = ? AND livro_id = ?", userID, livroID).Delete(&models.Favorito{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrFavoritoNotFound
+	}
+	return nil
+}
+
+// ListFavoritos retorna uma página dos favoritos do usuário, dos mais recentes para os mais antigos,
+// e o total de registros encontrados.
+func (r *FavoritoRepository) ListFavoritos(userID uint, page, limit int) ([]models.Favorito, int64, error) {
+	query := r.DB.Model(&models.Favorito{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var favoritos []models.Favorito
+	err := query.Preload("Livro").
+		Order("created_at DESC, id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&favoritos).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return favoritos, total, nil
+}
+
+// Seguir passa a seguir o autor ou, se já seguido, atualiza o nome exibido e a preferência de e-mail.
+func (r *FavoritoRepository) Seguir(seguido *models.AutorSeguido) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "chave"}},
+		DoUpdates: clause.AssignmentColumns([]string{"autor", "email", "updated_at"}),
+	}).Create(seguido).Error
+}
+
+// FindSeguido busca o autor seguido pelo usuário pela chave de comparação.
+func (r *FavoritoRepository) FindSeguido(userID uint, chave string) (*models.AutorSeguido, error) {
+	var seguido models.AutorSeguido
+	if err := r.DB.Where("user_id = ? AND chave = ?", userID, chave).First(&seguido).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAutorSeguidoNotFound
+		}
+		return nil, err
+	}
+	return &seguido, nil
+}
+
+// ListSeguindo retorna os autores seguidos pelo usuário em ordem alfabética.
+func (r *FavoritoRepository) ListSeguindo(userID uint) ([]models.AutorSeguido, error) {
+	var seguidos []models.AutorSeguido
+	err := r.DB.Where("user_id = ?", userID).Order("chave").Find(&seguidos).Error
+	return seguidos, err
+}
+
+// DeixarDeSeguir remove o autor seguido pelo usuário.
+func (r *FavoritoRepository) DeixarDeSeguir(userID, id uint) error {
+	result := r.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.AutorSeguido{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAutorSeguidoNotFound
+	}
+	return nil
+}
+
+// Seguidores retorna quem segue o autor com a chave informada, com os dados de contato dos usuários
+// ativos. Usuários desativados não são incluídos.
+func (r *FavoritoRepository) Seguidores(chave string) ([]models.AutorSeguido, error) {
+	var seguidores []models.AutorSeguido
+	err := r.DB.Preload("User").
+		Joins("JOIN users ON users.id = autor_seguidos.user_id").
+		Where("autor_seguidos.chave = ? AND users.disabled = ?", chave, false).
+		Order("autor_seguidos.id").
+		Find(&seguidores).Error
+	return seguidores, err
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"books_api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrCurtidaNotFound = errors.New("livro não foi curtido")
+
+type InteracaoRepository struct {
+	DB *gorm.DB
+}
+
+// InteracaoUsuario resume as interações de um usuário com um livro.
+type InteracaoUsuario struct {
+	UserID  uint
+	LivroID uint
+	Curtiu  bool
+	Ultima  time.Time
+}
+
+func NewInteracaoRepository(db *gorm.DB) *InteracaoRepository {
+	return &InteracaoRepository{DB: db}
+}
+
+// RegistrarVisualizacao registra que o usuário viu os detalhes do livro.
+func (r *InteracaoRepository) RegistrarVisualizacao(userID, livroID uint) error {
+	return r.DB.Create(&models.InteracaoLivro{UserID: userID, LivroID: livroID, Tipo: models.InteracaoVisualizacao}).Error
+}
+
+// Curtir registra a curtida do usuário no livro. Curtir de novo não tem efeito.
+func (r *InteracaoRepository) Curtir(userID, livroID uint) error {
+	if err := r.DB.Select("id").First(&models.Livro{}, livroID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrLivroNotFound
+		}
+		return err
+	}
+	curtida := &models.InteracaoLivro{UserID: userID, LivroID: livroID, Tipo: models.InteracaoCurtida}
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(curtida).Error
+}
+
+// Descurtir remove a curtida do usuário no livro.
+func (r *InteracaoRepository) Descurtir(userID, livroID uint) error {
+	result := r.DB.Where("user_id = ? AND livro_id = ? AND tipo = ?", userID, livroID, models.InteracaoCurtida).
+		Delete(&models.InteracaoLivro{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCurtidaNotFound
+	}
+	return nil
+}
+
+// Interacoes agrupa por usuário e livro as visualizações a partir de desde e todas as curtidas, ordenadas
+// por usuário e da interação mais recente para a mais antiga. Se userID for informado, só as dele são retornadas.
+func (r *InteracaoRepository) Interacoes(userID *uint, desde time.Time) ([]InteracaoUsuario, error) {
+	query := r.DB.Model(&models.InteracaoLivro{}).
+		Select("user_id, livro_id, BOOL_OR(tipo = ?) AS curtiu, MAX(created_at) AS ultima", models.InteracaoCurtida).
+		Where("created_at >= ? OR tipo = ?", desde, models.InteracaoCurtida)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	var interacoes []InteracaoUsuario
+	err := query.Group("user_id, livro_id").
+		Order("user_id, ultima DESC, livro_id").
+		Scan(&interacoes).Error
+	return interacoes, err
+}
+
+// LivrosLidos retorna os livros que o usuário já começou ou concluiu a leitura.
+func (r *InteracaoRepository) LivrosLidos(userID uint) ([]uint, error) {
+	var ids []uint
+	err := r.DB.Model(&models.Leitura{}).Where("user_id = ?", userID).Pluck("livro_id", &ids).Error
+	return ids, err
+}
+
+// PodarVisualizacoes exclui as visualizações anteriores a antes, que já não entram nas recomendações.
+func (r *InteracaoRepository) PodarVisualizacoes(antes time.Time) (int64, error) {
+	result := r.DB.Where("tipo = ? AND created_at < ?", models.InteracaoVisualizacao, antes).
+		Delete(&models.InteracaoLivro{})
+	return result.RowsAffected, result.Error
+}
+
+// FindLivro busca um livro pelo ID, sem passar pelo cache.
+func (r *InteracaoRepository) FindLivro(id uint) (*models.Livro, error) {
+	var livro models.Livro
+	if err := r.DB.First(&livro, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLivroNotFound
+		}
+		return nil, err
+	}
+	return &livro, nil
+}
+
+// FindLivros busca os livros pelos IDs, na ordem informada. IDs inexistentes são ignorados.
+func (r *InteracaoRepository) FindLivros(ids []uint) ([]models.Livro, error) {
+	if len(ids) == 0 {
+		return []models.Livro{}, nil
+	}
+	var encontrados []models.Livro
+	if err := r.DB.Where("id IN ?", ids).Find(&encontrados).Error; err != nil {
+		return nil, err
+	}
+	porID := make(map[uint]models.Livro, len(encontrados))
+	for _, livro := range encontrados {
+		porID[livro.ID] = livro
+	}
+	livros := make([]models.Livro, 0, len(encontrados))
+	for _, id := range ids {
+		if livro, ok := porID[id]; ok {
+			livros = append(livros, livro)
+		}
+	}
+	return livros, nil
+}
+
+// Relacionados retorna até limite IDs de livros dos autores ou gêneros informados, fora de excluir.
+// Livros dos mesmos autores vêm primeiro e, entre eles, os mais bem avaliados.
+func (r *InteracaoRepository) Relacionados(autores, generos []string, excluir []uint, limite int) ([]uint, error) {
+	autores, generos = naoVazios(autores), naoVazios(generos)
+	if limite <= 0 || (len(autores) == 0 && len(generos) == 0) {
+		return nil, nil
+	}
+
+	query := r.DB.Model(&models.Livro{})
+	switch {
+	case len(autores) > 0 && len(generos) > 0:
+		query = query.Where("autor IN ? OR genero IN ?", autores, generos)
+	case len(autores) > 0:
+		query = query.Where("autor IN ?", autores)
+	default:
+		query = query.Where("genero IN ?", generos)
+	}
+	if len(excluir) > 0 {
+		query = query.Where("id NOT IN ?", excluir)
+	}
+	ordem := "media_avaliacoes DESC, total_avaliacoes DESC, id"
+	if len(autores) > 0 {
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "CASE WHEN autor IN ? THEN 0 ELSE 1 END, " + ordem,
+			Vars: []interface{}{autores},
+		}})
+	} else {
+		query = query.Order(ordem)
+	}
+
+	var ids []uint
+	err := query.Limit(limite).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Populares retorna até limite IDs dos livros com mais avaliações, fora de excluir.
+func (r *InteracaoRepository) Populares(excluir []uint, limite int) ([]uint, error) {
+	if limite <= 0 {
+		return nil, nil
+	}
+	query := r.DB.Model(&models.Livro{})
+	if len(excluir) > 0 {
+		query = query.Where("id NOT IN ?", excluir)
+	}
+	var ids []uint
+	err := query.Order("total_avaliacoes DESC, media_avaliacoes DESC, id").Limit(limite).Pluck("id", &ids).Error
+	return ids, err
+}
+
+func naoVazios(valores []string) []string {
+	var resultado []string
+	for _, v := range valores {
+		if v != "" {
+			resultado = append(resultado, v)
+		}
+	}
+	return resultado
+}
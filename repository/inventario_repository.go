@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"books_api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInventarioNotFound    = errors.New("inventário não encontrado")
+	ErrInventarioEmAndamento = errors.New("já existe um inventário em andamento nesta localização")
+	ErrInventarioConcluido   = errors.New("inventário já foi concluído")
+)
+
+type InventarioRepository struct {
+	DB *gorm.DB
+}
+
+// ProgressoInventario resume as leituras de um inventário.
+type ProgressoInventario struct {
+	Lidos     int64 `json:"lidos"`
+	Esperados int64 `json:"esperados"`
+	Pendentes int64 `json:"pendentes"`
+}
+
+func NewInventarioRepository(db *gorm.DB) *InventarioRepository {
+	return &InventarioRepository{DB: db}
+}
+
+// Create inicia um inventário, desde que não haja outro em andamento na mesma localização.
+func (r *InventarioRepository) Create(inventario *models.Inventario) error {
+	var abertos int64
+	if err := r.DB.Model(&models.Inventario{}).
+		Where("localizacao = ? AND concluido_em IS NULL", inventario.Localizacao).
+		Count(&abertos).Error; err != nil {
+		return err
+	}
+	if abertos > 0 {
+		return ErrInventarioEmAndamento
+	}
+	return r.DB.Create(inventario).Error
+}
+
+// FindByID busca um inventário pelo ID.
+func (r *InventarioRepository) FindByID(id uint) (*models.Inventario, error) {
+	var inventario models.Inventario
+	if err := r.DB.First(&inventario, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInventarioNotFound
+		}
+		return nil, err
+	}
+	return &inventario, nil
+}
+
+// RegistrarLeitura grava a leitura do exemplar no inventário. Leituras repetidas são ignoradas.
+// Um exemplar dado como perdido que é encontrado volta a ficar disponível.
+func (r *InventarioRepository) RegistrarLeitura(inventarioID uint, exemplar *models.Exemplar, at time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		leitura := &models.InventarioLeitura{InventarioID: inventarioID, ExemplarID: exemplar.ID, LidoEm: at}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(leitura).Error; err != nil {
+			return err
+		}
+		if exemplar.Status == models.ExemplarPerdido {
+			exemplar.Status = models.ExemplarDisponivel
+			return tx.Model(exemplar).Update("status", exemplar.Status).Error
+		}
+		return nil
+	})
+}
+
+// Progresso conta os exemplares lidos e os que ainda são esperados na localização do inventário.
+func (r *InventarioRepository) Progresso(inventario *models.Inventario) (*ProgressoInventario, error) {
+	var p ProgressoInventario
+	if err := r.DB.Model(&models.InventarioLeitura{}).
+		Where("inventario_id = ?", inventario.ID).
+		Count(&p.Lidos).Error; err != nil {
+		return nil, err
+	}
+	if err := r.DB.Model(&models.Exemplar{}).
+		Where("localizacao = ? AND status = ?", inventario.Localizacao, models.ExemplarDisponivel).
+		Count(&p.Esperados).Error; err != nil {
+		return nil, err
+	}
+	if err := r.naoLidos(r.DB, inventario).Count(&p.Pendentes).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Concluir encerra o inventário e marca como perdidos os exemplares disponíveis da localização
+// que não foram lidos, retornando-os.
+func (r *InventarioRepository) Concluir(id uint, at time.Time) ([]models.Exemplar, error) {
+	var perdidos []models.Exemplar
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var inventario models.Inventario
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&inventario, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInventarioNotFound
+			}
+			return err
+		}
+		if inventario.ConcluidoEm != nil {
+			return ErrInventarioConcluido
+		}
+
+		if err := r.naoLidos(tx, &inventario).Find(&perdidos).Error; err != nil {
+			return err
+		}
+		if len(perdidos) > 0 {
+			ids := make([]uint, len(perdidos))
+			for i := range perdidos {
+				ids[i] = perdidos[i].ID
+				perdidos[i].Status = models.ExemplarPerdido
+			}
+			if err := tx.Model(&models.Exemplar{}).Where("id IN ?", ids).
+				Update("status", models.ExemplarPerdido).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&inventario).Updates(map[string]interface{}{
+			"concluido_em":    at,
+			"nao_localizados": len(perdidos),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return perdidos, nil
+}
+
+// naoLidos seleciona os exemplares disponíveis da localização ainda não lidos no inventário.
+func (r *InventarioRepository) naoLidos(db *gorm.DB, inventario *models.Inventario) *gorm.DB {
+	return db.Model(&models.Exemplar{}).
+		Where("localizacao = ? AND status = ?", inventario.Localizacao, models.ExemplarDisponivel).
+		Where("id NOT IN (?)", db.Session(&gorm.Session{NewDB: true}).Model(&models.InventarioLeitura{}).
+			Select("exemplar_id").
+			Where("inventario_id = ?", inventario.ID))
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"books_api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrLeituraNotFound     = errors.New("leitura não encontrada")
+	ErrMetaLeituraNotFound = errors.New("meta de leitura não encontrada")
+)
+
+type LeituraRepository struct {
+	DB *gorm.DB
+}
+
+// LeituraFilter define os critérios de busca e paginação da listagem de leituras.
+type LeituraFilter struct {
+	UserID uint
+	// Status é models.LeituraEmAndamento ou models.LeituraConcluida.
+	Status string
+	Page   int
+	Limit  int
+}
+
+// AutorFavorito é um autor entre os livros concluídos pelo usuário no ano.
+type AutorFavorito struct {
+	Autor   string `json:"autor"`
+	Livros  int64  `json:"livros"`
+	Paginas int64  `json:"paginas"`
+}
+
+// EstatisticasLeitura resume as leituras de um usuário em um ano.
+type EstatisticasLeitura struct {
+	Ano              int   `json:"ano"`
+	LivrosConcluidos int64 `json:"livros_concluidos"`
+	PaginasLidas     int   `json:"paginas_lidas"`
+	// PaginasPorMes tem uma posição por mês, de janeiro a dezembro.
+	PaginasPorMes    []int           `json:"paginas_por_mes"`
+	AutoresFavoritos []AutorFavorito `json:"autores_favoritos"`
+}
+
+func NewLeituraRepository(db *gorm.DB) *LeituraRepository {
+	return &LeituraRepository{DB: db}
+}
+
+// RegistrarSessao grava a sessão na leitura do livro, criando a leitura na primeira sessão. A leitura
+// é concluída no fim da sessão se as páginas lidas alcançarem o total do livro ou se concluir for verdadeiro;
+// sessões de leituras já concluídas somam páginas, mas não alteram a data de conclusão.
+func (r *LeituraRepository) RegistrarSessao(livroID uint, sessao *models.SessaoLeitura, concluir bool) (*models.Leitura, error) {
+	var leituraID uint
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var livro models.Livro
+		if err := tx.First(&livro, livroID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrLivroNotFound
+			}
+			return err
+		}
+
+		nova := &models.Leitura{UserID: sessao.UserID, LivroID: livroID, IniciadaEm: sessao.InicioEm}
+		// Sessões simultâneas do mesmo livro disputam a criação; o índice único mantém uma só leitura.
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(nova).Error; err != nil {
+			return err
+		}
+		var leitura models.Leitura
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND livro_id = ?", sessao.UserID, livroID).
+			First(&leitura).Error; err != nil {
+			return err
+		}
+
+		sessao.LeituraID = leitura.ID
+		if err := tx.Create(sessao).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"paginas_lidas": leitura.PaginasLidas + sessao.Paginas}
+		if sessao.InicioEm.Before(leitura.IniciadaEm) {
+			updates["iniciada_em"] = sessao.InicioEm
+		}
+		if leitura.ConcluidaEm == nil &&
+			(concluir || (livro.Paginas > 0 && leitura.PaginasLidas+sessao.Paginas >= livro.Paginas)) {
+			updates["concluida_em"] = sessao.FimEm
+		}
+		leituraID = leitura.ID
+		return tx.Model(&leitura).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.findByID(leituraID)
+}
+
+// FindByLivro busca a leitura do livro pelo usuário, com as sessões em ordem cronológica.
+func (r *LeituraRepository) FindByLivro(userID, livroID uint) (*models.Leitura, error) {
+	var leitura models.Leitura
+	err := r.DB.Preload("Livro").
+		Preload("Sessoes", func(db *gorm.DB) *gorm.DB { return db.Order("inicio_em, id") }).
+		Where("user_id = ? AND livro_id = ?", userID, livroID).
+		First(&leitura).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLeituraNotFound
+		}
+		return nil, err
+	}
+	leitura.PreencherProgresso()
+	return &leitura, nil
+}
+
+// List retorna uma página das leituras do usuário, das atualizadas mais recentemente para as mais
+// antigas, e o total de registros encontrados.
+func (r *LeituraRepository) List(filter LeituraFilter) ([]models.Leitura, int64, error) {
+	query := r.DB.Model(&models.Leitura{}).Where("user_id = ?", filter.UserID)
+	switch filter.Status {
+	case models.LeituraEmAndamento:
+		query = query.Where("concluida_em IS NULL")
+	case models.LeituraConcluida:
+		query = query.Where("concluida_em IS NOT NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var leituras []models.Leitura
+	err := query.Preload("Livro").
+		Order("updated_at DESC, id DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&leituras).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range leituras {
+		leituras[i].PreencherProgresso()
+	}
+	return leituras, total, nil
+}
+
+// Delete remove a leitura do livro pelo usuário e todas as sessões dela.
+func (r *LeituraRepository) Delete(userID, livroID uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var leitura models.Leitura
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND livro_id = ?", userID, livroID).
+			First(&leitura).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrLeituraNotFound
+			}
+			return err
+		}
+		if err := tx.Where("leitura_id = ?", leitura.ID).Delete(&models.SessaoLeitura{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&leitura).Error
+	})
+}
+
+// Estatisticas resume as leituras do usuário entre inicio e fim, que devem delimitar um ano. As páginas
+// são atribuídas ao mês em que cada sessão terminou, no fuso horário de inicio.
+func (r *LeituraRepository) Estatisticas(userID uint, inicio, fim time.Time) (*EstatisticasLeitura, error) {
+	estatisticas := &EstatisticasLeitura{
+		Ano:              inicio.Year(),
+		PaginasPorMes:    make([]int, 12),
+		AutoresFavoritos: []AutorFavorito{},
+	}
+
+	if err := r.DB.Model(&models.Leitura{}).
+		Where("user_id = ? AND concluida_em >= ? AND concluida_em < ?", userID, inicio, fim).
+		Count(&estatisticas.LivrosConcluidos).Error; err != nil {
+		return nil, err
+	}
+
+	var sessoes []models.SessaoLeitura
+	if err := r.DB.Select("paginas", "fim_em").
+		Where("user_id = ? AND fim_em >= ? AND fim_em < ?", userID, inicio, fim).
+		Find(&sessoes).Error; err != nil {
+		return nil, err
+	}
+	for _, sessao := range sessoes {
+		estatisticas.PaginasPorMes[sessao.FimEm.In(inicio.Location()).Month()-1] += sessao.Paginas
+		estatisticas.PaginasLidas += sessao.Paginas
+	}
+
+	if err := r.DB.Model(&models.Leitura{}).
+		Select("livros.autor AS autor, COUNT(*) AS livros, SUM(leituras.paginas_lidas) AS paginas").
+		Joins("JOIN livros ON livros.id = leituras.livro_id").
+		Where("leituras.user_id = ? AND leituras.concluida_em >= ? AND leituras.concluida_em < ?", userID, inicio, fim).
+		Where("livros.autor <> ''").
+		Group("livros.autor").
+		Order("COUNT(*) DESC, SUM(leituras.paginas_lidas) DESC, livros.autor").
+		Limit(5).
+		Scan(&estatisticas.AutoresFavoritos).Error; err != nil {
+		return nil, err
+	}
+	return estatisticas, nil
+}
+
+// DefinirMeta cria ou atualiza a meta do usuário para o ano.
+func (r *LeituraRepository) DefinirMeta(meta *models.MetaLeitura) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "ano"}},
+		DoUpdates: clause.AssignmentColumns([]string{"livros", "updated_at"}),
+	}).Create(meta).Error
+}
+
+// FindMeta busca a meta do usuário para o ano.
+func (r *LeituraRepository) FindMeta(userID uint, ano int) (*models.MetaLeitura, error) {
+	var meta models.MetaLeitura
+	if err := r.DB.Where("user_id = ? AND ano = ?", userID, ano).First(&meta).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMetaLeituraNotFound
+		}
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// DeleteMeta remove a meta do usuário para o ano.
+func (r *LeituraRepository) DeleteMeta(userID uint, ano int) error {
+	result := r.DB.Where("user_id = ? AND ano = ?", userID, ano).Delete(&models.MetaLeitura{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMetaLeituraNotFound
+	}
+	return nil
+}
+
+func (r *LeituraRepository) findByID(id uint) (*models.Leitura, error) {
+	var leitura models.Leitura
+	if err := r.DB.Preload("Livro").First(&leitura, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLeituraNotFound
+		}
+		return nil, err
+	}
+	leitura.PreencherProgresso()
+	return &leitura, nil
+}
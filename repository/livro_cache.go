@@ -0,0 +1,442 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"books_api/cache"
+	"books_api/models"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	cacheKey        = "livros"
+	cacheExpiration = 10 * time.Minute
+	// cacheStale é o tempo, depois da expiração, em que uma entrada ainda é servida enquanto é atualizada.
+	cacheStale = 2 * time.Minute
+	// cacheBloqueio limita quanto tempo uma instância segura o bloqueio de uma chave enquanto consulta o banco.
+	cacheBloqueio = 5 * time.Second
+	// cacheEspera é o intervalo entre as leituras do cache enquanto outra instância consulta o banco.
+	cacheEspera = 25 * time.Millisecond
+	// versaoKey guarda a geração atual do cache de livros. Toda alteração a incrementa, e as entradas
+	// das gerações anteriores deixam de ser lidas e expiram sozinhas.
+	versaoKey = cacheKey + ":versao"
+)
+
+// InvalidadorLivros descarta os dados em cache de um livro alterado por fora do LivroRepository, como
+// as médias de avaliação.
+type InvalidadorLivros interface {
+	InvalidarLivro(ctx context.Context, id uint) error
+}
+
+// MetricasCache conta os acessos ao cache de livros desde o início do processo.
+type MetricasCache struct {
+	// Hits são as consultas respondidas pelo cache, incluindo as Stale.
+	Hits int64 `json:"hits"`
+	// Stale são as consultas respondidas com uma entrada expirada, enquanto ela era atualizada.
+	Stale int64 `json:"stale"`
+	// Misses são as consultas que não encontraram a entrada no cache.
+	Misses int64 `json:"misses"`
+	// Coalescidas são os misses que aproveitaram a consulta ao banco de outra requisição do processo.
+	Coalescidas int64 `json:"coalescidas"`
+	// Renovacoes são as atualizações em segundo plano, por expiração ou antecipadas.
+	Renovacoes int64 `json:"renovacoes"`
+}
+
+type contadoresCache struct {
+	hits, stale, misses, coalescidas, renovacoes atomic.Int64
+}
+
+// entradaCache é o valor gravado no cache: os dados, quando foram consultados e quanto a consulta levou.
+type entradaCache struct {
+	Dados   json.RawMessage `json:"dados"`
+	Gravado time.Time       `json:"gravado"`
+	Duracao time.Duration   `json:"duracao"`
+}
+
+// CachedLivroRepository guarda no cache as consultas de outro LivroRepository e descarta o cache nas
+// alterações. Falhas no cache são registradas no log e as consultas seguem para o repositório.
+//
+// As chaves incluem a geração do cache, incrementada depois de cada alteração gravada. Como uma listagem
+// pode conter qualquer livro, uma alteração invalida todas as listagens e também as entradas por ID: uma
+// leitura que começou antes da alteração grava o resultado antigo sob a geração anterior, que ninguém
+// mais consulta.
+//
+// Para que a expiração de uma chave concorrida não leve todas as requisições ao banco, os misses da
+// mesma chave são agrupados no processo e, entre instâncias, só quem obtém o bloqueio da chave consulta
+// o banco. Entradas perto de expirar são renovadas antes, com probabilidade que cresce com a idade e o
+// custo da consulta, e entradas expiradas há menos de Stale são servidas enquanto são atualizadas.
+type CachedLivroRepository struct {
+	Repo  LivroRepository
+	Cache cache.Cache
+	TTL   time.Duration
+	Stale time.Duration
+	// Beta ajusta a renovação antecipada: valores maiores renovam mais cedo, e zero a desliga.
+	Beta     float64
+	Bloqueio time.Duration
+
+	grupo       singleflight.Group
+	atualizando sync.Map
+	// atualizacoes acompanha as renovações em segundo plano, para os testes aguardarem por elas.
+	atualizacoes sync.WaitGroup
+	contadores   contadoresCache
+	// invalidacaoPendente indica uma alteração que não conseguiu iniciar uma nova geração no cache.
+	invalidacaoPendente atomic.Bool
+
+	agora     func() time.Time
+	aleatorio func() float64
+}
+
+// NewCachedLivroRepository cria o cache de livros sobre repo. Com c nil, as consultas vão direto ao repositório.
+func NewCachedLivroRepository(repo LivroRepository, c cache.Cache) *CachedLivroRepository {
+	return &CachedLivroRepository{
+		Repo:      repo,
+		Cache:     c,
+		TTL:       cacheExpiration,
+		Stale:     cacheStale,
+		Beta:      1,
+		Bloqueio:  cacheBloqueio,
+		agora:     time.Now,
+		aleatorio: rand.Float64,
+	}
+}
+
+// Metricas retorna os contadores de acesso ao cache.
+func (r *CachedLivroRepository) Metricas() MetricasCache {
+	return MetricasCache{
+		Hits:        r.contadores.hits.Load(),
+		Stale:       r.contadores.stale.Load(),
+		Misses:      r.contadores.misses.Load(),
+		Coalescidas: r.contadores.coalescidas.Load(),
+		Renovacoes:  r.contadores.renovacoes.Load(),
+	}
+}
+
+// List retorna uma página de livros, tentando primeiro obter os dados do cache. Caso não haja cache ou
+// ocorra erro, os dados são buscados no repositório e o cache é atualizado.
+func (r *CachedLivroRepository) List(ctx context.Context, filter LivroFilter) ([]models.Livro, error) {
+	versao, ok := r.versao(ctx)
+	if !ok {
+		return r.Repo.List(ctx, filter)
+	}
+
+	var livros []models.Livro
+	err := r.buscar(ctx, listaKey(versao, filter), &livros, func(ctx context.Context) (interface{}, error) {
+		return r.Repo.List(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return livros, nil
+}
+
+// FindByID retorna um livro pelo seu ID (com cache).
+func (r *CachedLivroRepository) FindByID(ctx context.Context, id uint) (*models.Livro, error) {
+	versao, ok := r.versao(ctx)
+	if !ok {
+		return r.Repo.FindByID(ctx, id)
+	}
+
+	var livro models.Livro
+	err := r.buscar(ctx, livroKey(versao, id), &livro, func(ctx context.Context) (interface{}, error) {
+		return r.Repo.FindByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &livro, nil
+}
+
+func (r *CachedLivroRepository) Create(ctx context.Context, livro *models.Livro) error {
+	if err := r.Repo.Create(ctx, livro); err != nil {
+		return err
+	}
+	if err := r.InvalidarLivro(ctx, livro.ID); err != nil {
+		log.Printf("Erro ao invalidar cache do livro %d: %v", livro.ID, err)
+	}
+	return nil
+}
+
+func (r *CachedLivroRepository) Update(ctx context.Context, id uint, livroAtualizado *models.Livro) (*models.Livro, error) {
+	livro, err := r.Repo.Update(ctx, id, livroAtualizado)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.InvalidarLivro(ctx, id); err != nil {
+		log.Printf("Erro ao invalidar cache do livro %d: %v", id, err)
+	}
+	return livro, nil
+}
+
+func (r *CachedLivroRepository) UpdateImagem(ctx context.Context, id uint, imagePath string) error {
+	if err := r.Repo.UpdateImagem(ctx, id, imagePath); err != nil {
+		return err
+	}
+	if err := r.InvalidarLivro(ctx, id); err != nil {
+		log.Printf("Erro ao invalidar cache do livro %d: %v", id, err)
+	}
+	return nil
+}
+
+func (r *CachedLivroRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.Repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := r.InvalidarLivro(ctx, id); err != nil {
+		log.Printf("Erro ao invalidar cache do livro %d: %v", id, err)
+	}
+	return nil
+}
+
+// InvalidarLivro descarta as listagens e as entradas por ID em cache, iniciando uma nova geração.
+// O livro é informado para os implementadores que invalidam entradas individuais. Se o cache falhar, as
+// consultas deixam de usá-lo até que a nova geração seja gravada, para não servir dados antigos quando
+// ele voltar.
+func (r *CachedLivroRepository) InvalidarLivro(ctx context.Context, _ uint) error {
+	if r.Cache == nil {
+		return nil
+	}
+	if _, err := r.Cache.Incr(ctx, versaoKey); err != nil {
+		r.invalidacaoPendente.Store(true)
+		return err
+	}
+	return nil
+}
+
+// versao lê a geração atual do cache. Se o cache falhar, ele não é usado na consulta, pois não
+// há como saber se as entradas ainda valem.
+func (r *CachedLivroRepository) versao(ctx context.Context) (int64, bool) {
+	if r.Cache == nil {
+		return 0, false
+	}
+	if r.invalidacaoPendente.Load() {
+		if _, err := r.Cache.Incr(ctx, versaoKey); err != nil {
+			return 0, false
+		}
+		r.invalidacaoPendente.Store(false)
+	}
+
+	data, err := r.Cache.Get(ctx, versaoKey)
+	if errors.Is(err, cache.ErrMiss) {
+		return 0, true
+	}
+	if err != nil {
+		logErroCache("Erro ao buscar versão do cache de livros", err)
+		return 0, false
+	}
+	versao, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		log.Printf("Versão inválida no cache de livros: %v", err)
+		return 0, false
+	}
+	return versao, true
+}
+
+// buscar preenche dest com a entrada da chave, consultando o repositório com consultar no miss. Cada
+// chamador recebe a sua cópia dos dados, mesmo quando a consulta foi compartilhada.
+func (r *CachedLivroRepository) buscar(ctx context.Context, key string, dest interface{}, consultar func(context.Context) (interface{}, error)) error {
+	if entrada, ok := r.get(ctx, key); ok {
+		err := json.Unmarshal(entrada.Dados, dest)
+		if err == nil {
+			r.contadores.hits.Add(1)
+			idade := r.now().Sub(entrada.Gravado)
+			if idade >= r.TTL {
+				r.contadores.stale.Add(1)
+				r.renovar(key, consultar)
+			} else if r.renovarAntes(entrada, idade) {
+				r.renovar(key, consultar)
+			}
+			return nil
+		}
+		log.Printf("Erro ao desserializar livros do cache: %v", err)
+	}
+
+	r.contadores.misses.Add(1)
+	// A consulta agrupada não é cancelada junto com a requisição que a iniciou, pois outras a aguardam.
+	lider := false
+	dados, err, _ := r.grupo.Do(key, func() (interface{}, error) {
+		lider = true
+		return r.carregar(context.WithoutCancel(ctx), key, consultar)
+	})
+	if !lider {
+		r.contadores.coalescidas.Add(1)
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(dados.([]byte), dest)
+}
+
+// carregar consulta o repositório e grava o resultado no cache. Se outra instância já estiver
+// consultando a mesma chave, aguarda o resultado dela no cache até o fim do bloqueio e só então
+// consulta o banco.
+func (r *CachedLivroRepository) carregar(ctx context.Context, key string, consultar func(context.Context) (interface{}, error)) ([]byte, error) {
+	liberar, obtido := r.bloquear(ctx, key)
+	if obtido {
+		defer liberar()
+	} else if entrada, ok := r.aguardar(ctx, key); ok {
+		return entrada.Dados, nil
+	}
+
+	inicio := r.now()
+	valor, err := consultar(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dados, err := json.Marshal(valor)
+	if err != nil {
+		return nil, err
+	}
+	r.set(ctx, key, entradaCache{Dados: dados, Gravado: r.now(), Duracao: r.now().Sub(inicio)})
+	return dados, nil
+}
+
+// renovarAntes decide se uma entrada ainda válida deve ser renovada, com a fórmula de expiração
+// antecipada probabilística: quanto mais perto da expiração e mais cara a consulta, maior a chance.
+func (r *CachedLivroRepository) renovarAntes(entrada entradaCache, idade time.Duration) bool {
+	if r.Beta <= 0 || r.aleatorio == nil {
+		return false
+	}
+	antecipacao := float64(entrada.Duracao) * r.Beta * -math.Log(1-r.aleatorio())
+	return float64(idade)+antecipacao >= float64(r.TTL)
+}
+
+// renovar atualiza a entrada em segundo plano. Só uma renovação por chave roda no processo, e só a
+// instância que obtiver o bloqueio da chave consulta o banco.
+func (r *CachedLivroRepository) renovar(key string, consultar func(context.Context) (interface{}, error)) {
+	if _, emAndamento := r.atualizando.LoadOrStore(key, struct{}{}); emAndamento {
+		return
+	}
+
+	r.atualizacoes.Add(1)
+	go func() {
+		defer r.atualizacoes.Done()
+		defer r.atualizando.Delete(key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), r.bloqueio())
+		defer cancel()
+
+		liberar, obtido := r.bloquear(ctx, key)
+		if !obtido {
+			return
+		}
+		defer liberar()
+
+		r.contadores.renovacoes.Add(1)
+		inicio := r.now()
+		valor, err := consultar(ctx)
+		if err != nil {
+			log.Printf("Erro ao renovar cache de livros: %v", err)
+			return
+		}
+		dados, err := json.Marshal(valor)
+		if err != nil {
+			log.Printf("Erro ao serializar livros para o cache: %v", err)
+			return
+		}
+		r.set(ctx, key, entradaCache{Dados: dados, Gravado: r.now(), Duracao: r.now().Sub(inicio)})
+	}()
+}
+
+// bloquear tenta obter o bloqueio da chave entre as instâncias. Se o cache falhar, segue sem bloqueio
+// para não impedir a consulta.
+func (r *CachedLivroRepository) bloquear(ctx context.Context, key string) (func(), bool) {
+	liberar, obtido, err := r.Cache.Lock(ctx, key+":bloqueio", r.bloqueio())
+	if err != nil {
+		logErroCache("Erro ao obter bloqueio do cache de livros", err)
+		return func() {}, true
+	}
+	return liberar, obtido
+}
+
+// aguardar lê a chave até outra instância gravá-la, pelo tempo máximo do bloqueio.
+func (r *CachedLivroRepository) aguardar(ctx context.Context, key string) (entradaCache, bool) {
+	limite := time.NewTimer(r.bloqueio())
+	defer limite.Stop()
+	ticker := time.NewTicker(cacheEspera)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return entradaCache{}, false
+		case <-limite.C:
+			return entradaCache{}, false
+		case <-ticker.C:
+			if entrada, ok := r.get(ctx, key); ok {
+				return entrada, true
+			}
+		}
+	}
+}
+
+func (r *CachedLivroRepository) bloqueio() time.Duration {
+	if r.Bloqueio > 0 {
+		return r.Bloqueio
+	}
+	return cacheBloqueio
+}
+
+func (r *CachedLivroRepository) now() time.Time {
+	if r.agora != nil {
+		return r.agora()
+	}
+	return time.Now()
+}
+
+// get lê a entrada da chave, informando se encontrou um valor válido.
+func (r *CachedLivroRepository) get(ctx context.Context, key string) (entradaCache, bool) {
+	var entrada entradaCache
+	data, err := r.Cache.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			logErroCache("Erro ao buscar livros no cache", err)
+		}
+		return entrada, false
+	}
+	if err := json.Unmarshal(data, &entrada); err != nil {
+		log.Printf("Erro ao desserializar livros do cache: %v", err)
+		return entrada, false
+	}
+	return entrada, true
+}
+
+// set grava a entrada no cache. Ela é mantida por TTL mais Stale, para poder ser servida enquanto é
+// atualizada depois de expirar.
+func (r *CachedLivroRepository) set(ctx context.Context, key string, entrada entradaCache) {
+	cacheData, err := json.Marshal(entrada)
+	if err != nil {
+		log.Printf("Erro ao serializar livros para o cache: %v", err)
+		return
+	}
+	if err := r.Cache.Set(ctx, key, cacheData, r.TTL+r.Stale); err != nil {
+		logErroCache("Erro ao atualizar cache", err)
+	}
+}
+
+// logErroCache registra a falha do cache, exceto quando ele está desligado, o que já foi registrado.
+func logErroCache(mensagem string, err error) {
+	if !errors.Is(err, cache.ErrUnavailable) {
+		log.Printf("%s: %v", mensagem, err)
+	}
+}
+
+// listaKey monta a chave de uma página da listagem. Os campos entram sempre na mesma ordem, para que a
+// mesma consulta use sempre a mesma chave.
+func listaKey(versao int64, filter LivroFilter) string {
+	return fmt.Sprintf("%s:v%d:lista:page=%d:limit=%d:ordem=%s", cacheKey, versao, filter.Page, filter.Limit, filter.Ordem)
+}
+
+func livroKey(versao int64, id uint) string {
+	return fmt.Sprintf("%s:v%d:id:%d", cacheKey, versao, id)
+}
@@ -0,0 +1,454 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"books_api/cache"
+	"books_api/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCachedLivros monta o cache sobre um repositório em memória e um Redis falso.
+func newCachedLivros(t *testing.T, livros ...models.Livro) (*CachedLivroRepository, *MemoryLivroRepository, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	repo := NewMemoryLivroRepository(livros...)
+	return NewCachedLivroRepository(repo, cache.NewRedis(client)), repo, mr
+}
+
+func titulos(livros []models.Livro) []string {
+	result := make([]string, len(livros))
+	for i, livro := range livros {
+		result[i] = livro.Titulo
+	}
+	return result
+}
+
+func TestCachedLivroRepositoryUsaCache(t *testing.T) {
+	ctx := context.Background()
+	cached, repo, mr := newCachedLivros(t, models.Livro{Titulo: "Dom Casmurro", Autor: "Machado de Assis"})
+	filter := LivroFilter{Page: 1, Limit: 10, Ordem: "titulo"}
+
+	_, err := cached.List(ctx, filter)
+	require.NoError(t, err)
+	_, err = cached.FindByID(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, mr.Exists(listaKey(0, filter)))
+	assert.True(t, mr.Exists(livroKey(0, 1)))
+
+	// Alterações feitas por fora do cache não aparecem até a invalidação.
+	_, err = repo.Update(ctx, 1, &models.Livro{Titulo: "Memórias Póstumas", Autor: "Machado de Assis"})
+	require.NoError(t, err)
+
+	livros, err := cached.List(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Dom Casmurro"}, titulos(livros))
+
+	require.NoError(t, cached.InvalidarLivro(ctx, 1))
+	livros, err = cached.List(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Memórias Póstumas"}, titulos(livros))
+	livro, err := cached.FindByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Memórias Póstumas", livro.Titulo)
+}
+
+func TestCachedLivroRepositoryLeituraAposEscrita(t *testing.T) {
+	ctx := context.Background()
+	filters := []LivroFilter{
+		{Page: 1, Limit: 10},
+		{Page: 1, Limit: 10, Ordem: "titulo"},
+		{Page: 1, Limit: 1, Ordem: "titulo"},
+		{Page: 2, Limit: 1, Ordem: "titulo"},
+	}
+
+	tests := []struct {
+		name    string
+		escrita func(t *testing.T, cached *CachedLivroRepository)
+		// esperado traz os títulos de cada filtro depois da escrita.
+		esperado [][]string
+		titulo   string
+	}{
+		{
+			name: "criação",
+			escrita: func(t *testing.T, cached *CachedLivroRepository) {
+				require.NoError(t, cached.Create(ctx, &models.Livro{Titulo: "A Hora da Estrela", Autor: "Clarice Lispector"}))
+			},
+			esperado: [][]string{
+				{"O Cortiço", "Iracema", "A Hora da Estrela"},
+				{"A Hora da Estrela", "Iracema", "O Cortiço"},
+				{"A Hora da Estrela"},
+				{"Iracema"},
+			},
+			titulo: "O Cortiço",
+		},
+		{
+			name: "atualização",
+			escrita: func(t *testing.T, cached *CachedLivroRepository) {
+				_, err := cached.Update(ctx, 1, &models.Livro{Titulo: "Casa de Pensão", Autor: "Aluísio Azevedo"})
+				require.NoError(t, err)
+			},
+			esperado: [][]string{
+				{"Casa de Pensão", "Iracema"},
+				{"Casa de Pensão", "Iracema"},
+				{"Casa de Pensão"},
+				{"Iracema"},
+			},
+			titulo: "Casa de Pensão",
+		},
+		{
+			name: "imagem",
+			escrita: func(t *testing.T, cached *CachedLivroRepository) {
+				require.NoError(t, cached.UpdateImagem(ctx, 1, "uploads/1.png"))
+			},
+			esperado: [][]string{
+				{"O Cortiço", "Iracema"},
+				{"Iracema", "O Cortiço"},
+				{"Iracema"},
+				{"O Cortiço"},
+			},
+			titulo: "O Cortiço",
+		},
+		{
+			name: "remoção",
+			escrita: func(t *testing.T, cached *CachedLivroRepository) {
+				require.NoError(t, cached.Delete(ctx, 1))
+			},
+			esperado: [][]string{
+				{"Iracema"},
+				{"Iracema"},
+				{"Iracema"},
+				{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cached, _, _ := newCachedLivros(t,
+				models.Livro{Titulo: "O Cortiço", Autor: "Aluísio Azevedo"},
+				models.Livro{Titulo: "Iracema", Autor: "José de Alencar"},
+			)
+
+			// Preenche o cache de todas as listagens e do livro alterado.
+			for _, filter := range filters {
+				_, err := cached.List(ctx, filter)
+				require.NoError(t, err)
+			}
+			_, err := cached.FindByID(ctx, 1)
+			require.NoError(t, err)
+
+			tt.escrita(t, cached)
+
+			for i, filter := range filters {
+				livros, err := cached.List(ctx, filter)
+				require.NoError(t, err)
+				assert.Equal(t, tt.esperado[i], titulos(livros), "filtro %+v", filter)
+			}
+
+			livro, err := cached.FindByID(ctx, 1)
+			if tt.titulo == "" {
+				assert.ErrorIs(t, err, ErrLivroNotFound)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.titulo, livro.Titulo)
+			if tt.name == "imagem" {
+				assert.Equal(t, "uploads/1.png", livro.ImagePath)
+			}
+		})
+	}
+}
+
+func TestCachedLivroRepositoryRedisIndisponivel(t *testing.T) {
+	ctx := context.Background()
+	cached, _, mr := newCachedLivros(t, models.Livro{Titulo: "Vidas Secas", Autor: "Graciliano Ramos"})
+	mr.Close()
+
+	livros, err := cached.List(ctx, LivroFilter{Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Vidas Secas"}, titulos(livros))
+
+	_, err = cached.Update(ctx, 1, &models.Livro{Titulo: "São Bernardo", Autor: "Graciliano Ramos"})
+	require.NoError(t, err)
+	livro, err := cached.FindByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "São Bernardo", livro.Titulo)
+}
+
+func TestCachedLivroRepositorySemRedis(t *testing.T) {
+	ctx := context.Background()
+	cached := NewCachedLivroRepository(NewMemoryLivroRepository(), nil)
+
+	livro := models.Livro{Titulo: "Macunaíma", Autor: "Mário de Andrade"}
+	require.NoError(t, cached.Create(ctx, &livro))
+	encontrado, err := cached.FindByID(ctx, livro.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Macunaíma", encontrado.Titulo)
+}
+
+func TestLivroCacheKeys(t *testing.T) {
+	filter := LivroFilter{Page: 12, Limit: 100, Ordem: "avaliacao"}
+	assert.Equal(t, "livros:v3:lista:page=12:limit=100:ordem=avaliacao", listaKey(3, filter))
+	assert.Equal(t, listaKey(3, filter), listaKey(3, LivroFilter{Ordem: "avaliacao", Limit: 100, Page: 12}))
+	assert.NotEqual(t, listaKey(3, filter), listaKey(4, filter))
+	assert.Equal(t, "livros:v0:id:42", livroKey(0, 42))
+}
+
+// livrosLentos conta as consultas ao repositório e as atrasa, para que as requisições concorrentes se
+// sobreponham.
+type livrosLentos struct {
+	LivroRepository
+	consultas atomic.Int64
+	atraso    time.Duration
+}
+
+func (r *livrosLentos) List(ctx context.Context, filter LivroFilter) ([]models.Livro, error) {
+	r.consultas.Add(1)
+	time.Sleep(r.atraso)
+	return r.LivroRepository.List(ctx, filter)
+}
+
+func listarConcorrente(t *testing.T, caches ...*CachedLivroRepository) {
+	t.Helper()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		cached := caches[i%len(caches)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			livros, err := cached.List(context.Background(), LivroFilter{Page: 1, Limit: 10})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"Grande Sertão: Veredas"}, titulos(livros))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCachedLivroRepositoryAgrupaMisses(t *testing.T) {
+	cached, repo, _ := newCachedLivros(t, models.Livro{Titulo: "Grande Sertão: Veredas", Autor: "Guimarães Rosa"})
+	lento := &livrosLentos{LivroRepository: repo, atraso: 100 * time.Millisecond}
+	cached.Repo = lento
+
+	listarConcorrente(t, cached)
+
+	assert.Equal(t, int64(1), lento.consultas.Load())
+	metricas := cached.Metricas()
+	assert.Equal(t, int64(20), metricas.Misses)
+	assert.Equal(t, int64(19), metricas.Coalescidas)
+}
+
+func TestCachedLivroRepositoryBloqueioEntreInstancias(t *testing.T) {
+	primeira, repo, mr := newCachedLivros(t, models.Livro{Titulo: "Grande Sertão: Veredas", Autor: "Guimarães Rosa"})
+	lento := &livrosLentos{LivroRepository: repo, atraso: 100 * time.Millisecond}
+	primeira.Repo = lento
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	segunda := NewCachedLivroRepository(lento, cache.NewRedis(client))
+
+	listarConcorrente(t, primeira, segunda)
+
+	assert.Equal(t, int64(1), lento.consultas.Load())
+}
+
+func TestCachedLivroRepositoryServeExpiradoEnquantoAtualiza(t *testing.T) {
+	ctx := context.Background()
+	cached, repo, _ := newCachedLivros(t, models.Livro{Titulo: "Dom Casmurro", Autor: "Machado de Assis"})
+	cached.Beta = 0
+	agora := time.Now()
+	cached.agora = func() time.Time { return agora }
+	filter := LivroFilter{Page: 1, Limit: 10}
+
+	_, err := cached.List(ctx, filter)
+	require.NoError(t, err)
+	_, err = repo.Update(ctx, 1, &models.Livro{Titulo: "Quincas Borba", Autor: "Machado de Assis"})
+	require.NoError(t, err)
+
+	// Ainda dentro do TTL, a entrada é servida sem renovação.
+	agora = agora.Add(cached.TTL - time.Second)
+	livros, err := cached.List(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Dom Casmurro"}, titulos(livros))
+	assert.Zero(t, cached.Metricas().Renovacoes)
+
+	// Expirada, a entrada antiga é servida e renovada em segundo plano.
+	agora = agora.Add(2 * time.Second)
+	livros, err = cached.List(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Dom Casmurro"}, titulos(livros))
+	cached.atualizacoes.Wait()
+
+	livros, err = cached.List(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Quincas Borba"}, titulos(livros))
+
+	metricas := cached.Metricas()
+	assert.Equal(t, int64(3), metricas.Hits)
+	assert.Equal(t, int64(1), metricas.Stale)
+	assert.Equal(t, int64(1), metricas.Misses)
+	assert.Equal(t, int64(1), metricas.Renovacoes)
+}
+
+func TestCachedLivroRepositoryRenovacaoAntecipada(t *testing.T) {
+	ctx := context.Background()
+	cached, repo, _ := newCachedLivros(t, models.Livro{Titulo: "Dom Casmurro", Autor: "Machado de Assis"})
+	filter := LivroFilter{Page: 1, Limit: 10}
+
+	_, err := cached.List(ctx, filter)
+	require.NoError(t, err)
+	_, err = repo.Update(ctx, 1, &models.Livro{Titulo: "Quincas Borba", Autor: "Machado de Assis"})
+	require.NoError(t, err)
+
+	// Com o sorteio no limite, a renovação não é antecipada.
+	cached.aleatorio = func() float64 { return 0 }
+	_, err = cached.List(ctx, filter)
+	require.NoError(t, err)
+	assert.Zero(t, cached.Metricas().Renovacoes)
+
+	// Com o sorteio no outro extremo, a entrada é servida e renovada antes de expirar.
+	cached.aleatorio = func() float64 { return 1 }
+	livros, err := cached.List(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Dom Casmurro"}, titulos(livros))
+	cached.atualizacoes.Wait()
+	assert.Equal(t, int64(1), cached.Metricas().Renovacoes)
+
+	cached.aleatorio = func() float64 { return 0 }
+	livros, err = cached.List(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Quincas Borba"}, titulos(livros))
+}
+
+func TestRenovarAntes(t *testing.T) {
+	cached := &CachedLivroRepository{TTL: time.Minute, Beta: 1, aleatorio: func() float64 { return 1 - math.Exp(-1) }}
+	entrada := entradaCache{Duracao: 2 * time.Second}
+
+	// -ln(1 - x) = 1, então a renovação é antecipada em Duracao * Beta.
+	assert.False(t, cached.renovarAntes(entrada, 57*time.Second))
+	assert.True(t, cached.renovarAntes(entrada, 58*time.Second))
+
+	cached.Beta = 0
+	assert.False(t, cached.renovarAntes(entrada, 59*time.Second))
+}
+
+func TestCachedLivroRepositoryCacheEmMemoria(t *testing.T) {
+	ctx := context.Background()
+	cached := NewCachedLivroRepository(NewMemoryLivroRepository(models.Livro{Titulo: "Vidas Secas", Autor: "Graciliano Ramos"}), cache.NewMemory(100))
+	filter := LivroFilter{Page: 1, Limit: 10}
+
+	_, err := cached.List(ctx, filter)
+	require.NoError(t, err)
+	_, err = cached.Update(ctx, 1, &models.Livro{Titulo: "São Bernardo", Autor: "Graciliano Ramos"})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		livros, err := cached.List(ctx, filter)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"São Bernardo"}, titulos(livros))
+	}
+	assert.Equal(t, int64(1), cached.Metricas().Hits)
+	assert.Equal(t, int64(2), cached.Metricas().Misses)
+}
+
+func TestCachedLivroRepositoryInvalidacaoPendente(t *testing.T) {
+	ctx := context.Background()
+	cached, _, mr := newCachedLivros(t, models.Livro{Titulo: "Vidas Secas", Autor: "Graciliano Ramos"})
+	filter := LivroFilter{Page: 1, Limit: 10}
+
+	_, err := cached.List(ctx, filter)
+	require.NoError(t, err)
+
+	// A alteração é gravada, mas o cache está fora do ar e não recebe a nova geração.
+	mr.SetError("fora do ar")
+	_, err = cached.Update(ctx, 1, &models.Livro{Titulo: "São Bernardo", Autor: "Graciliano Ramos"})
+	require.NoError(t, err)
+
+	livros, err := cached.List(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"São Bernardo"}, titulos(livros))
+
+	// Quando o cache volta, a entrada anterior à alteração não é mais servida.
+	mr.SetError("")
+	livros, err = cached.List(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"São Bernardo"}, titulos(livros))
+	assert.False(t, cached.invalidacaoPendente.Load())
+}
+
+func TestCachedLivroRepositoryDisjuntorAberto(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	breaker := cache.NewBreaker(cache.NewRedis(client), 1, time.Hour)
+	cached := NewCachedLivroRepository(NewMemoryLivroRepository(models.Livro{Titulo: "Vidas Secas", Autor: "Graciliano Ramos"}), breaker)
+
+	mr.SetError("fora do ar")
+	for i := 0; i < 3; i++ {
+		livros, err := cached.List(ctx, LivroFilter{Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Vidas Secas"}, titulos(livros))
+	}
+	assert.True(t, breaker.Open())
+}
+
+func TestCachedLivroRepositoryDuasInstancias(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	repo := NewMemoryLivroRepository(models.Livro{Titulo: "Vidas Secas", Autor: "Graciliano Ramos"})
+
+	instancia := func() *CachedLivroRepository {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		tiered := cache.NewTiered(cache.NewMemory(100), cache.NewRedis(client), client, time.Minute)
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			tiered.Run(runCtx)
+		}()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+		return NewCachedLivroRepository(repo, tiered)
+	}
+	a, b := instancia(), instancia()
+	require.Eventually(t, func() bool {
+		return mr.PubSubNumSub(cache.InvalidationChannel)[cache.InvalidationChannel] == 2
+	}, time.Second, 10*time.Millisecond)
+
+	filter := LivroFilter{Page: 1, Limit: 10}
+	for _, cached := range []*CachedLivroRepository{a, b} {
+		_, err := cached.List(ctx, filter)
+		require.NoError(t, err)
+		_, err = cached.FindByID(ctx, 1)
+		require.NoError(t, err)
+	}
+
+	_, err := a.Update(ctx, 1, &models.Livro{Titulo: "São Bernardo", Autor: "Graciliano Ramos"})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		livros, err := b.List(ctx, filter)
+		if err != nil || len(livros) != 1 || livros[0].Titulo != "São Bernardo" {
+			return false
+		}
+		livro, err := b.FindByID(ctx, 1)
+		return err == nil && livro.Titulo == "São Bernardo"
+	}, time.Second, 10*time.Millisecond)
+}
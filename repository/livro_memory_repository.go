@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"books_api/models"
+)
+
+// MemoryLivroRepository guarda os livros em memória. É usado nos testes, sem banco de dados.
+type MemoryLivroRepository struct {
+	mu     sync.RWMutex
+	livros map[uint]models.Livro
+	nextID uint
+}
+
+func NewMemoryLivroRepository(livros ...models.Livro) *MemoryLivroRepository {
+	r := &MemoryLivroRepository{livros: make(map[uint]models.Livro)}
+	for i := range livros {
+		r.Create(context.Background(), &livros[i])
+	}
+	return r
+}
+
+// List retorna uma página de livros na ordem informada, com os mesmos critérios do banco de dados.
+func (r *MemoryLivroRepository) List(_ context.Context, filter LivroFilter) ([]models.Livro, error) {
+	r.mu.RLock()
+	livros := make([]models.Livro, 0, len(r.livros))
+	for _, livro := range r.livros {
+		livros = append(livros, livro)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(livros, func(i, j int) bool {
+		a, b := livros[i], livros[j]
+		switch filter.Ordem {
+		case "titulo":
+			if a.Titulo != b.Titulo {
+				return a.Titulo < b.Titulo
+			}
+		case "ano":
+			if a.Ano != b.Ano {
+				return a.Ano > b.Ano
+			}
+		case "avaliacao":
+			if a.MediaAvaliacoes != b.MediaAvaliacoes {
+				return a.MediaAvaliacoes > b.MediaAvaliacoes
+			}
+			if a.TotalAvaliacoes != b.TotalAvaliacoes {
+				return a.TotalAvaliacoes > b.TotalAvaliacoes
+			}
+		case "avaliacoes":
+			if a.TotalAvaliacoes != b.TotalAvaliacoes {
+				return a.TotalAvaliacoes > b.TotalAvaliacoes
+			}
+			if a.MediaAvaliacoes != b.MediaAvaliacoes {
+				return a.MediaAvaliacoes > b.MediaAvaliacoes
+			}
+		}
+		return a.ID < b.ID
+	})
+
+	inicio := (filter.Page - 1) * filter.Limit
+	if inicio < 0 || inicio >= len(livros) {
+		return []models.Livro{}, nil
+	}
+	fim := inicio + filter.Limit
+	if filter.Limit <= 0 || fim > len(livros) {
+		fim = len(livros)
+	}
+	return livros[inicio:fim], nil
+}
+
+func (r *MemoryLivroRepository) FindByID(_ context.Context, id uint) (*models.Livro, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	livro, ok := r.livros[id]
+	if !ok {
+		return nil, ErrLivroNotFound
+	}
+	return &livro, nil
+}
+
+// Create grava o livro, atribuindo um ID se ele ainda não tiver.
+func (r *MemoryLivroRepository) Create(_ context.Context, livro *models.Livro) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if livro.ID == 0 {
+		r.nextID++
+		livro.ID = r.nextID
+	} else if livro.ID > r.nextID {
+		r.nextID = livro.ID
+	}
+	r.livros[livro.ID] = *livro
+	return nil
+}
+
+func (r *MemoryLivroRepository) Update(_ context.Context, id uint, livroAtualizado *models.Livro) (*models.Livro, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	livro, ok := r.livros[id]
+	if !ok {
+		return nil, ErrLivroNotFound
+	}
+	aplicarAlteracoes(&livro, livroAtualizado)
+	r.livros[id] = livro
+	return &livro, nil
+}
+
+func (r *MemoryLivroRepository) UpdateImagem(_ context.Context, id uint, imagePath string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	livro, ok := r.livros[id]
+	if !ok {
+		return ErrLivroNotFound
+	}
+	livro.ImagePath = imagePath
+	r.livros[id] = livro
+	return nil
+}
+
+// Delete remove o livro. Remover um livro inexistente não é erro, como no banco de dados.
+func (r *MemoryLivroRepository) Delete(_ context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.livros, id)
+	return nil
+}
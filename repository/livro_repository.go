@@ -2,195 +2,155 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"log"
-	"strconv"
-	"time"
 
-	"books_api/config"
 	"books_api/models"
 
-	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-const (
-	cacheKey        = "livros"
-	cacheExpiration = 10 * time.Minute
-)
-
-// GetLivrosFromCache retorna uma lista paginada de livros, tentando primeiro obter os dados do cache.
-// Caso não haja cache ou ocorra erro, os dados são buscados no banco de dados e o cache é atualizado.
-func GetLivrosFromCache(ctx context.Context, page, limit int, filters map[string]interface{}) ([]models.Livro, error) {
-	var livros []models.Livro
-	offset := (page - 1) * limit
-
-	cacheKeyWithParams := cacheKey + getCacheKey(filters, page, limit)
+// Ordenações aceitas na listagem de livros.
+var livroOrdens = map[string]string{
+	"":           "id",
+	"titulo":     "titulo, id",
+	"ano":        "ano DESC, id",
+	"avaliacao":  "media_avaliacoes DESC, total_avaliacoes DESC, id",
+	"avaliacoes": "total_avaliacoes DESC, media_avaliacoes DESC, id",
+}
 
-	// Tenta obter os livros do cache Redis.
-	data, err := config.RedisClient.Get(ctx, cacheKeyWithParams).Result()
-	if err == nil {
-		if err = json.Unmarshal([]byte(data), &livros); err == nil {
-			return livros, nil
-		}
-		log.Printf("Erro ao desserializar livros do cache: %v", err)
-	} else if err != redis.Nil {
-		log.Printf("Erro ao buscar livros no Redis: %v", err)
-	}
+// ValidLivroOrdem informa se a ordenação da listagem de livros é conhecida.
+func ValidLivroOrdem(ordem string) bool {
+	_, ok := livroOrdens[ordem]
+	return ok
+}
 
-	// Monta a query com os filtros
-	query := config.DB.WithContext(ctx).Model(&models.Livro{})
-	for key, value := range filters {
-		query = query.Where(key+" = ?", value)
-	}
+// LivroFilter define a paginação e a ordenação da listagem de livros.
+type LivroFilter struct {
+	Page  int
+	Limit int
+	// Ordem é uma das chaves de livroOrdens.
+	Ordem string
+}
 
-	// Busca os livros no banco de dados
-	if err = query.Offset(offset).Limit(limit).Find(&livros).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			log.Println("Nenhum livro encontrado no banco de dados")
-			return nil, nil
-		}
-		log.Printf("Erro ao buscar livros no banco de dados: %v", err)
-		return nil, err
-	}
+// LivroRepository é o armazenamento do acervo de livros.
+type LivroRepository interface {
+	List(ctx context.Context, filter LivroFilter) ([]models.Livro, error)
+	// FindByID retorna ErrLivroNotFound se o livro não existir.
+	FindByID(ctx context.Context, id uint) (*models.Livro, error)
+	Create(ctx context.Context, livro *models.Livro) error
+	// Update altera os dados cadastrais do livro. Campos de avaliação não são regravados, e gênero,
+	// imagem e páginas vazios mantêm o valor atual.
+	Update(ctx context.Context, id uint, livro *models.Livro) (*models.Livro, error)
+	UpdateImagem(ctx context.Context, id uint, imagePath string) error
+	// Delete retorna ErrEmprestimosAtivos se algum exemplar do livro estiver emprestado.
+	Delete(ctx context.Context, id uint) error
+}
 
-	// Atualiza o cache Redis com os dados obtidos.
-	if err := updateCache(ctx, cacheKeyWithParams, livros); err != nil {
-		log.Printf("Erro ao atualizar cache: %v", err)
-	}
+// PostgresLivroRepository guarda os livros no banco de dados.
+type PostgresLivroRepository struct {
+	DB *gorm.DB
+}
 
-	return livros, nil
+func NewPostgresLivroRepository(db *gorm.DB) *PostgresLivroRepository {
+	return &PostgresLivroRepository{DB: db}
 }
 
-// GetLivroByID retorna um livro pelo seu ID (com cache).
-func GetLivroByID(ctx context.Context, id uint) (*models.Livro, error) {
-	cacheKeyByID := cacheKey + ":id:" + strconv.Itoa(int(id))
+// List retorna uma página de livros na ordem informada (veja livroOrdens).
+func (r *PostgresLivroRepository) List(ctx context.Context, filter LivroFilter) ([]models.Livro, error) {
+	var livros []models.Livro
+	err := r.DB.WithContext(ctx).
+		Order(livroOrdens[filter.Ordem]).
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&livros).Error
+	return livros, err
+}
 
+// FindByID busca um livro pelo ID.
+func (r *PostgresLivroRepository) FindByID(ctx context.Context, id uint) (*models.Livro, error) {
 	var livro models.Livro
-
-	// Tenta buscar do cache
-	data, err := config.RedisClient.Get(ctx, cacheKeyByID).Result()
-	if err == nil {
-		if err = json.Unmarshal([]byte(data), &livro); err == nil {
-			return &livro, nil
-		}
-		log.Printf("Erro ao desserializar livro do cache: %v", err)
-	} else if err != redis.Nil {
-		log.Printf("Erro ao acessar Redis: %v", err)
-	}
-
-	// Busca no banco de dados
-	if err := config.DB.WithContext(ctx).First(&livro, id).Error; err != nil {
+	if err := r.DB.WithContext(ctx).First(&livro, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
+			return nil, ErrLivroNotFound
 		}
 		return nil, err
 	}
-
-	// Atualiza cache
-	go func() {
-		if err := updateCache(ctx, cacheKeyByID, livro); err != nil {
-			log.Printf("Erro ao atualizar cache de livro por ID: %v", err)
-		}
-	}()
-
 	return &livro, nil
 }
 
-// CreateLivro adiciona um novo livro ao banco de dados dentro de uma transação e invalida o cache.
-func CreateLivro(ctx context.Context, livro *models.Livro) error {
-	return config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(livro).Error; err != nil {
-			return err
-		}
-
-		// Invalida cache após inserção
-		go func() {
-			err := invalidateCache(ctx)
-			if err != nil {
-
-			}
-		}()
-		return nil
-	})
+// Create adiciona um novo livro.
+func (r *PostgresLivroRepository) Create(ctx context.Context, livro *models.Livro) error {
+	return r.DB.WithContext(ctx).Create(livro).Error
 }
 
-// UpdateLivro atualiza um livro existente dentro de uma transação e invalida o cache.
-func UpdateLivro(ctx context.Context, id uint, livroAtualizado *models.Livro) (*models.Livro, error) {
+// Update atualiza um livro existente dentro de uma transação.
+func (r *PostgresLivroRepository) Update(ctx context.Context, id uint, livroAtualizado *models.Livro) (*models.Livro, error) {
 	var livro models.Livro
-
-	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.First(&livro, id).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return nil
+				return ErrLivroNotFound
 			}
 			return err
 		}
 
-		livro.Titulo = livroAtualizado.Titulo
-		livro.Autor = livroAtualizado.Autor
-		if livroAtualizado.ImagePath != "" {
-			livro.ImagePath = livroAtualizado.ImagePath
-		}
-
-		if err := tx.Save(&livro).Error; err != nil {
-			return err
-		}
+		aplicarAlteracoes(&livro, livroAtualizado)
 
-		// Invalida cache em segundo plano
-		go func() {
-			err := invalidateCache(ctx)
-			if err != nil {
-				log.Printf("Erro ao invalidar cache: %v", err)
-			}
-		}()
-		return nil
+		// Os campos de avaliação são mantidos pelo repositório de avaliações e não são regravados aqui.
+		return tx.Model(&livro).Select("Titulo", "Autor", "Genero", "ImagePath", "Paginas").Updates(&livro).Error
 	})
-
 	if err != nil {
 		return nil, err
 	}
 	return &livro, nil
 }
 
-// DeleteLivro remove um livro dentro de uma transação e invalida o cache.
-func DeleteLivro(ctx context.Context, id uint) error {
-	return config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Delete(&models.Livro{}, id).Error; err != nil {
+// UpdateImagem altera só o caminho da imagem do livro.
+func (r *PostgresLivroRepository) UpdateImagem(ctx context.Context, id uint, imagePath string) error {
+	result := r.DB.WithContext(ctx).Model(&models.Livro{}).Where("id = ?", id).Update("image_path", imagePath)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLivroNotFound
+	}
+	return nil
+}
+
+// Delete remove um livro, desde que nenhum exemplar dele esteja emprestado.
+func (r *PostgresLivroRepository) Delete(ctx context.Context, id uint) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// O bloqueio do livro impede que um empréstimo seja registrado durante a exclusão.
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Livro{}, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
 			return err
 		}
-
-		go func() {
-			err := invalidateCache(ctx)
-			if err != nil {
-
-			}
-		}()
-		return nil
+		ativos, err := contarEmprestimosAtivos(tx, "livro_id = ?", id)
+		if err != nil {
+			return err
+		}
+		if ativos > 0 {
+			return ErrEmprestimosAtivos
+		}
+		return tx.Delete(&models.Livro{}, id).Error
 	})
 }
 
-// updateCache armazena os livros no Redis com um tempo de expiração definido.
-func updateCache(ctx context.Context, key string, data interface{}) error {
-	cacheData, err := json.Marshal(data)
-	if err != nil {
-		return err
+// aplicarAlteracoes copia para livro os dados cadastrais alterados, seguindo as regras de LivroRepository.Update.
+func aplicarAlteracoes(livro, alterado *models.Livro) {
+	livro.Titulo = alterado.Titulo
+	livro.Autor = alterado.Autor
+	if alterado.Genero != "" {
+		livro.Genero = alterado.Genero
 	}
-
-	return config.RedisClient.Set(ctx, key, cacheData, cacheExpiration).Err()
-}
-
-// invalidateCache remove a chave do cache para garantir dados atualizados.
-func invalidateCache(ctx context.Context) error {
-	return config.RedisClient.Del(ctx, cacheKey).Err()
-}
-
-// getCacheKey gera uma chave de cache personalizada para filtros e paginação.
-func getCacheKey(filters map[string]interface{}, page, limit int) string {
-	key := ""
-	for k, v := range filters {
-		key += ":" + k + "=" + v.(string)
+	if alterado.ImagePath != "" {
+		livro.ImagePath = alterado.ImagePath
+	}
+	if alterado.Paginas > 0 {
+		livro.Paginas = alterado.Paginas
 	}
-	return key + ":page=" + string(rune(page)) + ":limit=" + string(rune(limit))
 }
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"books_api/models"
+	"gorm.io/gorm"
+)
+
+type LoginAttemptRepository struct {
+	DB *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{DB: db}
+}
+
+// Create registra uma tentativa de login.
+func (r *LoginAttemptRepository) Create(attempt *models.LoginAttempt) error {
+	return r.DB.Create(attempt).Error
+}
+
+// ListByUser retorna as tentativas de login mais recentes de um usuário.
+func (r *LoginAttemptRepository) ListByUser(userID uint, limit int) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	err := r.DB.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&attempts).Error
+	return attempts, err
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"books_api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrMultaNotFound   = errors.New("multa não encontrada")
+	ErrMultaPaga       = errors.New("multa já foi paga")
+	ErrMultaEmAberto   = errors.New("a multa só pode ser paga após a devolução do livro")
+	ErrMultasPendentes = errors.New("usuário possui multas pendentes acima do limite permitido")
+	ErrMultasNaoPagas  = errors.New("usuário possui multas não pagas")
+)
+
+type MultaRepository struct {
+	DB *gorm.DB
+}
+
+// MultaFilter define os critérios de busca e paginação da listagem de multas.
+type MultaFilter struct {
+	UserID *uint
+	// Status é models.MultaPendente ou models.MultaPaga.
+	Status string
+	Page   int
+	Limit  int
+}
+
+func NewMultaRepository(db *gorm.DB) *MultaRepository {
+	return &MultaRepository{DB: db}
+}
+
+// Registrar cria ou atualiza a multa do empréstimo. Multas já pagas não são alteradas.
+func (r *MultaRepository) Registrar(emprestimo *models.Emprestimo, dias int, valor int64) error {
+	multa := &models.Multa{
+		UserID:       emprestimo.UserID,
+		EmprestimoID: emprestimo.ID,
+		DiasAtraso:   dias,
+		Valor:        valor,
+	}
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "emprestimo_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"dias_atraso", "valor", "updated_at"}),
+		// A tabela é referenciada pelo nome gerado pelo GORM, que é "multa", e não "multas".
+		Where: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "?.paga_em IS NULL", Vars: []interface{}{clause.Table{Name: clause.CurrentTable}}}}},
+	}).Create(multa).Error
+}
+
+// FindByID busca uma multa pelo ID, incluindo o empréstimo e o livro.
+func (r *MultaRepository) FindByID(id uint) (*models.Multa, error) {
+	var multa models.Multa
+	if err := r.DB.Preload("Emprestimo.Livro").First(&multa, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMultaNotFound
+		}
+		return nil, err
+	}
+	return &multa, nil
+}
+
+// List retorna uma página de multas que atendem ao filtro, da mais recente para a mais antiga,
+// e o total de registros encontrados.
+func (r *MultaRepository) List(filter MultaFilter) ([]models.Multa, int64, error) {
+	query := r.DB.Model(&models.Multa{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	switch filter.Status {
+	case models.MultaPendente:
+		query = query.Where("paga_em IS NULL")
+	case models.MultaPaga:
+		query = query.Where("paga_em IS NOT NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var multas []models.Multa
+	err := query.Preload("Emprestimo.Livro").
+		Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&multas).Error
+	return multas, total, err
+}
+
+// TotalPendente soma as multas ainda não pagas do usuário, em centavos.
+func (r *MultaRepository) TotalPendente(userID uint) (int64, error) {
+	return totalMultasPendentes(r.DB, userID)
+}
+
+// Pagar registra o pagamento de uma multa de empréstimo já devolvido.
+func (r *MultaRepository) Pagar(id uint, at time.Time) (*models.Multa, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var multa models.Multa
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Emprestimo").First(&multa, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMultaNotFound
+			}
+			return err
+		}
+		if multa.PagaEm != nil {
+			return ErrMultaPaga
+		}
+		if multa.Emprestimo != nil && multa.Emprestimo.DataDevolucao == nil {
+			return ErrMultaEmAberto
+		}
+		return tx.Model(&multa).Update("paga_em", at).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(id)
+}
+
+// totalMultasPendentes soma as multas não pagas do usuário, em centavos.
+func totalMultasPendentes(db *gorm.DB, userID uint) (int64, error) {
+	var total int64
+	err := db.Model(&models.Multa{}).
+		Select("COALESCE(SUM(valor), 0)").
+		Where("user_id = ? AND paga_em IS NULL", userID).
+		Scan(&total).Error
+	return total, err
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"books_api/models"
+	"gorm.io/gorm"
+)
+
+var ErrNotificacaoNotFound = errors.New("notificação não encontrada")
+
+// notificacaoLote é a quantidade de notificações gravadas por comando na distribuição para vários usuários.
+const notificacaoLote = 500
+
+type NotificacaoRepository struct {
+	DB *gorm.DB
+}
+
+// NotificacaoFilter define os critérios de busca e paginação da listagem de notificações.
+type NotificacaoFilter struct {
+	UserID   uint
+	NaoLidas bool
+	Page     int
+	Limit    int
+}
+
+func NewNotificacaoRepository(db *gorm.DB) *NotificacaoRepository {
+	return &NotificacaoRepository{DB: db}
+}
+
+// CreateMany grava as notificações em lotes.
+func (r *NotificacaoRepository) CreateMany(notificacoes []models.Notificacao) error {
+	if len(notificacoes) == 0 {
+		return nil
+	}
+	return r.DB.CreateInBatches(notificacoes, notificacaoLote).Error
+}
+
+// List retorna uma página das notificações do usuário, das mais recentes para as mais antigas,
+// e o total de registros encontrados.
+func (r *NotificacaoRepository) List(filter NotificacaoFilter) ([]models.Notificacao, int64, error) {
+	query := r.DB.Model(&models.Notificacao{}).Where("user_id = ?", filter.UserID)
+	if filter.NaoLidas {
+		query = query.Where("lida_em IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notificacoes []models.Notificacao
+	err := query.Preload("Livro").
+		Order("created_at DESC, id DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&notificacoes).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return notificacoes, total, nil
+}
+
+// ContarNaoLidas conta as notificações do usuário ainda não lidas.
+func (r *NotificacaoRepository) ContarNaoLidas(userID uint) (int64, error) {
+	var total int64
+	err := r.DB.Model(&models.Notificacao{}).Where("user_id = ? AND lida_em IS NULL", userID).Count(&total).Error
+	return total, err
+}
+
+// MarcarLida marca como lida uma notificação do usuário. Notificações já lidas mantêm a data original.
+func (r *NotificacaoRepository) MarcarLida(userID, id uint, at time.Time) (*models.Notificacao, error) {
+	var notificacao models.Notificacao
+	if err := r.DB.Where("id = ? AND user_id = ?", id, userID).First(&notificacao).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotificacaoNotFound
+		}
+		return nil, err
+	}
+	if notificacao.LidaEm == nil {
+		if err := r.DB.Model(&notificacao).Update("lida_em", at).Error; err != nil {
+			return nil, err
+		}
+		notificacao.LidaEm = &at
+	}
+	return &notificacao, nil
+}
+
+// MarcarTodasLidas marca como lidas todas as notificações do usuário e retorna quantas foram alteradas.
+func (r *NotificacaoRepository) MarcarTodasLidas(userID uint, at time.Time) (int64, error) {
+	result := r.DB.Model(&models.Notificacao{}).
+		Where("user_id = ? AND lida_em IS NULL", userID).
+		Update("lida_em", at)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,52 @@
+// Package repotest oferece um banco SQLite em memória com o esquema da API, para testes dos
+// repositórios e dos serviços que dependem deles.
+package repotest
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"books_api/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var seq atomic.Int64
+
+// NewDB cria um banco vazio e migrado, descartado ao fim do teste. Cada chamada tem o seu
+// próprio banco.
+func NewDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:repotest%d?mode=memory&cache=shared&_pragma=foreign_keys(1)", seq.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("erro ao abrir banco de teste: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("erro ao abrir banco de teste: %v", err)
+	}
+	// Uma única conexão serializa as transações, como os bloqueios de linha fariam no Postgres.
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	err = db.AutoMigrate(
+		&models.User{}, &models.Livro{}, &models.UserToken{}, &models.LoginAttempt{},
+		&models.RecoveryCode{}, &models.APIKey{}, &models.SigningKey{}, &models.ExternalIdentity{},
+		&models.Exemplar{}, &models.Inventario{}, &models.InventarioLeitura{},
+		&models.Emprestimo{}, &models.Reserva{}, &models.Multa{},
+		&models.Avaliacao{}, &models.SinalizacaoAvaliacao{},
+		&models.Estante{}, &models.EstanteItem{},
+		&models.Leitura{}, &models.SessaoLeitura{}, &models.MetaLeitura{},
+		&models.InteracaoLivro{},
+		&models.Favorito{}, &models.AutorSeguido{}, &models.Notificacao{},
+	)
+	if err != nil {
+		t.Fatalf("erro ao migrar banco de teste: %v", err)
+	}
+	return db
+}
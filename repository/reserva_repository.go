@@ -0,0 +1,340 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"books_api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrReservaNotFound  = errors.New("reserva não encontrada")
+	ErrReservaDuplicada = errors.New("usuário já possui uma reserva ativa deste livro")
+	ErrReservaEncerrada = errors.New("reserva já foi encerrada")
+	ErrLivroDisponivel  = errors.New("livro possui exemplares disponíveis para empréstimo")
+)
+
+type ReservaRepository struct {
+	DB *gorm.DB
+}
+
+// ReservaFilter define os critérios de busca e paginação da listagem de reservas.
+type ReservaFilter struct {
+	UserID  *uint
+	LivroID *uint
+	Status  string
+	// Ativas restringe a listagem às reservas aguardando ou disponíveis para retirada.
+	Ativas bool
+	Page   int
+	Limit  int
+}
+
+func NewReservaRepository(db *gorm.DB) *ReservaRepository {
+	return &ReservaRepository{DB: db}
+}
+
+// Create coloca o usuário no fim da fila do livro. Só é possível reservar livros sem exemplares
+// disponíveis ou que já tenham fila, e cada usuário tem no máximo uma reserva ativa por livro.
+func (r *ReservaRepository) Create(reserva *models.Reserva) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockLivro(tx, reserva.LivroID); err != nil {
+			return err
+		}
+
+		var ativas int64
+		if err := tx.Model(&models.Reserva{}).
+			Where("user_id = ? AND livro_id = ? AND encerrada_em IS NULL", reserva.UserID, reserva.LivroID).
+			Count(&ativas).Error; err != nil {
+			return err
+		}
+		if ativas > 0 {
+			return ErrReservaDuplicada
+		}
+
+		fila, err := tamanhoFila(tx, reserva.LivroID)
+		if err != nil {
+			return err
+		}
+		if fila == 0 {
+			var disponiveis int64
+			if err := tx.Model(&models.Exemplar{}).
+				Where("livro_id = ? AND status = ?", reserva.LivroID, models.ExemplarDisponivel).
+				Count(&disponiveis).Error; err != nil {
+				return err
+			}
+			if disponiveis > 0 {
+				return ErrLivroDisponivel
+			}
+		}
+
+		reserva.Status = models.ReservaAguardando
+		if err := tx.Create(reserva).Error; err != nil {
+			return err
+		}
+		reserva.Posicao = int(fila) + 1
+		return nil
+	})
+}
+
+// FindByID busca uma reserva pelo ID, incluindo o livro, o exemplar separado e a posição na fila.
+func (r *ReservaRepository) FindByID(id uint) (*models.Reserva, error) {
+	var reserva models.Reserva
+	if err := r.DB.Preload("Livro").Preload("Exemplar").First(&reserva, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReservaNotFound
+		}
+		return nil, err
+	}
+	if err := r.preencherPosicao(&reserva); err != nil {
+		return nil, err
+	}
+	return &reserva, nil
+}
+
+// List retorna uma página de reservas que atendem ao filtro, na ordem da fila, e o total de registros encontrados.
+func (r *ReservaRepository) List(filter ReservaFilter) ([]models.Reserva, int64, error) {
+	query := r.DB.Model(&models.Reserva{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.LivroID != nil {
+		query = query.Where("livro_id = ?", *filter.LivroID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Ativas {
+		query = query.Where("encerrada_em IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reservas []models.Reserva
+	err := query.Preload("Livro").Preload("Exemplar").
+		Order("id").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&reservas).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range reservas {
+		if err := r.preencherPosicao(&reservas[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return reservas, total, nil
+}
+
+// Atribuir separa os exemplares disponíveis do livro para as primeiras reservas da fila, com prazo de
+// retirada até at+prazo. Se codigoBarras for informado, o exemplar volta a ficar disponível antes da
+// distribuição. Retorna as reservas atendidas.
+func (r *ReservaRepository) Atribuir(livroID uint, codigoBarras string, at time.Time, prazo time.Duration) ([]models.Reserva, error) {
+	var atribuidas []models.Reserva
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockLivro(tx, livroID); err != nil {
+			return err
+		}
+
+		if codigoBarras != "" {
+			var exemplar models.Exemplar
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("livro_id = ? AND codigo_barras = ?", livroID, codigoBarras).
+				First(&exemplar).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrExemplarNotFound
+				}
+				return err
+			}
+			if err := exemplarOcupado(exemplar.Status); err != nil {
+				return err
+			}
+			if err := tx.Model(&exemplar).Update("status", models.ExemplarDisponivel).Error; err != nil {
+				return err
+			}
+		}
+
+		var err error
+		atribuidas, err = atribuirFila(tx, livroID, at, prazo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return atribuidas, nil
+}
+
+// Cancelar encerra uma reserva ativa. Se um exemplar estava separado, ele passa para o próximo da fila.
+func (r *ReservaRepository) Cancelar(id uint, at time.Time, prazo time.Duration) (*models.Reserva, error) {
+	atual, err := r.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockLivro(tx, atual.LivroID); err != nil {
+			return err
+		}
+		var reserva models.Reserva
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reserva, id).Error; err != nil {
+			return err
+		}
+		if reserva.EncerradaEm != nil {
+			return ErrReservaEncerrada
+		}
+		return encerrarReserva(tx, &reserva, models.ReservaCancelada, at, prazo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByID(id)
+}
+
+// Expirar encerra as reservas cujo prazo de retirada terminou antes de at, passando os exemplares
+// separados para os próximos da fila. Retorna as reservas expiradas.
+func (r *ReservaRepository) Expirar(at time.Time, prazo time.Duration) ([]models.Reserva, error) {
+	var livroIDs []uint
+	if err := r.DB.Model(&models.Reserva{}).
+		Where("status = ? AND prazo_retirada < ?", models.ReservaDisponivel, at).
+		Distinct().Pluck("livro_id", &livroIDs).Error; err != nil {
+		return nil, err
+	}
+
+	var expiradas []models.Reserva
+	for _, livroID := range livroIDs {
+		err := r.DB.Transaction(func(tx *gorm.DB) error {
+			if err := lockLivro(tx, livroID); err != nil {
+				return err
+			}
+			var vencidas []models.Reserva
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("livro_id = ? AND status = ? AND prazo_retirada < ?", livroID, models.ReservaDisponivel, at).
+				Order("id").
+				Find(&vencidas).Error; err != nil {
+				return err
+			}
+			for i := range vencidas {
+				if err := encerrarReserva(tx, &vencidas[i], models.ReservaExpirada, at, prazo); err != nil {
+					return err
+				}
+			}
+			expiradas = append(expiradas, vencidas...)
+			return nil
+		})
+		if err != nil && !errors.Is(err, ErrLivroNotFound) {
+			return expiradas, err
+		}
+	}
+	return expiradas, nil
+}
+
+// preencherPosicao calcula a posição na fila de uma reserva aguardando.
+func (r *ReservaRepository) preencherPosicao(reserva *models.Reserva) error {
+	if reserva.Status != models.ReservaAguardando {
+		reserva.Posicao = 0
+		return nil
+	}
+	var anteriores int64
+	if err := r.DB.Model(&models.Reserva{}).
+		Where("livro_id = ? AND status = ? AND id < ?", reserva.LivroID, models.ReservaAguardando, reserva.ID).
+		Count(&anteriores).Error; err != nil {
+		return err
+	}
+	reserva.Posicao = int(anteriores) + 1
+	return nil
+}
+
+// encerrarReserva encerra a reserva com a situação informada. O exemplar que estava separado para ela
+// volta a ficar disponível e é oferecido ao próximo da fila. Deve ser chamada com o livro bloqueado.
+func encerrarReserva(tx *gorm.DB, reserva *models.Reserva, status string, at time.Time, prazo time.Duration) error {
+	separado := reserva.Status == models.ReservaDisponivel && reserva.ExemplarID != nil
+	if err := tx.Model(reserva).Updates(map[string]interface{}{
+		"status":       status,
+		"encerrada_em": at,
+	}).Error; err != nil {
+		return err
+	}
+	if !separado {
+		return nil
+	}
+	if err := tx.Model(&models.Exemplar{}).
+		Where("id = ? AND status = ?", *reserva.ExemplarID, models.ExemplarReservado).
+		Update("status", models.ExemplarDisponivel).Error; err != nil {
+		return err
+	}
+	_, err := atribuirFila(tx, reserva.LivroID, at, prazo)
+	return err
+}
+
+// atribuirFila separa um exemplar disponível para cada reserva aguardando do livro, na ordem da fila,
+// enquanto houver exemplares. Deve ser chamada com o livro bloqueado.
+func atribuirFila(tx *gorm.DB, livroID uint, at time.Time, prazo time.Duration) ([]models.Reserva, error) {
+	var fila []models.Reserva
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("livro_id = ? AND status = ?", livroID, models.ReservaAguardando).
+		Order("id").
+		Find(&fila).Error; err != nil {
+		return nil, err
+	}
+	if len(fila) == 0 {
+		return nil, nil
+	}
+
+	var exemplares []models.Exemplar
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("livro_id = ? AND status = ?", livroID, models.ExemplarDisponivel).
+		Order("id").
+		Limit(len(fila)).
+		Find(&exemplares).Error; err != nil {
+		return nil, err
+	}
+
+	prazoRetirada := at.Add(prazo)
+	atribuidas := fila[:len(exemplares)]
+	for i := range atribuidas {
+		exemplar := &exemplares[i]
+		if err := tx.Model(exemplar).Update("status", models.ExemplarReservado).Error; err != nil {
+			return nil, err
+		}
+		exemplar.Status = models.ExemplarReservado
+
+		reserva := &atribuidas[i]
+		if err := tx.Model(reserva).Updates(map[string]interface{}{
+			"status":         models.ReservaDisponivel,
+			"exemplar_id":    exemplar.ID,
+			"prazo_retirada": prazoRetirada,
+		}).Error; err != nil {
+			return nil, err
+		}
+		reserva.Status = models.ReservaDisponivel
+		reserva.ExemplarID = &exemplar.ID
+		reserva.PrazoRetirada = &prazoRetirada
+		reserva.Exemplar = exemplar
+	}
+	return atribuidas, nil
+}
+
+// tamanhoFila conta as reservas aguardando um exemplar do livro.
+func tamanhoFila(tx *gorm.DB, livroID uint) (int64, error) {
+	var total int64
+	err := tx.Model(&models.Reserva{}).
+		Where("livro_id = ? AND status = ?", livroID, models.ReservaAguardando).
+		Count(&total).Error
+	return total, err
+}
+
+// lockLivro bloqueia o livro até o fim da transação, serializando empréstimos e reservas do mesmo livro.
+func lockLivro(tx *gorm.DB, livroID uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Livro{}, livroID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrLivroNotFound
+		}
+		return err
+	}
+	return nil
+}
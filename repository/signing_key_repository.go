@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"time"
+
+	"books_api/models"
+	"gorm.io/gorm"
+)
+
+type SigningKeyRepository struct {
+	DB *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{DB: db}
+}
+
+// List retorna as chaves de assinatura ainda não expiradas, da mais recente para a mais antiga.
+func (r *SigningKeyRepository) List() ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	err := r.DB.Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// Rotate grava a nova chave e aposenta as chaves ativas, que permanecem válidas para
+// verificação até expiresAt.
+func (r *SigningKeyRepository) Rotate(key *models.SigningKey, retiredAt, expiresAt time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.SigningKey{}).
+			Where("retired_at IS NULL").
+			Updates(map[string]interface{}{"retired_at": retiredAt, "expires_at": expiresAt}).Error; err != nil {
+			return err
+		}
+		return tx.Create(key).Error
+	})
+}
+
+// DeleteExpired remove as chaves cujo período de verificação terminou.
+func (r *SigningKeyRepository) DeleteExpired() error {
+	return r.DB.Where("expires_at IS NOT NULL AND expires_at <= ?", time.Now()).Delete(&models.SigningKey{}).Error
+}
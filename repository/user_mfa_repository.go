@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"time"
+
+	"books_api/models"
+	"gorm.io/gorm"
+)
+
+// SetTOTPSecret grava um segredo TOTP ainda não confirmado, mantendo o 2FA desativado.
+func (r *UserRepository) SetTOTPSecret(id uint, secret string) error {
+	return r.updateFields(id, map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_enabled":   false,
+		"totp_last_step": 0,
+	})
+}
+
+// EnableTOTP ativa o 2FA e substitui os códigos de recuperação do usuário.
+func (r *UserRepository) EnableTOTP(id uint, step int64, recoveryHashes []string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, id, recoveryHashes)
+	})
+}
+
+// DisableTOTP desativa o 2FA, removendo o segredo e os códigos de recuperação.
+func (r *UserRepository) DisableTOTP(id uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"totp_secret":    "",
+			"totp_enabled":   false,
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", id).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+// AdvanceTOTPStep registra a janela do último código aceito. Retorna false se um código da
+// mesma janela ou de uma posterior já tiver sido usado, impedindo a reutilização concorrente.
+func (r *UserRepository) AdvanceTOTPStep(id uint, step int64) (bool, error) {
+	result := r.DB.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReplaceRecoveryCodes substitui os códigos de recuperação do usuário.
+func (r *UserRepository) ReplaceRecoveryCodes(id uint, hashes []string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, id, hashes)
+	})
+}
+
+// ConsumeRecoveryCode marca como usado o código de recuperação com o hash informado.
+// Retorna false se o código não existir ou já tiver sido usado.
+func (r *UserRepository) ConsumeRecoveryCode(id uint, hash string) (bool, error) {
+	result := r.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", id, hash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountRecoveryCodes retorna quantos códigos de recuperação ainda não foram usados.
+func (r *UserRepository) CountRecoveryCodes(id uint) (int64, error) {
+	var total int64
+	err := r.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", id).Count(&total).Error
+	return total, err
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, id uint, hashes []string) error {
+	if err := tx.Where("user_id = ?", id).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]models.RecoveryCode, len(hashes))
+	for i, hash := range hashes {
+		codes[i] = models.RecoveryCode{UserID: id, CodeHash: hash}
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
@@ -6,14 +6,28 @@ import (
 
 	"books_api/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-var ErrUsernameInUse = errors.New("nome de usuário já está em uso")
+var (
+	ErrUsernameInUse = errors.New("nome de usuário já está em uso")
+	ErrUserNotFound  = errors.New("usuário não encontrado")
+	ErrEmailInUse    = errors.New("e-mail já está em uso")
+)
 
 type UserRepository struct {
 	DB *gorm.DB
 }
 
+// UserFilter define os critérios de busca e paginação da listagem de usuários.
+type UserFilter struct {
+	Query    string
+	Role     models.Role
+	Disabled *bool
+	Page     int
+	Limit    int
+}
+
 func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{DB: db}
 }
@@ -22,15 +36,48 @@ func (r *UserRepository) FindByUsername(username string) (*models.User, error) {
 	var user models.User
 	if err := r.DB.Where("LOWER(username) = ?", strings.ToLower(username)).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("usuário não encontrado")
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByID busca um usuário pelo seu ID.
+func (r *UserRepository) FindByID(id uint) (*models.User, error) {
+	var user models.User
+	if err := r.DB.First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByEmail busca um usuário pelo e-mail, sem diferenciar maiúsculas e minúsculas.
+func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
+	var user models.User
+	if err := r.DB.Where("LOWER(email) = ?", strings.ToLower(email)).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
+// Create cadastra o usuário, cuja senha já deve ter passado pela política e pelo hash.
 func (r *UserRepository) Create(user *models.User) error {
-	if err := user.Validate(); err != nil {
+	if err := r.checkNew(user); err != nil {
+		return err
+	}
+	return r.DB.Create(user).Error
+}
+
+// checkNew valida a conta e verifica se o nome de usuário e o e-mail estão livres.
+func (r *UserRepository) checkNew(user *models.User) error {
+	if err := user.ValidateAccount(); err != nil {
 		return err
 	}
 
@@ -38,5 +85,177 @@ func (r *UserRepository) Create(user *models.User) error {
 		return ErrUsernameInUse
 	}
 
-	return r.DB.Create(user).Error
+	if user.Email != "" {
+		if existingUser, _ := r.FindByEmail(user.Email); existingUser != nil {
+			return ErrEmailInUse
+		}
+	}
+	return nil
+}
+
+// CreateFirstAdmin cria o usuário como Create e, se ele for o primeiro cadastrado, como administrador.
+// A tabela fica bloqueada da contagem até a inclusão, para que dois cadastros simultâneos numa base
+// vazia não se tornem ambos administradores.
+func (r *UserRepository) CreateFirstAdmin(user *models.User) error {
+	if err := r.checkNew(user); err != nil {
+		return err
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// O SQLite dos testes já serializa as transações de escrita.
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+				return err
+			}
+		}
+		var total int64
+		if err := tx.Model(&models.User{}).Count(&total).Error; err != nil {
+			return err
+		}
+		if total == 0 {
+			user.Role = models.RoleAdmin
+		}
+		return tx.Create(user).Error
+	})
+}
+
+// CountByRole retorna a quantidade de usuários ativos com o papel informado.
+func (r *UserRepository) CountByRole(role models.Role) (int64, error) {
+	var total int64
+	if err := r.DB.Model(&models.User{}).Where("role = ? AND disabled = ?", role, false).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// List retorna uma página de usuários que atendem ao filtro e o total de registros encontrados.
+func (r *UserRepository) List(filter UserFilter) ([]models.User, int64, error) {
+	query := r.DB.Model(&models.User{})
+	if filter.Query != "" {
+		like := "%" + strings.ToLower(filter.Query) + "%"
+		query = query.Where("LOWER(username) LIKE ? OR LOWER(email) LIKE ? OR LOWER(display_name) LIKE ?", like, like, like)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Disabled != nil {
+		query = query.Where("disabled = ?", *filter.Disabled)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Order("id").Offset(offset).Limit(filter.Limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// UpdateRole altera o papel de um usuário.
+func (r *UserRepository) UpdateRole(id uint, role models.Role) error {
+	return r.updateFields(id, map[string]interface{}{"role": role})
+}
+
+// SetDisabled ativa ou desativa um usuário.
+func (r *UserRepository) SetDisabled(id uint, disabled bool) error {
+	return r.updateFields(id, map[string]interface{}{"disabled": disabled})
+}
+
+// UpdatePassword grava um novo hash de senha, indica se o usuário deverá trocá-la no próximo acesso
+// e incrementa a versão de sessão, invalidando os tokens emitidos anteriormente.
+func (r *UserRepository) UpdatePassword(id uint, hashedPassword string, mustChange bool) error {
+	return r.updateFields(id, map[string]interface{}{
+		"password":             hashedPassword,
+		"must_change_password": mustChange,
+		"session_version":      gorm.Expr("session_version + 1"),
+	})
+}
+
+// RequirePasswordChange obriga o usuário a trocar a senha no próximo acesso e revoga as sessões abertas.
+func (r *UserRepository) RequirePasswordChange(id uint) error {
+	return r.updateFields(id, map[string]interface{}{
+		"must_change_password": true,
+		"session_version":      gorm.Expr("session_version + 1"),
+	})
+}
+
+// ReplacePasswordHash troca o hash da senha por outro da mesma senha, gerado com parâmetros atuais.
+// A sessão não é revogada, e nada é alterado se a senha tiver sido trocada nesse meio tempo.
+func (r *UserRepository) ReplacePasswordHash(id uint, oldHash, newHash string) error {
+	return r.DB.Model(&models.User{}).
+		Where("id = ? AND password = ?", id, oldHash).
+		Update("password", newHash).Error
+}
+
+// UpdateProfile atualiza o nome de exibição e o e-mail de um usuário.
+// Quando o e-mail muda, ele volta a ficar pendente de verificação.
+func (r *UserRepository) UpdateProfile(id uint, displayName, email string) error {
+	if email != "" {
+		if existingUser, _ := r.FindByEmail(email); existingUser != nil && existingUser.ID != id {
+			return ErrEmailInUse
+		}
+	}
+	return r.updateFields(id, map[string]interface{}{
+		"display_name":   displayName,
+		"email":          email,
+		"email_verified": gorm.Expr("email_verified AND LOWER(email) = LOWER(?)", email),
+	})
+}
+
+// MarkEmailVerified confirma o e-mail do usuário, desde que ele não tenha sido alterado desde o envio.
+func (r *UserRepository) MarkEmailVerified(id uint, email string) error {
+	result := r.DB.Model(&models.User{}).
+		Where("id = ? AND LOWER(email) = LOWER(?)", id, email).
+		Update("email_verified", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenInvalid
+	}
+	return nil
+}
+
+// Delete remove um usuário sem empréstimos em aberto nem multas pendentes; com eles, retorna
+// ErrEmprestimosAtivos ou ErrMultasNaoPagas.
+func (r *UserRepository) Delete(id uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// O bloqueio do usuário impede que um empréstimo seja registrado durante a exclusão.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.User{}, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		ativos, err := contarEmprestimosAtivos(tx, "user_id = ?", id)
+		if err != nil {
+			return err
+		}
+		if ativos > 0 {
+			return ErrEmprestimosAtivos
+		}
+		pendentes, err := totalMultasPendentes(tx, id)
+		if err != nil {
+			return err
+		}
+		if pendentes > 0 {
+			return ErrMultasNaoPagas
+		}
+		return tx.Delete(&models.User{}, id).Error
+	})
+}
+
+// updateFields atualiza as colunas informadas, retornando ErrUserNotFound se o usuário não existir.
+func (r *UserRepository) updateFields(id uint, fields map[string]interface{}) error {
+	result := r.DB.Model(&models.User{}).Where("id = ?", id).Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
 }
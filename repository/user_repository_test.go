@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"books_api/models"
+	"books_api/repository/repotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepositoryCreateFirstAdmin(t *testing.T) {
+	repo := NewUserRepository(repotest.NewDB(t))
+
+	// Cadastros simultâneos numa base vazia: só um deles se torna administrador.
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, repo.CreateFirstAdmin(&models.User{Username: fmt.Sprintf("leitor%d", i), Password: "hash"}))
+		}(i)
+	}
+	wg.Wait()
+
+	admins, err := repo.CountByRole(models.RoleAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), admins)
+	readers, err := repo.CountByRole(models.RoleReader)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), readers)
+
+	err = repo.CreateFirstAdmin(&models.User{Username: "leitor0", Password: "hash"})
+	assert.ErrorIs(t, err, ErrUsernameInUse)
+}
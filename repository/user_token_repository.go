@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"books_api/models"
+	"gorm.io/gorm"
+)
+
+var ErrTokenInvalid = errors.New("token inválido ou expirado")
+
+type UserTokenRepository struct {
+	DB *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) *UserTokenRepository {
+	return &UserTokenRepository{DB: db}
+}
+
+// Replace invalida os tokens pendentes do usuário com a mesma finalidade e grava o novo token.
+func (r *UserTokenRepository) Replace(token *models.UserToken) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Delete(&models.UserToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// Find retorna o token válido com o hash e a finalidade informados, sem consumi-lo.
+func (r *UserTokenRepository) Find(tokenHash, purpose string) (*models.UserToken, error) {
+	var token models.UserToken
+	if err := r.DB.Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenHash, purpose, time.Now()).
+		First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTokenInvalid
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Consume marca como usado o token válido com o hash e a finalidade informados e o retorna.
+// Um token só pode ser consumido uma vez, mesmo com requisições concorrentes.
+func (r *UserTokenRepository) Consume(tokenHash, purpose string) (*models.UserToken, error) {
+	var token models.UserToken
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenHash, purpose, now).
+			First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTokenInvalid
+			}
+			return err
+		}
+
+		result := tx.Model(&models.UserToken{}).Where("id = ? AND used_at IS NULL", token.ID).Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTokenInvalid
+		}
+		token.UsedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// DeleteExpired remove os tokens expirados ou já utilizados.
+func (r *UserTokenRepository) DeleteExpired() error {
+	return r.DB.Where("expires_at < ? OR used_at IS NOT NULL", time.Now()).Delete(&models.UserToken{}).Error
+}
@@ -0,0 +1,210 @@
+package routes
+
+import (
+	"books_api/middleware"
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRoutes configura as rotas de administração de usuários.
+func AdminRoutes(router *gin.Engine, authService *service.AuthService, userService *service.UserService) {
+	admin := router.Group("/admin/usuarios")
+	admin.Use(middleware.AuthMiddleware(authService), middleware.RequirePermission(models.PermUsuariosManage))
+	{
+		admin.GET("", listarUsuariosHandler(userService))
+		admin.GET("/:id", buscarUsuarioHandler(userService))
+		admin.POST("/:id/ativar", definirAtivoHandler(userService, true))
+		admin.POST("/:id/desativar", definirAtivoHandler(userService, false))
+		admin.PUT("/:id/papel", alterarPapelHandler(userService))
+		admin.POST("/:id/redefinir-senha", forcarRedefinicaoSenhaHandler(authService))
+		admin.POST("/:id/desbloquear", desbloquearLoginHandler(authService))
+		admin.POST("/:id/2fa/redefinir", redefinirMFAHandler(authService))
+		admin.DELETE("/:id", deletarUsuarioHandler(userService))
+	}
+}
+
+func listarUsuariosHandler(userService *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit := getPagination(c)
+		filter := repository.UserFilter{
+			Query: c.Query("q"),
+			Role:  models.Role(c.Query("role")),
+			Page:  page,
+			Limit: limit,
+		}
+		if disabled := c.Query("disabled"); disabled != "" {
+			value, err := strconv.ParseBool(disabled)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Filtro disabled inválido"})
+				return
+			}
+			filter.Disabled = &value
+		}
+
+		users, total, err := userService.ListarUsuarios(filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao listar usuários"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": users, "total": total, "page": page, "limit": limit})
+	}
+}
+
+func buscarUsuarioHandler(userService *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		user, err := userService.BuscarUsuario(id)
+		if err != nil {
+			respondUserError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, user)
+	}
+}
+
+func definirAtivoHandler(userService *service.UserService, ativo bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := userService.DefinirAtivo(currentUserID(c), id, ativo); err != nil {
+			respondUserError(c, err)
+			return
+		}
+
+		message := "Usuário desativado com sucesso"
+		if ativo {
+			message = "Usuário ativado com sucesso"
+		}
+		c.JSON(http.StatusOK, gin.H{"message": message})
+	}
+}
+
+func alterarPapelHandler(userService *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		var req struct {
+			Role models.Role `json:"role" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		if err := userService.AlterarPapel(currentUserID(c), id, req.Role); err != nil {
+			respondUserError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Papel atualizado com sucesso"})
+	}
+}
+
+// forcarRedefinicaoSenhaHandler obriga o usuário a trocar a senha. O link de redefinição é enviado
+// ao e-mail do usuário, e nenhuma senha passa pelo administrador.
+func forcarRedefinicaoSenhaHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := authService.ForcarRedefinicaoSenha(id); err != nil {
+			if errors.Is(err, service.ErrEmailNotSet) {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "O usuário não tem e-mail cadastrado para receber o link de redefinição"})
+				return
+			}
+			respondUserError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "O usuário deverá alterar a senha no próximo acesso. O link de redefinição foi enviado ao seu e-mail."})
+	}
+}
+
+func desbloquearLoginHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := authService.DesbloquearLogin(id); err != nil {
+			respondUserError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Login desbloqueado com sucesso"})
+	}
+}
+
+func redefinirMFAHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := authService.RedefinirMFA(id); err != nil {
+			respondUserError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "2FA desativado. O usuário deverá ativá-lo novamente."})
+	}
+}
+
+func deletarUsuarioHandler(userService *service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := userService.DeletarUsuario(currentUserID(c), id); err != nil {
+			respondUserError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Usuário deletado com sucesso"})
+	}
+}
+
+// respondUserError converte os erros do serviço de usuários em respostas HTTP.
+func respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Usuário não encontrado"})
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrCannotModifySelf):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case errors.Is(err, repository.ErrEmprestimosAtivos), errors.Is(err, repository.ErrMultasNaoPagas):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao processar usuário"})
+	}
+}
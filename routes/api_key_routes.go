@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func listarAPIKeysHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys, err := authService.ListarAPIKeys(currentUserID(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao listar chaves de API"})
+			return
+		}
+
+		c.JSON(http.StatusOK, keys)
+	}
+}
+
+func criarAPIKeyHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Name      string              `json:"name" binding:"required"`
+			Scopes    []models.Permission `json:"scopes" binding:"required"`
+			ExpiresAt *time.Time          `json:"expires_at"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		plain, key, err := authService.CriarAPIKey(currentUserID(c), req.Name, req.Scopes, req.ExpiresAt)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidScope), errors.Is(err, service.ErrAPIKeyName),
+				errors.Is(err, service.ErrAPIKeyExpiresAt):
+				c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao criar chave de API"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "Chave criada. Guarde-a agora: ela não será exibida novamente.",
+			"key":     plain,
+			"api_key": key,
+		})
+	}
+}
+
+func revogarAPIKeyHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := authService.RevogarAPIKey(currentUserID(c), id); err != nil {
+			if errors.Is(err, repository.ErrAPIKeyNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao revogar chave de API"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Chave de API revogada com sucesso"})
+	}
+}
@@ -0,0 +1,246 @@
+package routes
+
+import (
+	"books_api/middleware"
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AvaliacaoRoutes configura as rotas de avaliações de livros e de moderação.
+func AvaliacaoRoutes(router *gin.Engine, authService *service.AuthService, avaliacaoService *service.AvaliacaoService) {
+	auth := middleware.AuthMiddleware(authService)
+	leitura := middleware.RequirePermission(models.PermLivrosRead)
+	escrita := middleware.RequirePermission(models.PermAvaliacoesWrite)
+	moderar := middleware.RequirePermission(models.PermAvaliacoesModerate)
+
+	router.GET("/livros/:id/avaliacoes", auth, leitura, listarAvaliacoesHandler(avaliacaoService))
+	router.POST("/livros/:id/avaliacoes", auth, escrita, avaliarLivroHandler(avaliacaoService))
+
+	avaliacoes := router.Group("/avaliacoes")
+	avaliacoes.Use(auth)
+	{
+		avaliacoes.GET("/sinalizadas", moderar, listarSinalizadasHandler(avaliacaoService))
+		avaliacoes.PUT("/:id", escrita, editarAvaliacaoHandler(avaliacaoService))
+		avaliacoes.DELETE("/:id", escrita, removerAvaliacaoHandler(avaliacaoService))
+		avaliacoes.POST("/:id/sinalizar", escrita, sinalizarAvaliacaoHandler(avaliacaoService))
+		avaliacoes.POST("/:id/ocultar", moderar, ocultarAvaliacaoHandler(avaliacaoService))
+		avaliacoes.POST("/:id/reexibir", moderar, reexibirAvaliacaoHandler(avaliacaoService))
+	}
+}
+
+type avaliacaoRequest struct {
+	Nota  int    `json:"nota" binding:"required"`
+	Texto string `json:"texto"`
+}
+
+// listarAvaliacoesHandler lista as avaliações visíveis do livro. Moderadores podem incluir as
+// ocultas com incluir_ocultas=true.
+func listarAvaliacoesHandler(avaliacaoService *service.AvaliacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		livroID, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		page, limit := getPagination(c)
+		filter := repository.AvaliacaoFilter{
+			LivroID: &livroID,
+			Ordenar: c.Query("ordenar"),
+			Page:    page,
+			Limit:   limit,
+		}
+		if c.Query("incluir_ocultas") == "true" && middleware.HasPermission(c, models.PermAvaliacoesModerate) {
+			filter.IncluirOcultas = true
+		}
+
+		avaliacoes, total, err := avaliacaoService.ListarAvaliacoes(filter)
+		if err != nil {
+			respondAvaliacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": avaliacoes, "total": total, "page": page, "limit": limit})
+	}
+}
+
+func avaliarLivroHandler(avaliacaoService *service.AvaliacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		livroID, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		var req avaliacaoRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		avaliacao, err := avaliacaoService.Avaliar(livroID, currentUserID(c), req.Nota, req.Texto)
+		if err != nil {
+			respondAvaliacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, avaliacao)
+	}
+}
+
+func editarAvaliacaoHandler(avaliacaoService *service.AvaliacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		var req avaliacaoRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		avaliacao, err := avaliacaoService.Editar(id, currentUserID(c), req.Nota, req.Texto)
+		if err != nil {
+			respondAvaliacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, avaliacao)
+	}
+}
+
+func removerAvaliacaoHandler(avaliacaoService *service.AvaliacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		moderar := middleware.HasPermission(c, models.PermAvaliacoesModerate)
+		if err := avaliacaoService.Remover(id, currentUserID(c), moderar); err != nil {
+			respondAvaliacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Avaliação removida com sucesso"})
+	}
+}
+
+func sinalizarAvaliacaoHandler(avaliacaoService *service.AvaliacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		var req struct {
+			Motivo string `json:"motivo"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+				return
+			}
+		}
+
+		if err := avaliacaoService.Sinalizar(id, currentUserID(c), req.Motivo); err != nil {
+			respondAvaliacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Avaliação enviada para moderação"})
+	}
+}
+
+// listarSinalizadasHandler lista as avaliações denunciadas, das mais denunciadas para as menos.
+func listarSinalizadasHandler(avaliacaoService *service.AvaliacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit := getPagination(c)
+		avaliacoes, total, err := avaliacaoService.ListarAvaliacoes(repository.AvaliacaoFilter{
+			IncluirOcultas: true,
+			Sinalizadas:    true,
+			Ordenar:        "denuncias",
+			Page:           page,
+			Limit:          limit,
+		})
+		if err != nil {
+			respondAvaliacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": avaliacoes, "total": total, "page": page, "limit": limit})
+	}
+}
+
+func ocultarAvaliacaoHandler(avaliacaoService *service.AvaliacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		var req struct {
+			Motivo string `json:"motivo"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+				return
+			}
+		}
+
+		avaliacao, err := avaliacaoService.Ocultar(id, req.Motivo)
+		if err != nil {
+			respondAvaliacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, avaliacao)
+	}
+}
+
+func reexibirAvaliacaoHandler(avaliacaoService *service.AvaliacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		avaliacao, err := avaliacaoService.Reexibir(id)
+		if err != nil {
+			respondAvaliacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, avaliacao)
+	}
+}
+
+// respondAvaliacaoError converte os erros de avaliação em respostas HTTP.
+func respondAvaliacaoError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrAvaliacaoNotFound), errors.Is(err, repository.ErrLivroNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, repository.ErrAvaliacaoDuplicada):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrNotAvaliacaoAutor), errors.Is(err, service.ErrSinalizarPropria):
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	case errors.Is(err, models.ErrNotaInvalida), errors.Is(err, models.ErrTextoAvaliacaoLongo),
+		errors.Is(err, service.ErrInvalidOrdem):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao processar avaliação"})
+	}
+}
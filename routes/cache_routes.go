@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"books_api/middleware"
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheRoutes configura a rota de métricas do cache de livros, para os administradores.
+func CacheRoutes(router *gin.Engine, authService *service.AuthService, livrosCache *repository.CachedLivroRepository) {
+	router.GET("/admin/cache", middleware.AuthMiddleware(authService), middleware.RequirePermission(models.PermUsuariosManage),
+		metricasCacheHandler(livrosCache))
+}
+
+// metricasCacheHandler retorna os contadores de acesso ao cache de livros.
+func metricasCacheHandler(livrosCache *repository.CachedLivroRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if livrosCache == nil {
+			c.JSON(http.StatusOK, gin.H{"livros": repository.MetricasCache{}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"livros": livrosCache.Metricas()})
+	}
+}
@@ -0,0 +1,160 @@
+package routes
+
+import (
+	"books_api/middleware"
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmprestimoRoutes configura as rotas de empréstimo e devolução de livros.
+func EmprestimoRoutes(router *gin.Engine, authService *service.AuthService, emprestimoService *service.EmprestimoService) {
+	auth := middleware.AuthMiddleware(authService)
+	emprestimo := middleware.RequirePermission(models.PermEmprestimosWrite)
+
+	router.POST("/livros/:id/emprestar", auth, emprestimo, emprestarLivroHandler(emprestimoService))
+
+	emprestimos := router.Group("/emprestimos")
+	emprestimos.Use(auth, emprestimo)
+	{
+		emprestimos.GET("", listarEmprestimosHandler(emprestimoService))
+		emprestimos.POST("/:id/devolver", devolverLivroHandler(emprestimoService))
+	}
+}
+
+// emprestarLivroHandler registra a retirada de um exemplar do livro pelo próprio usuário. Quem gerencia
+// empréstimos pode informar usuario_id para registrar a retirada em nome de outro usuário e
+// codigo_barras para escolher o exemplar lido no balcão.
+func emprestarLivroHandler(emprestimoService *service.EmprestimoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		livroID, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		var req struct {
+			UsuarioID    uint   `json:"usuario_id"`
+			CodigoBarras string `json:"codigo_barras"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+				return
+			}
+		}
+
+		// A escolha do exemplar é feita no balcão; leitores recebem qualquer exemplar disponível.
+		if req.CodigoBarras != "" && !canManageEmprestimos(c) {
+			c.JSON(http.StatusForbidden, gin.H{"message": "Acesso negado"})
+			return
+		}
+
+		userID := currentUserID(c)
+		if req.UsuarioID != 0 && req.UsuarioID != userID {
+			if !canManageEmprestimos(c) {
+				c.JSON(http.StatusForbidden, gin.H{"message": "Acesso negado"})
+				return
+			}
+			userID = req.UsuarioID
+		}
+
+		result, err := emprestimoService.Emprestar(livroID, userID, req.CodigoBarras)
+		if err != nil {
+			respondEmprestimoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, result)
+	}
+}
+
+func devolverLivroHandler(emprestimoService *service.EmprestimoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		result, err := emprestimoService.Devolver(id, currentUserID(c), canManageEmprestimos(c))
+		if err != nil {
+			respondEmprestimoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// listarEmprestimosHandler lista os empréstimos do usuário. Quem gerencia empréstimos vê os de
+// todos os usuários e pode filtrar por usuario_id.
+func listarEmprestimosHandler(emprestimoService *service.EmprestimoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit := getPagination(c)
+		filter := repository.EmprestimoFilter{Status: c.Query("status"), Page: page, Limit: limit}
+
+		if canManageEmprestimos(c) {
+			if value := c.Query("usuario_id"); value != "" {
+				id, err := strconv.ParseUint(value, 10, 32)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"message": "Filtro usuario_id inválido"})
+					return
+				}
+				userID := uint(id)
+				filter.UserID = &userID
+			}
+		} else {
+			userID := currentUserID(c)
+			filter.UserID = &userID
+		}
+
+		if value := c.Query("livro_id"); value != "" {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Filtro livro_id inválido"})
+				return
+			}
+			livroID := uint(id)
+			filter.LivroID = &livroID
+		}
+
+		emprestimos, total, err := emprestimoService.ListarEmprestimos(filter)
+		if err != nil {
+			respondEmprestimoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": emprestimos, "total": total, "page": page, "limit": limit})
+	}
+}
+
+// canManageEmprestimos informa se o usuário autenticado pode gerenciar empréstimos de outros usuários,
+// considerando também os escopos da chave de API.
+func canManageEmprestimos(c *gin.Context) bool {
+	return middleware.HasPermission(c, models.PermEmprestimosManage)
+}
+
+// respondEmprestimoError converte os erros de empréstimo em respostas HTTP.
+func respondEmprestimoError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrLivroNotFound), errors.Is(err, repository.ErrEmprestimoNotFound),
+		errors.Is(err, repository.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, repository.ErrLivroIndisponivel), errors.Is(err, repository.ErrLimiteEmprestimos),
+		errors.Is(err, repository.ErrEmprestimoDevolvido), errors.Is(err, repository.ErrLivroReservado),
+		errors.Is(err, repository.ErrExemplarNaoSeparado), errors.Is(err, repository.ErrMultasPendentes):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrNotBorrower):
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrInvalidStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao processar empréstimo"})
+	}
+}
@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"books_api/models"
+	"books_api/repository"
+	"books_api/repository/repotest"
+	"books_api/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmprestarLivroCodigoBarras(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := repotest.NewDB(t)
+	srv := &service.EmprestimoService{Repo: repository.NewEmprestimoRepository(db), Prazo: time.Hour}
+
+	livro := &models.Livro{Titulo: "Vidas Secas"}
+	require.NoError(t, db.Create(livro).Error)
+	require.NoError(t, db.Create(&models.Exemplar{LivroID: livro.ID, CodigoBarras: "001"}).Error)
+	for _, username := range []string{"leitor", "balcao"} {
+		require.NoError(t, db.Create(&models.User{Username: username, Password: "hash"}).Error)
+	}
+
+	tests := []struct {
+		name   string
+		userID uint
+		role   models.Role
+		code   int
+	}{
+		{"leitor", 1, models.RoleReader, http.StatusForbidden},
+		{"gerente", 2, models.RoleEditor, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/livros/:id/emprestar", func(c *gin.Context) {
+				c.Set("userID", tt.userID)
+				c.Set("role", tt.role)
+			}, emprestarLivroHandler(srv))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/livros/1/emprestar", strings.NewReader(`{"codigo_barras":"001"}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.code, w.Code, w.Body.String())
+		})
+	}
+}
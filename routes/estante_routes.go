@@ -0,0 +1,302 @@
+package routes
+
+import (
+	"books_api/middleware"
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EstanteRoutes configura as rotas das estantes e listas de leitura dos usuários.
+func EstanteRoutes(router *gin.Engine, authService *service.AuthService, estanteService *service.EstanteService) {
+	auth := middleware.AuthMiddleware(authService)
+	leitura := middleware.RequirePermission(models.PermLivrosRead)
+
+	minhas := router.Group("/usuarios/me/estantes")
+	minhas.Use(auth, middleware.RequirePermission(models.PermLeituraWrite))
+	{
+		minhas.GET("", minhasEstantesHandler(estanteService))
+		minhas.POST("", criarEstanteHandler(estanteService))
+		minhas.GET("/:id", buscarEstanteHandler(estanteService))
+		minhas.PUT("/:id", atualizarEstanteHandler(estanteService))
+		minhas.DELETE("/:id", removerEstanteHandler(estanteService))
+		minhas.POST("/:id/livros", adicionarLivroEstanteHandler(estanteService))
+		minhas.PUT("/:id/livros/:livro_id", atualizarLivroEstanteHandler(estanteService))
+		minhas.DELETE("/:id/livros/:livro_id", removerLivroEstanteHandler(estanteService))
+		minhas.POST("/:id/compartilhar", compartilharEstanteHandler(estanteService))
+		minhas.DELETE("/:id/compartilhar", pararCompartilhamentoHandler(estanteService))
+	}
+
+	router.GET("/usuarios/:id/estantes", auth, leitura, estantesPublicasHandler(estanteService))
+	router.GET("/estantes/:id", auth, leitura, buscarEstanteHandler(estanteService))
+	// O link de compartilhamento funciona sem autenticação.
+	router.GET("/estantes/compartilhadas/:token", estanteCompartilhadaHandler(estanteService))
+}
+
+type estanteRequest struct {
+	Nome      string `json:"nome" binding:"required"`
+	Descricao string `json:"descricao"`
+	Publica   bool   `json:"publica"`
+}
+
+func minhasEstantesHandler(estanteService *service.EstanteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		estantes, err := estanteService.MinhasEstantes(currentUserID(c))
+		if err != nil {
+			respondEstanteError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, estantes)
+	}
+}
+
+func criarEstanteHandler(estanteService *service.EstanteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req estanteRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		estante, err := estanteService.CriarEstante(currentUserID(c), req.Nome, req.Descricao, req.Publica)
+		if err != nil {
+			respondEstanteError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, estante)
+	}
+}
+
+// buscarEstanteHandler retorna a estante com os livros. Estantes de outros usuários só são
+// retornadas se forem públicas.
+func buscarEstanteHandler(estanteService *service.EstanteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		estante, err := estanteService.BuscarEstante(id, currentUserID(c))
+		if err != nil {
+			respondEstanteError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, estante)
+	}
+}
+
+func atualizarEstanteHandler(estanteService *service.EstanteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		var req estanteRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		estante, err := estanteService.AtualizarEstante(id, currentUserID(c), req.Nome, req.Descricao, req.Publica)
+		if err != nil {
+			respondEstanteError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, estante)
+	}
+}
+
+func removerEstanteHandler(estanteService *service.EstanteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := estanteService.RemoverEstante(id, currentUserID(c)); err != nil {
+			respondEstanteError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Estante removida com sucesso"})
+	}
+}
+
+// adicionarLivroEstanteHandler coloca um livro na estante. posicao é opcional; sem ela, o livro vai
+// para o fim da lista.
+func adicionarLivroEstanteHandler(estanteService *service.EstanteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		var req struct {
+			LivroID uint   `json:"livro_id" binding:"required"`
+			Nota    string `json:"nota"`
+			Posicao int    `json:"posicao"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		item, err := estanteService.AdicionarLivro(id, currentUserID(c), req.LivroID, req.Nota, req.Posicao)
+		if err != nil {
+			respondEstanteError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, item)
+	}
+}
+
+// atualizarLivroEstanteHandler altera a nota e/ou move o livro para outra posição da estante.
+func atualizarLivroEstanteHandler(estanteService *service.EstanteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+		livroID, err := getUintParam(c, "livro_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID do livro inválido"})
+			return
+		}
+
+		var req struct {
+			Nota    *string `json:"nota"`
+			Posicao int     `json:"posicao"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		item, err := estanteService.AtualizarLivro(id, currentUserID(c), livroID, req.Nota, req.Posicao)
+		if err != nil {
+			respondEstanteError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, item)
+	}
+}
+
+func removerLivroEstanteHandler(estanteService *service.EstanteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+		livroID, err := getUintParam(c, "livro_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID do livro inválido"})
+			return
+		}
+
+		if err := estanteService.RemoverLivro(id, currentUserID(c), livroID); err != nil {
+			respondEstanteError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Livro removido da estante"})
+	}
+}
+
+func compartilharEstanteHandler(estanteService *service.EstanteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		estante, err := estanteService.Compartilhar(id, currentUserID(c))
+		if err != nil {
+			respondEstanteError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"link": estante.LinkCompartilhamento})
+	}
+}
+
+func pararCompartilhamentoHandler(estanteService *service.EstanteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := estanteService.PararCompartilhamento(id, currentUserID(c)); err != nil {
+			respondEstanteError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Compartilhamento desativado"})
+	}
+}
+
+// estantesPublicasHandler lista as estantes públicas de um usuário.
+func estantesPublicasHandler(estanteService *service.EstanteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		estantes, err := estanteService.EstantesPublicas(userID)
+		if err != nil {
+			respondEstanteError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, estantes)
+	}
+}
+
+func estanteCompartilhadaHandler(estanteService *service.EstanteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		estante, err := estanteService.BuscarCompartilhada(c.Param("token"))
+		if err != nil {
+			respondEstanteError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, estante)
+	}
+}
+
+// respondEstanteError converte os erros de estantes em respostas HTTP.
+func respondEstanteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrEstanteNotFound), errors.Is(err, repository.ErrItemEstanteNotFound),
+		errors.Is(err, repository.ErrLivroNotFound), errors.Is(err, service.ErrTokenEstanteVazio):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, repository.ErrNomeEstanteEmUso), errors.Is(err, repository.ErrLivroNaEstante):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrEstantePadrao):
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	case errors.Is(err, models.ErrNomeEstanteInvalido), errors.Is(err, models.ErrNotaItemLonga):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao processar estantes"})
+	}
+}
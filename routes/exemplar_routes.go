@@ -0,0 +1,272 @@
+package routes
+
+import (
+	"books_api/middleware"
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExemplarRoutes configura as rotas de exemplares físicos e de inventário.
+func ExemplarRoutes(router *gin.Engine, authService *service.AuthService, exemplarService *service.ExemplarService) {
+	auth := middleware.AuthMiddleware(authService)
+	leitura := middleware.RequirePermission(models.PermLivrosRead)
+	escrita := middleware.RequirePermission(models.PermLivrosWrite)
+
+	router.GET("/livros/:id/exemplares", auth, leitura, listarExemplaresLivroHandler(exemplarService))
+	router.POST("/livros/:id/exemplares", auth, escrita, cadastrarExemplarHandler(exemplarService))
+
+	exemplares := router.Group("/exemplares")
+	exemplares.Use(auth)
+	{
+		exemplares.GET("", leitura, listarExemplaresHandler(exemplarService))
+		exemplares.GET("/resumo", leitura, resumoInventarioHandler(exemplarService))
+		exemplares.PUT("/:id", escrita, atualizarExemplarHandler(exemplarService))
+		exemplares.DELETE("/:id", escrita, removerExemplarHandler(exemplarService))
+	}
+
+	inventarios := router.Group("/inventarios")
+	inventarios.Use(auth, escrita)
+	{
+		inventarios.POST("", iniciarInventarioHandler(exemplarService))
+		inventarios.GET("/:id", buscarInventarioHandler(exemplarService))
+		inventarios.POST("/:id/leituras", registrarLeituraHandler(exemplarService))
+		inventarios.POST("/:id/concluir", concluirInventarioHandler(exemplarService))
+	}
+}
+
+func listarExemplaresLivroHandler(exemplarService *service.ExemplarService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		livroID, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		page, limit := getPagination(c)
+		exemplares, total, err := exemplarService.ListarExemplares(repository.ExemplarFilter{
+			LivroID: &livroID,
+			Status:  c.Query("status"),
+			Page:    page,
+			Limit:   limit,
+		})
+		if err != nil {
+			respondExemplarError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": exemplares, "total": total, "page": page, "limit": limit})
+	}
+}
+
+func cadastrarExemplarHandler(exemplarService *service.ExemplarService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		livroID, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		var req struct {
+			CodigoBarras string `json:"codigo_barras" binding:"required"`
+			Condicao     string `json:"condicao"`
+			Localizacao  string `json:"localizacao"`
+			Status       string `json:"status"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		exemplar := &models.Exemplar{
+			LivroID:      livroID,
+			CodigoBarras: req.CodigoBarras,
+			Condicao:     req.Condicao,
+			Localizacao:  req.Localizacao,
+			Status:       req.Status,
+		}
+		if err := exemplarService.CadastrarExemplar(exemplar); err != nil {
+			respondExemplarError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, exemplar)
+	}
+}
+
+func listarExemplaresHandler(exemplarService *service.ExemplarService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit := getPagination(c)
+		exemplares, total, err := exemplarService.ListarExemplares(repository.ExemplarFilter{
+			Localizacao: c.Query("localizacao"),
+			Status:      c.Query("status"),
+			Page:        page,
+			Limit:       limit,
+		})
+		if err != nil {
+			respondExemplarError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": exemplares, "total": total, "page": page, "limit": limit})
+	}
+}
+
+func resumoInventarioHandler(exemplarService *service.ExemplarService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resumo, err := exemplarService.ResumoInventario()
+		if err != nil {
+			respondExemplarError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, resumo)
+	}
+}
+
+func atualizarExemplarHandler(exemplarService *service.ExemplarService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		var req struct {
+			Condicao    string `json:"condicao"`
+			Localizacao string `json:"localizacao"`
+			Status      string `json:"status"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		exemplar, err := exemplarService.AtualizarExemplar(id, req.Condicao, req.Localizacao, req.Status)
+		if err != nil {
+			respondExemplarError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, exemplar)
+	}
+}
+
+func removerExemplarHandler(exemplarService *service.ExemplarService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := exemplarService.RemoverExemplar(id); err != nil {
+			respondExemplarError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Exemplar removido com sucesso"})
+	}
+}
+
+func iniciarInventarioHandler(exemplarService *service.ExemplarService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Localizacao string `json:"localizacao" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		inventario, err := exemplarService.IniciarInventario(req.Localizacao, currentUserID(c))
+		if err != nil {
+			respondExemplarError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, inventario)
+	}
+}
+
+func buscarInventarioHandler(exemplarService *service.ExemplarService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		inventario, progresso, err := exemplarService.BuscarInventario(id)
+		if err != nil {
+			respondExemplarError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"inventario": inventario, "progresso": progresso})
+	}
+}
+
+func registrarLeituraHandler(exemplarService *service.ExemplarService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		var req struct {
+			CodigoBarras string `json:"codigo_barras" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		leitura, err := exemplarService.RegistrarLeitura(id, req.CodigoBarras)
+		if err != nil {
+			respondExemplarError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, leitura)
+	}
+}
+
+func concluirInventarioHandler(exemplarService *service.ExemplarService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		resultado, err := exemplarService.ConcluirInventario(id)
+		if err != nil {
+			respondExemplarError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, resultado)
+	}
+}
+
+// respondExemplarError converte os erros de exemplares e inventários em respostas HTTP.
+func respondExemplarError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrExemplarNotFound), errors.Is(err, repository.ErrLivroNotFound),
+		errors.Is(err, repository.ErrInventarioNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, repository.ErrCodigoBarrasInUse), errors.Is(err, repository.ErrExemplarEmprestado),
+		errors.Is(err, repository.ErrExemplarReservado), errors.Is(err, repository.ErrInventarioEmAndamento), errors.Is(err, repository.ErrInventarioConcluido):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrInvalidExemplar), errors.Is(err, service.ErrInvalidExemplarStatus),
+		errors.Is(err, service.ErrLocalizacaoRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao processar exemplares"})
+	}
+}
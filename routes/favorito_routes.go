@@ -0,0 +1,142 @@
+package routes
+
+import (
+	"books_api/middleware"
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FavoritoRoutes configura as rotas de livros favoritos e autores seguidos.
+func FavoritoRoutes(router *gin.Engine, authService *service.AuthService, favoritoService *service.FavoritoService) {
+	me := router.Group("/usuarios/me")
+	me.Use(middleware.AuthMiddleware(authService), middleware.RequirePermission(models.PermLeituraWrite))
+	{
+		me.GET("/favoritos", listarFavoritosHandler(favoritoService))
+		me.POST("/favoritos", favoritarHandler(favoritoService))
+		// Nas rotas de favoritos, o ID é o do livro.
+		me.DELETE("/favoritos/:id", desfavoritarHandler(favoritoService))
+		me.GET("/seguindo", listarSeguindoHandler(favoritoService))
+		me.POST("/seguindo", seguirAutorHandler(favoritoService))
+		me.DELETE("/seguindo/:id", deixarDeSeguirHandler(favoritoService))
+	}
+}
+
+func listarFavoritosHandler(favoritoService *service.FavoritoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit := getPagination(c)
+		favoritos, total, err := favoritoService.ListarFavoritos(currentUserID(c), page, limit)
+		if err != nil {
+			respondFavoritoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": favoritos, "total": total, "page": page, "limit": limit})
+	}
+}
+
+func favoritarHandler(favoritoService *service.FavoritoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			LivroID uint `json:"livro_id" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		favorito, err := favoritoService.Favoritar(currentUserID(c), req.LivroID)
+		if err != nil {
+			respondFavoritoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, favorito)
+	}
+}
+
+func desfavoritarHandler(favoritoService *service.FavoritoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		livroID, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := favoritoService.Desfavoritar(currentUserID(c), livroID); err != nil {
+			respondFavoritoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Livro removido dos favoritos"})
+	}
+}
+
+func listarSeguindoHandler(favoritoService *service.FavoritoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		seguidos, err := favoritoService.ListarSeguindo(currentUserID(c))
+		if err != nil {
+			respondFavoritoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, seguidos)
+	}
+}
+
+// seguirAutorHandler passa a seguir um autor. email indica se o usuário também quer ser avisado por
+// e-mail dos livros novos do autor.
+func seguirAutorHandler(favoritoService *service.FavoritoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Autor string `json:"autor" binding:"required"`
+			Email bool   `json:"email"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		seguido, err := favoritoService.Seguir(currentUserID(c), req.Autor, req.Email)
+		if err != nil {
+			respondFavoritoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, seguido)
+	}
+}
+
+func deixarDeSeguirHandler(favoritoService *service.FavoritoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := favoritoService.DeixarDeSeguir(currentUserID(c), id); err != nil {
+			respondFavoritoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Autor deixou de ser seguido"})
+	}
+}
+
+// respondFavoritoError converte os erros de favoritos e autores seguidos em respostas HTTP.
+func respondFavoritoError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrLivroNotFound), errors.Is(err, repository.ErrFavoritoNotFound),
+		errors.Is(err, repository.ErrAutorSeguidoNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, models.ErrAutorInvalido):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao processar favoritos"})
+	}
+}
@@ -0,0 +1,200 @@
+package routes
+
+import (
+	"books_api/middleware"
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LeituraRoutes configura as rotas de acompanhamento de leituras, estatísticas e metas anuais.
+func LeituraRoutes(router *gin.Engine, authService *service.AuthService, leituraService *service.LeituraService) {
+	me := router.Group("/usuarios/me")
+	me.Use(middleware.AuthMiddleware(authService), middleware.RequirePermission(models.PermLeituraWrite))
+	{
+		me.POST("/leituras", registrarSessaoHandler(leituraService))
+		me.GET("/leituras", listarLeiturasHandler(leituraService))
+		// Nas rotas de uma leitura, o ID é o do livro.
+		me.GET("/leituras/:id", buscarLeituraHandler(leituraService))
+		me.DELETE("/leituras/:id", removerLeituraHandler(leituraService))
+		me.GET("/estatisticas", estatisticasLeituraHandler(leituraService))
+		me.PUT("/metas/:ano", definirMetaHandler(leituraService))
+		me.DELETE("/metas/:ano", removerMetaHandler(leituraService))
+	}
+}
+
+// registrarSessaoHandler grava uma sessão de leitura. inicio e fim são opcionais e valem o instante
+// atual; concluida encerra a leitura do livro.
+func registrarSessaoHandler(leituraService *service.LeituraService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			LivroID   uint       `json:"livro_id" binding:"required"`
+			Paginas   int        `json:"paginas"`
+			Inicio    *time.Time `json:"inicio"`
+			Fim       *time.Time `json:"fim"`
+			Concluida bool       `json:"concluida"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		var inicio, fim time.Time
+		if req.Inicio != nil {
+			inicio = *req.Inicio
+		}
+		if req.Fim != nil {
+			fim = *req.Fim
+		}
+
+		leitura, err := leituraService.RegistrarSessao(currentUserID(c), req.LivroID, req.Paginas, inicio, fim, req.Concluida)
+		if err != nil {
+			respondLeituraError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, leitura)
+	}
+}
+
+// listarLeiturasHandler lista as leituras do usuário com o progresso, opcionalmente filtradas por status
+// (lendo ou concluida).
+func listarLeiturasHandler(leituraService *service.LeituraService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit := getPagination(c)
+		leituras, total, err := leituraService.ListarLeituras(repository.LeituraFilter{
+			UserID: currentUserID(c),
+			Status: c.Query("status"),
+			Page:   page,
+			Limit:  limit,
+		})
+		if err != nil {
+			respondLeituraError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": leituras, "total": total, "page": page, "limit": limit})
+	}
+}
+
+func buscarLeituraHandler(leituraService *service.LeituraService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		livroID, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		leitura, err := leituraService.BuscarLeitura(currentUserID(c), livroID)
+		if err != nil {
+			respondLeituraError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, leitura)
+	}
+}
+
+func removerLeituraHandler(leituraService *service.LeituraService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		livroID, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := leituraService.RemoverLeitura(currentUserID(c), livroID); err != nil {
+			respondLeituraError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Leitura removida com sucesso"})
+	}
+}
+
+// estatisticasLeituraHandler retorna as estatísticas do ano informado em ?ano, ou do ano atual.
+func estatisticasLeituraHandler(leituraService *service.LeituraService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ano := time.Now().Year()
+		if value := c.Query("ano"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Filtro ano inválido"})
+				return
+			}
+			ano = n
+		}
+
+		estatisticas, err := leituraService.Estatisticas(currentUserID(c), ano)
+		if err != nil {
+			respondLeituraError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, estatisticas)
+	}
+}
+
+func definirMetaHandler(leituraService *service.LeituraService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ano, err := strconv.Atoi(c.Param("ano"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": models.ErrAnoInvalido.Error()})
+			return
+		}
+
+		var req struct {
+			Livros int `json:"livros" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		meta, err := leituraService.DefinirMeta(currentUserID(c), ano, req.Livros)
+		if err != nil {
+			respondLeituraError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, meta)
+	}
+}
+
+func removerMetaHandler(leituraService *service.LeituraService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ano, err := strconv.Atoi(c.Param("ano"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": models.ErrAnoInvalido.Error()})
+			return
+		}
+
+		if err := leituraService.RemoverMeta(currentUserID(c), ano); err != nil {
+			respondLeituraError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Meta removida com sucesso"})
+	}
+}
+
+// respondLeituraError converte os erros de leitura em respostas HTTP.
+func respondLeituraError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrLeituraNotFound), errors.Is(err, repository.ErrMetaLeituraNotFound),
+		errors.Is(err, repository.ErrLivroNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, models.ErrPaginasSessaoInvalidas), errors.Is(err, models.ErrPeriodoSessaoInvalido),
+		errors.Is(err, models.ErrMetaLeituraInvalida), errors.Is(err, models.ErrAnoInvalido),
+		errors.Is(err, service.ErrSessaoVazia), errors.Is(err, service.ErrInvalidLeituraStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao processar leitura"})
+	}
+}
@@ -3,7 +3,9 @@ package routes
 import (
 	"books_api/middleware"
 	"books_api/models"
+	"books_api/repository"
 	"books_api/service"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -13,16 +15,19 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func BookRoutes(router *gin.Engine, livroService service.LivroService) { // Receber o serviço corretamente
+func BookRoutes(router *gin.Engine, authService *service.AuthService, livroService service.LivroService, recomendacaoService *service.RecomendacaoService) { // Receber o serviço corretamente
 	livros := router.Group("/livros")
-	livros.Use(middleware.AuthMiddleware())
+	livros.Use(middleware.AuthMiddleware(authService))
 	{
-		livros.GET("", func(c *gin.Context) { listarLivros(c, livroService) })
-		livros.GET("/:id", func(c *gin.Context) { buscarLivroPorID(c, livroService) })
-		livros.POST("", func(c *gin.Context) { criarLivro(c, livroService) })
-		livros.PUT("/:id", func(c *gin.Context) { atualizarLivro(c, livroService) })
-		livros.DELETE("/:id", func(c *gin.Context) { deletarLivro(c, livroService) })
-		livros.POST("/:id/upload", func(c *gin.Context) { uploadImagemLivro(c, livroService) }) // Passando o serviço
+		leitura := middleware.RequirePermission(models.PermLivrosRead)
+		escrita := middleware.RequirePermission(models.PermLivrosWrite)
+
+		livros.GET("", leitura, func(c *gin.Context) { listarLivros(c, livroService) })
+		livros.GET("/:id", leitura, func(c *gin.Context) { buscarLivroPorID(c, livroService, recomendacaoService) })
+		livros.POST("", escrita, func(c *gin.Context) { criarLivro(c, livroService) })
+		livros.PUT("/:id", escrita, func(c *gin.Context) { atualizarLivro(c, livroService) })
+		livros.DELETE("/:id", escrita, func(c *gin.Context) { deletarLivro(c, livroService) })
+		livros.POST("/:id/upload", escrita, func(c *gin.Context) { uploadImagemLivro(c, livroService) }) // Passando o serviço
 	}
 }
 
@@ -63,14 +68,22 @@ func uploadImagemLivro(c *gin.Context, srv service.LivroService) { // Agora rece
 }
 
 func getIDFromParam(c *gin.Context) (uint, error) {
-	idParam := c.Param("id")
-	id, err := strconv.ParseUint(idParam, 10, 32)
+	return getUintParam(c, "id")
+}
+
+// getUintParam lê um ID numérico do parâmetro de rota informado.
+func getUintParam(c *gin.Context, name string) (uint, error) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
 	return uint(id), err
 }
 
 func listarLivros(c *gin.Context, srv service.LivroService) {
 	ctx := c.Request.Context()
-	livros, err := srv.ListarLivros(ctx)
+	livros, err := srv.ListarLivros(ctx, c.Query("ordenar"))
+	if errors.Is(err, service.ErrInvalidOrdem) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao buscar livros"})
 		return
@@ -86,8 +99,8 @@ func listarLivros(c *gin.Context, srv service.LivroService) {
 	c.JSON(http.StatusOK, livros)
 }
 
-// buscarLivroPorID busca um livro pelo seu ID.
-func buscarLivroPorID(c *gin.Context, srv service.LivroService) {
+// buscarLivroPorID busca um livro pelo seu ID e registra a visualização para as recomendações.
+func buscarLivroPorID(c *gin.Context, srv service.LivroService, recomendacoes *service.RecomendacaoService) {
 	ctx := c.Request.Context()
 
 	id, err := getIDFromParam(c)
@@ -107,6 +120,8 @@ func buscarLivroPorID(c *gin.Context, srv service.LivroService) {
 		return
 	}
 
+	go recomendacoes.RegistrarVisualizacao(currentUserID(c), id)
+
 	c.JSON(http.StatusOK, livro)
 }
 
@@ -165,6 +180,10 @@ func deletarLivro(c *gin.Context, srv service.LivroService) {
 	}
 
 	if err := srv.DeletarLivro(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrEmprestimosAtivos) {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao deletar livro"})
 		return
 	}
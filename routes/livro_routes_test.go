@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLivroRouter monta as rotas de livros sem autenticação, sobre um repositório em memória.
+func newLivroRouter(livros ...models.Livro) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	srv := service.NewLivroService(repository.NewMemoryLivroRepository(livros...), nil, nil)
+
+	router := gin.New()
+	router.GET("/livros/:id", func(c *gin.Context) { buscarLivroPorID(c, srv, nil) })
+	router.POST("/livros", func(c *gin.Context) { criarLivro(c, srv) })
+	router.PUT("/livros/:id", func(c *gin.Context) { atualizarLivro(c, srv) })
+	return router
+}
+
+func TestBuscarLivroPorIDHandler(t *testing.T) {
+	router := newLivroRouter(models.Livro{Titulo: "Vidas Secas", Autor: "Graciliano Ramos"})
+
+	tests := []struct {
+		name string
+		path string
+		code int
+	}{
+		{"encontrado", "/livros/1", http.StatusOK},
+		{"inexistente", "/livros/2", http.StatusNotFound},
+		{"id inválido", "/livros/abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
+
+func TestCriarEAtualizarLivroHandler(t *testing.T) {
+	router := newLivroRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/livros",
+		strings.NewReader(`{"titulo":"Grande Sertão: Veredas","autor":"Guimarães Rosa"}`)))
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var criado models.Livro
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &criado))
+	assert.Equal(t, uint(1), criado.ID)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/livros/1",
+		strings.NewReader(`{"titulo":"Grande Sertão: Veredas","autor":"João Guimarães Rosa"}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "João Guimarães Rosa")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/livros/99", strings.NewReader(`{"titulo":"X"}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,165 @@
+package routes
+
+import (
+	"books_api/repository"
+	"books_api/service"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func completarLoginMFAHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			MFAToken string `json:"mfa_token" binding:"required"`
+			Code     string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		result, err := authService.CompletarLoginMFA(req.MFAToken, req.Code, loginInfo(c))
+		if err != nil {
+			respondLoginError(c, err)
+			return
+		}
+
+		respondLogin(c, result)
+	}
+}
+
+func iniciarMFAHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Password string `json:"password" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		enrollment, err := authService.IniciarMFA(currentUserID(c), req.Password)
+		if err != nil {
+			respondMFAError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, enrollment)
+	}
+}
+
+func confirmarMFAHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		codes, err := authService.ConfirmarMFA(currentUserID(c), req.Code)
+		if err != nil {
+			respondMFAError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":             "2FA ativado com sucesso. Guarde os códigos de recuperação em local seguro.",
+			"codigos_recuperacao": codes,
+		})
+	}
+}
+
+func desativarMFAHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Password string `json:"password" binding:"required"`
+			Code     string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		if err := authService.DesativarMFA(currentUserID(c), req.Password, req.Code); err != nil {
+			respondMFAError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "2FA desativado com sucesso"})
+	}
+}
+
+func gerarCodigosRecuperacaoHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		codes, err := authService.GerarCodigosRecuperacao(currentUserID(c), req.Code)
+		if err != nil {
+			respondMFAError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"codigos_recuperacao": codes})
+	}
+}
+
+// loginInfo extrai a origem da requisição de login.
+func loginInfo(c *gin.Context) service.LoginInfo {
+	return service.LoginInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// respondLogin responde com o token de acesso ou, se a conta possuir 2FA, com o token temporário.
+func respondLogin(c *gin.Context, result *service.LoginResult) {
+	if result.MFARequired {
+		c.JSON(http.StatusOK, gin.H{"mfa_required": true, "mfa_token": result.MFAToken})
+		return
+	}
+
+	response := gin.H{"token": result.Token}
+	if result.MFAEnrollmentRequired {
+		response["mfa_enrollment_required"] = true
+	}
+	if result.PasswordChangeRequired {
+		response["password_change_required"] = true
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// respondLoginError converte os erros de login em respostas HTTP, incluindo o Retry-After em bloqueios.
+func respondLoginError(c *gin.Context, err error) {
+	var locked *service.LockedError
+	if errors.As(err, &locked) {
+		seconds := int(math.Ceil(locked.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": err.Error(), "retry_after": seconds})
+		return
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+}
+
+// respondMFAError converte os erros de gerenciamento de 2FA em respostas HTTP.
+func respondMFAError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWrongPassword), errors.Is(err, service.ErrInvalidMFACode):
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrMFAAlreadyEnabled), errors.Is(err, service.ErrMFANotEnabled),
+		errors.Is(err, service.ErrMFANotStarted):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, repository.ErrUserNotFound):
+		respondUserError(c, err)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao processar 2FA"})
+	}
+}
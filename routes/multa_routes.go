@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"books_api/middleware"
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MultaRoutes configura as rotas de consulta e pagamento de multas por atraso.
+func MultaRoutes(router *gin.Engine, authService *service.AuthService, multaService *service.MultaService) {
+	multas := router.Group("/multas")
+	multas.Use(middleware.AuthMiddleware(authService), middleware.RequirePermission(models.PermEmprestimosWrite))
+	{
+		multas.GET("", listarMultasHandler(multaService))
+		multas.POST("/:id/pagar", middleware.RequirePermission(models.PermEmprestimosManage), pagarMultaHandler(multaService))
+	}
+}
+
+// listarMultasHandler lista as multas do usuário. Quem gerencia empréstimos vê as de todos os
+// usuários e pode filtrar por usuario_id.
+func listarMultasHandler(multaService *service.MultaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit := getPagination(c)
+		filter := repository.MultaFilter{Status: c.Query("status"), Page: page, Limit: limit}
+
+		if canManageEmprestimos(c) {
+			if value := c.Query("usuario_id"); value != "" {
+				id, err := strconv.ParseUint(value, 10, 32)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"message": "Filtro usuario_id inválido"})
+					return
+				}
+				userID := uint(id)
+				filter.UserID = &userID
+			}
+		} else {
+			userID := currentUserID(c)
+			filter.UserID = &userID
+		}
+
+		multas, total, err := multaService.ListarMultas(filter)
+		if err != nil {
+			respondMultaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": multas, "total": total, "page": page, "limit": limit})
+	}
+}
+
+func pagarMultaHandler(multaService *service.MultaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		multa, err := multaService.PagarMulta(id)
+		if err != nil {
+			respondMultaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, multa)
+	}
+}
+
+// respondMultaError converte os erros de multa em respostas HTTP.
+func respondMultaError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrMultaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, repository.ErrMultaPaga), errors.Is(err, repository.ErrMultaEmAberto):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrInvalidMultaStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao processar multas"})
+	}
+}
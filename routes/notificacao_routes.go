@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"books_api/middleware"
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificacaoRoutes configura as rotas das notificações do usuário no aplicativo.
+func NotificacaoRoutes(router *gin.Engine, authService *service.AuthService, notificacaoService *service.NotificacaoService) {
+	notificacoes := router.Group("/usuarios/me/notificacoes")
+	notificacoes.Use(middleware.AuthMiddleware(authService), middleware.RequirePermission(models.PermLeituraWrite))
+	{
+		notificacoes.GET("", listarNotificacoesHandler(notificacaoService))
+		notificacoes.POST("/lidas", marcarTodasLidasHandler(notificacaoService))
+		notificacoes.POST("/:id/lida", marcarNotificacaoLidaHandler(notificacaoService))
+	}
+}
+
+// listarNotificacoesHandler lista as notificações do usuário; ?nao_lidas=true mostra só as não lidas.
+func listarNotificacoesHandler(notificacaoService *service.NotificacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit := getPagination(c)
+		notificacoes, total, naoLidas, err := notificacaoService.ListarNotificacoes(repository.NotificacaoFilter{
+			UserID:   currentUserID(c),
+			NaoLidas: c.Query("nao_lidas") == "true",
+			Page:     page,
+			Limit:    limit,
+		})
+		if err != nil {
+			respondNotificacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": notificacoes, "total": total, "page": page, "limit": limit, "nao_lidas": naoLidas})
+	}
+}
+
+func marcarNotificacaoLidaHandler(notificacaoService *service.NotificacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		notificacao, err := notificacaoService.MarcarLida(currentUserID(c), id)
+		if err != nil {
+			respondNotificacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, notificacao)
+	}
+}
+
+func marcarTodasLidasHandler(notificacaoService *service.NotificacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		marcadas, err := notificacaoService.MarcarTodasLidas(currentUserID(c))
+		if err != nil {
+			respondNotificacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"marcadas": marcadas})
+	}
+}
+
+// respondNotificacaoError converte os erros de notificações em respostas HTTP.
+func respondNotificacaoError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotificacaoNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao processar notificações"})
+	}
+}
@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"books_api/oidc"
+	"books_api/service"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oidcFlowCookie     = "oidc_flow"
+	oidcFlowCookiePath = "/auth/oidc"
+	oidcFlowCookieAge  = 600
+)
+
+func listarProvedoresOIDCHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		names := authService.OIDCProviderNames()
+		sort.Strings(names)
+		c.JSON(http.StatusOK, gin.H{"provedores": names})
+	}
+}
+
+// iniciarLoginOIDCHandler redireciona o navegador ao provedor de identidade. O estado do fluxo
+// fica em um cookie HttpOnly restrito às rotas de OIDC.
+func iniciarLoginOIDCHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authURL, flowToken, err := authService.IniciarLoginOIDC(c.Request.Context(), c.Param("provider"))
+		if err != nil {
+			respondOIDCError(c, err)
+			return
+		}
+
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(oidcFlowCookie, flowToken, oidcFlowCookieAge, oidcFlowCookiePath, "", secureCookie(c, authService), true)
+		c.Redirect(http.StatusFound, authURL)
+	}
+}
+
+func callbackOIDCHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		flowToken, _ := c.Cookie(oidcFlowCookie)
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(oidcFlowCookie, "", -1, oidcFlowCookiePath, "", secureCookie(c, authService), true)
+
+		if providerErr := c.Query("error"); providerErr != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Login recusado pelo provedor de identidade", "error": providerErr})
+			return
+		}
+
+		result, err := authService.CompletarLoginOIDC(c.Request.Context(), c.Param("provider"),
+			c.Query("code"), c.Query("state"), flowToken, loginInfo(c))
+		if err != nil {
+			respondOIDCError(c, err)
+			return
+		}
+
+		respondLogin(c, result)
+	}
+}
+
+func identidadesExternasHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identities, err := authService.IdentidadesExternas(currentUserID(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao listar logins externos"})
+			return
+		}
+		c.JSON(http.StatusOK, identities)
+	}
+}
+
+// respondOIDCError converte os erros do login externo em respostas HTTP.
+func respondOIDCError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, oidc.ErrProviderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrOIDCInvalidFlow):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrOIDCEmailConflict):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrOIDCSignupDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrUserDisabled), errors.Is(err, oidc.ErrInvalidIDToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+	default:
+		log.Printf("Erro no login OIDC: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"message": "Erro ao comunicar com o provedor de identidade"})
+	}
+}
+
+// secureCookie indica se o cookie deve ser restrito a HTTPS.
+func secureCookie(c *gin.Context, authService *service.AuthService) bool {
+	return c.Request.TLS != nil || strings.HasPrefix(authService.BaseURL, "https://")
+}
@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"books_api/middleware"
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecomendacaoRoutes configura as rotas de curtidas, livros similares e recomendações.
+func RecomendacaoRoutes(router *gin.Engine, authService *service.AuthService, recomendacaoService *service.RecomendacaoService) {
+	auth := middleware.AuthMiddleware(authService)
+	leitura := middleware.RequirePermission(models.PermLivrosRead)
+	curtir := middleware.RequirePermission(models.PermLeituraWrite)
+
+	router.GET("/livros/:id/similares", auth, leitura, livrosSimilaresHandler(recomendacaoService))
+	router.POST("/livros/:id/curtida", auth, curtir, curtirLivroHandler(recomendacaoService))
+	router.DELETE("/livros/:id/curtida", auth, curtir, descurtirLivroHandler(recomendacaoService))
+	router.GET("/usuarios/me/recomendacoes", auth, leitura, recomendacoesHandler(recomendacaoService))
+}
+
+// livrosSimilaresHandler lista os livros similares, limitados por ?limite (padrão 10).
+func livrosSimilaresHandler(recomendacaoService *service.RecomendacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+		limite, ok := getLimiteRecomendacoes(c)
+		if !ok {
+			return
+		}
+
+		livros, err := recomendacaoService.LivrosSimilares(id, limite)
+		if err != nil {
+			respondRecomendacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, livros)
+	}
+}
+
+// recomendacoesHandler lista os livros recomendados ao usuário, limitados por ?limite (padrão 10).
+func recomendacoesHandler(recomendacaoService *service.RecomendacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limite, ok := getLimiteRecomendacoes(c)
+		if !ok {
+			return
+		}
+
+		livros, err := recomendacaoService.Recomendacoes(currentUserID(c), limite)
+		if err != nil {
+			respondRecomendacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, livros)
+	}
+}
+
+func curtirLivroHandler(recomendacaoService *service.RecomendacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := recomendacaoService.Curtir(currentUserID(c), id); err != nil {
+			respondRecomendacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Livro curtido"})
+	}
+}
+
+func descurtirLivroHandler(recomendacaoService *service.RecomendacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		if err := recomendacaoService.Descurtir(currentUserID(c), id); err != nil {
+			respondRecomendacaoError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Curtida removida"})
+	}
+}
+
+// getLimiteRecomendacoes lê ?limite, respondendo 400 se o valor for inválido.
+func getLimiteRecomendacoes(c *gin.Context) (int, bool) {
+	value := c.Query("limite")
+	if value == "" {
+		return 0, true
+	}
+	limite, err := strconv.Atoi(value)
+	if err != nil || limite < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Filtro limite inválido"})
+		return 0, false
+	}
+	return limite, true
+}
+
+// respondRecomendacaoError converte os erros de recomendações em respostas HTTP.
+func respondRecomendacaoError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrLivroNotFound), errors.Is(err, repository.ErrCurtidaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao buscar recomendações"})
+	}
+}
@@ -0,0 +1,183 @@
+package routes
+
+import (
+	"books_api/middleware"
+	"books_api/models"
+	"books_api/repository"
+	"books_api/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReservaRoutes configura as rotas da fila de espera de livros.
+func ReservaRoutes(router *gin.Engine, authService *service.AuthService, reservaService *service.ReservaService) {
+	auth := middleware.AuthMiddleware(authService)
+	emprestimo := middleware.RequirePermission(models.PermEmprestimosWrite)
+	gerenciar := middleware.RequirePermission(models.PermEmprestimosManage)
+
+	router.POST("/livros/:id/reservas", auth, emprestimo, reservarLivroHandler(reservaService))
+	router.GET("/livros/:id/reservas", auth, gerenciar, filaReservasHandler(reservaService))
+	router.POST("/livros/:id/disponivel", auth, gerenciar, marcarDisponivelHandler(reservaService))
+
+	reservas := router.Group("/reservas")
+	reservas.Use(auth, emprestimo)
+	{
+		reservas.GET("", listarReservasHandler(reservaService))
+		reservas.DELETE("/:id", cancelarReservaHandler(reservaService))
+	}
+}
+
+func reservarLivroHandler(reservaService *service.ReservaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		livroID, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		reserva, err := reservaService.Reservar(livroID, currentUserID(c))
+		if err != nil {
+			respondReservaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, reserva)
+	}
+}
+
+// filaReservasHandler lista as reservas ativas do livro na ordem da fila.
+func filaReservasHandler(reservaService *service.ReservaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		livroID, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		page, limit := getPagination(c)
+		reservas, total, err := reservaService.ListarReservas(repository.ReservaFilter{
+			LivroID: &livroID,
+			Ativas:  true,
+			Page:    page,
+			Limit:   limit,
+		})
+		if err != nil {
+			respondReservaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": reservas, "total": total, "page": page, "limit": limit})
+	}
+}
+
+// marcarDisponivelHandler separa os exemplares disponíveis do livro para os primeiros da fila.
+// codigo_barras é opcional e devolve ao acervo o exemplar lido no balcão.
+func marcarDisponivelHandler(reservaService *service.ReservaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		livroID, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		var req struct {
+			CodigoBarras string `json:"codigo_barras"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+				return
+			}
+		}
+
+		atribuidas, err := reservaService.MarcarDisponivel(livroID, req.CodigoBarras)
+		if err != nil {
+			respondReservaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"atribuidas": atribuidas})
+	}
+}
+
+// listarReservasHandler lista as reservas do usuário, com a posição na fila das que estão aguardando.
+// Quem gerencia empréstimos vê as de todos os usuários e pode filtrar por usuario_id.
+func listarReservasHandler(reservaService *service.ReservaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, limit := getPagination(c)
+		filter := repository.ReservaFilter{Status: c.Query("status"), Page: page, Limit: limit}
+
+		if canManageEmprestimos(c) {
+			if value := c.Query("usuario_id"); value != "" {
+				id, err := strconv.ParseUint(value, 10, 32)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"message": "Filtro usuario_id inválido"})
+					return
+				}
+				userID := uint(id)
+				filter.UserID = &userID
+			}
+		} else {
+			userID := currentUserID(c)
+			filter.UserID = &userID
+		}
+
+		if value := c.Query("livro_id"); value != "" {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Filtro livro_id inválido"})
+				return
+			}
+			livroID := uint(id)
+			filter.LivroID = &livroID
+		}
+
+		reservas, total, err := reservaService.ListarReservas(filter)
+		if err != nil {
+			respondReservaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": reservas, "total": total, "page": page, "limit": limit})
+	}
+}
+
+func cancelarReservaHandler(reservaService *service.ReservaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := getIDFromParam(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "ID inválido"})
+			return
+		}
+
+		reserva, err := reservaService.Cancelar(id, currentUserID(c), canManageEmprestimos(c))
+		if err != nil {
+			respondReservaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, reserva)
+	}
+}
+
+// respondReservaError converte os erros de reserva em respostas HTTP.
+func respondReservaError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrLivroNotFound), errors.Is(err, repository.ErrReservaNotFound),
+		errors.Is(err, repository.ErrExemplarNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, repository.ErrReservaDuplicada), errors.Is(err, repository.ErrReservaEncerrada),
+		errors.Is(err, repository.ErrLivroDisponivel), errors.Is(err, repository.ErrExemplarEmprestado),
+		errors.Is(err, repository.ErrExemplarReservado):
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrNotReservaOwner):
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+	case errors.Is(err, service.ErrInvalidReservaStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao processar reserva"})
+	}
+}
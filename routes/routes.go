@@ -1,15 +1,69 @@
 package routes
 
 import (
+	"books_api/repository"
 	"books_api/service"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
 // SetupRoutes configura todas as rotas da aplicação
-func SetupRoutes(router *gin.Engine, authService *service.AuthService, livroService service.LivroService) {
+func SetupRoutes(router *gin.Engine, authService *service.AuthService, userService *service.UserService, livroService service.LivroService, emprestimoService *service.EmprestimoService, exemplarService *service.ExemplarService, reservaService *service.ReservaService, multaService *service.MultaService, avaliacaoService *service.AvaliacaoService, estanteService *service.EstanteService, leituraService *service.LeituraService, recomendacaoService *service.RecomendacaoService, favoritoService *service.FavoritoService, notificacaoService *service.NotificacaoService, livrosCache *repository.CachedLivroRepository) {
 	// Configura as rotas de autenticação
 	AuthRoutes(router, authService)
 
-	BookRoutes(router, livroService)
+	WellKnownRoutes(router, authService)
+
+	AdminRoutes(router, authService, userService)
+
+	BookRoutes(router, authService, livroService, recomendacaoService)
+
+	EmprestimoRoutes(router, authService, emprestimoService)
+
+	ExemplarRoutes(router, authService, exemplarService)
+
+	ReservaRoutes(router, authService, reservaService)
+
+	MultaRoutes(router, authService, multaService)
+
+	AvaliacaoRoutes(router, authService, avaliacaoService)
+
+	EstanteRoutes(router, authService, estanteService)
+
+	LeituraRoutes(router, authService, leituraService)
+
+	RecomendacaoRoutes(router, authService, recomendacaoService)
+
+	FavoritoRoutes(router, authService, favoritoService)
+
+	NotificacaoRoutes(router, authService, notificacaoService)
+
+	CacheRoutes(router, authService, livrosCache)
+}
+
+// currentUserID retorna o ID do usuário autenticado, definido pelo AuthMiddleware.
+func currentUserID(c *gin.Context) uint {
+	return c.GetUint("userID")
+}
+
+// getPagination lê os parâmetros page e limit da query string, aplicando valores padrão.
+func getPagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageSize)))
+	if err != nil || limit < 1 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	return page, limit
 }
@@ -1,7 +1,11 @@
 package routes
 
 import (
+	"books_api/middleware"
+	"books_api/passpolicy"
+	"books_api/repository"
 	"books_api/service"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -12,7 +16,42 @@ func AuthRoutes(router *gin.Engine, authService *service.AuthService) {
 	authGroup := router.Group("/auth")
 	{
 		authGroup.POST("/login", loginHandler(authService))
+		authGroup.POST("/login/2fa", completarLoginMFAHandler(authService))
 		authGroup.POST("/register", registerHandler(authService))
+		authGroup.GET("/verificar-email", verificarEmailHandler(authService))
+		authGroup.POST("/esqueci-senha", esqueciSenhaHandler(authService))
+		authGroup.GET("/redefinir-senha", validarTokenRedefinicaoHandler(authService))
+		authGroup.POST("/redefinir-senha", redefinirSenhaHandler(authService))
+		authGroup.GET("/oidc", listarProvedoresOIDCHandler(authService))
+		authGroup.GET("/oidc/:provider/login", iniciarLoginOIDCHandler(authService))
+		authGroup.GET("/oidc/:provider/callback", callbackOIDCHandler(authService))
+
+		// O perfil e a troca de senha continuam acessíveis para quem é obrigado a trocar a senha.
+		trocaSenha := []gin.HandlerFunc{middleware.AllowPasswordChange(), middleware.AuthMiddleware(authService), middleware.RequireUserSession()}
+		authGroup.GET("/me", append(trocaSenha, perfilHandler(authService))...)
+		authGroup.POST("/me/senha", append(trocaSenha, alterarSenhaHandler(authService))...)
+
+		me := authGroup.Group("/me")
+		me.Use(middleware.AuthMiddleware(authService), middleware.RequireUserSession())
+		{
+			me.PUT("", atualizarPerfilHandler(authService))
+			me.POST("/verificar-email", reenviarVerificacaoHandler(authService))
+			me.GET("/acessos", historicoAcessosHandler(authService))
+			me.GET("/identidades", identidadesExternasHandler(authService))
+			me.POST("/2fa/iniciar", iniciarMFAHandler(authService))
+			me.POST("/2fa/confirmar", confirmarMFAHandler(authService))
+			me.POST("/2fa/desativar", desativarMFAHandler(authService))
+			me.POST("/2fa/codigos-recuperacao", gerarCodigosRecuperacaoHandler(authService))
+			me.DELETE("", excluirContaHandler(authService))
+		}
+
+		apiKeys := authGroup.Group("/api-keys")
+		apiKeys.Use(middleware.AuthMiddleware(authService), middleware.RequireUserSession())
+		{
+			apiKeys.GET("", listarAPIKeysHandler(authService))
+			apiKeys.POST("", criarAPIKeyHandler(authService))
+			apiKeys.DELETE("/:id", revogarAPIKeyHandler(authService))
+		}
 	}
 }
 
@@ -27,13 +66,13 @@ func loginHandler(authService *service.AuthService) gin.HandlerFunc {
 			return
 		}
 
-		token, err := authService.Authenticate(req.Username, req.Password)
+		result, err := authService.Authenticate(req.Username, req.Password, loginInfo(c))
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+			respondLoginError(c, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"token": token})
+		respondLogin(c, result)
 	}
 }
 
@@ -42,13 +81,23 @@ func registerHandler(authService *service.AuthService) gin.HandlerFunc {
 		var req struct {
 			Username string `json:"username" binding:"required"`
 			Password string `json:"password" binding:"required"`
+			Email    string `json:"email"`
+			Language string `json:"language"`
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
 			return
 		}
 
-		if err := authService.Register(req.Username, req.Password); err != nil {
+		lang := req.Language
+		if lang == "" {
+			lang = c.GetHeader("Accept-Language")
+		}
+
+		if err := authService.Register(req.Username, req.Password, req.Email, lang); err != nil {
+			if respondPasswordPolicyError(c, err) {
+				return
+			}
 			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 			return
 		}
@@ -56,3 +105,237 @@ func registerHandler(authService *service.AuthService) gin.HandlerFunc {
 		c.JSON(http.StatusCreated, gin.H{"message": "Usuário registrado com sucesso"})
 	}
 }
+
+func perfilHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := authService.Perfil(currentUserID(c))
+		if err != nil {
+			respondUserError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, user)
+	}
+}
+
+func atualizarPerfilHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			DisplayName string `json:"display_name"`
+			Email       string `json:"email"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		user, err := authService.AtualizarPerfil(currentUserID(c), req.DisplayName, req.Email)
+		if err != nil {
+			if errors.Is(err, repository.ErrEmailInUse) {
+				c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+				return
+			}
+			if errors.Is(err, repository.ErrUserNotFound) {
+				respondUserError(c, err)
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, user)
+	}
+}
+
+func alterarSenhaHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			SenhaAtual string `json:"senha_atual" binding:"required"`
+			NovaSenha  string `json:"nova_senha" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		token, err := authService.AlterarSenha(currentUserID(c), req.SenhaAtual, req.NovaSenha)
+		if err != nil {
+			if respondPasswordPolicyError(c, err) {
+				return
+			}
+			if errors.Is(err, service.ErrWrongPassword) {
+				c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+				return
+			}
+			if errors.Is(err, repository.ErrUserNotFound) {
+				respondUserError(c, err)
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Senha alterada com sucesso", "token": token})
+	}
+}
+
+func excluirContaHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Password string `json:"password" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		if err := authService.ExcluirConta(currentUserID(c), req.Password); err != nil {
+			switch {
+			case errors.Is(err, service.ErrWrongPassword):
+				c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+			case errors.Is(err, service.ErrLastAdmin):
+				c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			default:
+				respondUserError(c, err)
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Conta excluída com sucesso"})
+	}
+}
+
+func verificarEmailHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Token não informado"})
+			return
+		}
+
+		if err := authService.VerificarEmail(token); err != nil {
+			if errors.Is(err, repository.ErrTokenInvalid) {
+				c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao verificar e-mail"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "E-mail verificado com sucesso"})
+	}
+}
+
+func reenviarVerificacaoHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := authService.ReenviarVerificacao(currentUserID(c)); err != nil {
+			switch {
+			case errors.Is(err, service.ErrEmailNotSet), errors.Is(err, service.ErrEmailAlreadyVerified):
+				c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			case errors.Is(err, repository.ErrUserNotFound):
+				respondUserError(c, err)
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao enviar e-mail de verificação"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "E-mail de verificação enviado"})
+	}
+}
+
+func esqueciSenhaHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Email string `json:"email" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		authService.EsqueciSenha(req.Email)
+
+		// A resposta é a mesma para e-mails cadastrados ou não.
+		c.JSON(http.StatusOK, gin.H{"message": "Se o e-mail estiver cadastrado, você receberá as instruções para redefinir a senha"})
+	}
+}
+
+// validarTokenRedefinicaoHandler atende o link enviado por e-mail quando não há frontend configurado,
+// informando se o token ainda é válido para ser enviado com a nova senha.
+func validarTokenRedefinicaoHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Token não informado"})
+			return
+		}
+
+		if err := authService.ValidarTokenRedefinicao(token); err != nil {
+			if errors.Is(err, repository.ErrTokenInvalid) {
+				c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao validar token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Token válido. Envie a nova senha para POST /auth/redefinir-senha",
+			"token":   token,
+		})
+	}
+}
+
+func redefinirSenhaHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Token     string `json:"token" binding:"required"`
+			NovaSenha string `json:"nova_senha" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Requisição inválida"})
+			return
+		}
+
+		if err := authService.RedefinirSenha(req.Token, req.NovaSenha); err != nil {
+			if respondPasswordPolicyError(c, err) {
+				return
+			}
+			if errors.Is(err, repository.ErrUserNotFound) {
+				respondUserError(c, err)
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Senha redefinida com sucesso"})
+	}
+}
+
+func historicoAcessosHandler(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		attempts, err := authService.HistoricoAcessos(currentUserID(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Erro ao buscar histórico de acessos"})
+			return
+		}
+
+		c.JSON(http.StatusOK, attempts)
+	}
+}
+
+// respondPasswordPolicyError responde com as violações da política de senhas, campo a campo.
+// Retorna false se o erro não for de política de senhas.
+func respondPasswordPolicyError(c *gin.Context, err error) bool {
+	var policyErr *passpolicy.ValidationError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"message": "A senha não atende à política de senhas",
+		"errors":  policyErr.Violations,
+	})
+	return true
+}
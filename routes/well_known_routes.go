@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"books_api/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WellKnownRoutes publica as chaves públicas usadas para validar os tokens emitidos pela API.
+func WellKnownRoutes(router *gin.Engine, authService *service.AuthService) {
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, authService.Keys.JWKS())
+	})
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"books_api/mail"
+	"books_api/models"
+	"books_api/notify"
+	"books_api/repository"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+const notifyTimeout = 15 * time.Second
+
+// AtrasoService verifica periodicamente os empréstimos, enviando lembretes antes do vencimento,
+// avisos de atraso e atualizando as multas dos empréstimos atrasados.
+type AtrasoService struct {
+	Emprestimos *repository.EmprestimoRepository
+	Multas      *repository.MultaRepository
+	Notifier    notify.Notifier
+	Politica    PoliticaMultas
+	// LembreteAntes é a antecedência do lembrete de vencimento; 0 desativa o lembrete.
+	LembreteAntes time.Duration
+	// IntervaloAviso é o tempo mínimo entre dois avisos de atraso do mesmo empréstimo.
+	IntervaloAviso time.Duration
+	// Intervalo é a frequência da verificação em Run.
+	Intervalo time.Duration
+}
+
+// ResultadoAtrasos resume uma verificação de empréstimos.
+type ResultadoAtrasos struct {
+	Lembretes int
+	Avisos    int
+	Multas    int
+}
+
+// NewAtrasoService cria o serviço lendo EMPRESTIMO_LEMBRETE_ANTES (padrão 48h),
+// EMPRESTIMO_AVISO_ATRASO_INTERVALO (padrão 72h), EMPRESTIMO_VERIFICACAO_INTERVALO (padrão 1h)
+// e a política de multas.
+func NewAtrasoService(emprestimos *repository.EmprestimoRepository, multas *repository.MultaRepository, notifier notify.Notifier) *AtrasoService {
+	return &AtrasoService{
+		Emprestimos:    emprestimos,
+		Multas:         multas,
+		Notifier:       notifier,
+		Politica:       PoliticaMultasFromEnv(),
+		LembreteAntes:  envDuration("EMPRESTIMO_LEMBRETE_ANTES", 48*time.Hour),
+		IntervaloAviso: envDuration("EMPRESTIMO_AVISO_ATRASO_INTERVALO", 72*time.Hour),
+		Intervalo:      envDuration("EMPRESTIMO_VERIFICACAO_INTERVALO", time.Hour),
+	}
+}
+
+// Verificar envia os lembretes de vencimento, atualiza as multas dos empréstimos atrasados e avisa
+// os usuários sobre os atrasos. Falhas no envio de um aviso não interrompem a verificação; o aviso
+// não é repetido antes do próximo intervalo.
+func (s *AtrasoService) Verificar(ctx context.Context, now time.Time) (*ResultadoAtrasos, error) {
+	resultado := &ResultadoAtrasos{}
+
+	if s.LembreteAntes > 0 {
+		vencendo, err := s.Emprestimos.Vencendo(now, now.Add(s.LembreteAntes))
+		if err != nil {
+			return resultado, fmt.Errorf("erro ao buscar empréstimos a vencer: %w", err)
+		}
+		for i := range vencendo {
+			e := &vencendo[i]
+			ok, err := s.Emprestimos.MarcarLembrete(e.ID, now)
+			if err != nil {
+				return resultado, err
+			}
+			if !ok {
+				continue
+			}
+			s.notificar(ctx, notify.EventEmprestimoVencendo, e, nil)
+			resultado.Lembretes++
+		}
+	}
+
+	atrasados, err := s.Emprestimos.Atrasados(now)
+	if err != nil {
+		return resultado, fmt.Errorf("erro ao buscar empréstimos atrasados: %w", err)
+	}
+	for i := range atrasados {
+		e := &atrasados[i]
+		dias, valor := s.Politica.Calcular(e.DataDevolucaoPrevista, now)
+		if valor > 0 {
+			if err := s.Multas.Registrar(e, dias, valor); err != nil {
+				return resultado, fmt.Errorf("erro ao registrar multa: %w", err)
+			}
+			resultado.Multas++
+		}
+
+		ok, err := s.Emprestimos.MarcarAvisoAtraso(e.ID, now, now.Add(-s.IntervaloAviso))
+		if err != nil {
+			return resultado, err
+		}
+		if !ok {
+			continue
+		}
+		s.notificar(ctx, notify.EventEmprestimoAtrasado, e, map[string]string{
+			"dias_atraso": strconv.Itoa(dias),
+			"multa":       formatMoeda(e.User.Language, valor),
+		})
+		resultado.Avisos++
+	}
+
+	return resultado, nil
+}
+
+// Run executa Verificar periodicamente até que o contexto seja cancelado.
+func (s *AtrasoService) Run(ctx context.Context) {
+	if s.Intervalo <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.Intervalo)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			resultado, err := s.Verificar(ctx, time.Now())
+			if err != nil {
+				log.Printf("Erro na verificação de empréstimos atrasados: %v", err)
+			}
+			if resultado.Lembretes+resultado.Avisos > 0 {
+				log.Printf("Verificação de empréstimos: %d lembrete(s), %d aviso(s) de atraso, %d multa(s) atualizada(s)",
+					resultado.Lembretes, resultado.Avisos, resultado.Multas)
+			}
+		}
+	}
+}
+
+// notificar envia o aviso sobre o empréstimo ao usuário, registrando no log as falhas de entrega.
+func (s *AtrasoService) notificar(ctx context.Context, event string, e *models.Emprestimo, extra map[string]string) {
+	name := e.User.DisplayName
+	if name == "" {
+		name = e.User.Username
+	}
+	data := map[string]string{
+		"emprestimo_id": strconv.FormatUint(uint64(e.ID), 10),
+		"livro":         e.Livro.Titulo,
+		"data_prevista": formatData(e.User.Language, e.DataDevolucaoPrevista),
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	err := s.Notifier.Notify(ctx, notify.Notification{
+		Event:     event,
+		UserID:    e.UserID,
+		Name:      name,
+		Email:     e.User.Email,
+		Language:  e.User.Language,
+		Data:      data,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("Erro ao notificar o usuário %d sobre o empréstimo %d: %v", e.UserID, e.ID, err)
+	}
+}
+
+// formatMoeda formata um valor em centavos no idioma do usuário.
+func formatMoeda(lang string, centavos int64) string {
+	if mail.NormalizeLanguage(lang) == "en" {
+		return fmt.Sprintf("R$ %d.%02d", centavos/100, centavos%100)
+	}
+	return fmt.Sprintf("R$ %d,%02d", centavos/100, centavos%100)
+}
+
+// formatData formata uma data no idioma do usuário.
+func formatData(lang string, t time.Time) string {
+	if mail.NormalizeLanguage(lang) == "en" {
+		return t.Format("Jan 2, 2006")
+	}
+	return t.Format("02/01/2006")
+}
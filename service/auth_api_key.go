@@ -0,0 +1,121 @@
+package service
+
+import (
+	"books_api/models"
+	"books_api/repository"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyPrefix = "bka_"
+	// apiKeyTouchInterval evita gravar o último uso da chave a cada requisição.
+	apiKeyTouchInterval = time.Minute
+)
+
+var (
+	ErrInvalidAPIKey   = errors.New("chave de API inválida, expirada ou revogada")
+	ErrInvalidScope    = errors.New("escopo inválido ou não permitido para o seu papel")
+	ErrAPIKeyName      = errors.New("o nome da chave deve ter entre 1 e 100 caracteres")
+	ErrAPIKeyExpiresAt = errors.New("a data de expiração deve estar no futuro")
+)
+
+// CriarAPIKey cria uma chave de API para o usuário com os escopos informados, que não podem
+// exceder as permissões do seu papel. A chave em texto claro é retornada apenas uma vez.
+func (s *AuthService) CriarAPIKey(userID uint, name string, scopes []models.Permission, expiresAt *time.Time) (string, *models.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return "", nil, ErrAPIKeyName
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "", nil, ErrAPIKeyExpiresAt
+	}
+
+	user, err := s.CheckAccount(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(scopes) == 0 {
+		return "", nil, ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !scope.Valid() || !user.Role.Can(scope) {
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("erro ao gerar chave: %w", err)
+	}
+	plain := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plain[:len(apiKeyPrefix)+6],
+		KeyHash:   hashToken(plain),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.APIKeyRepo.Create(key); err != nil {
+		return "", nil, fmt.Errorf("erro ao salvar chave de API: %w", err)
+	}
+	return plain, key, nil
+}
+
+// ListarAPIKeys retorna as chaves de API do usuário.
+func (s *AuthService) ListarAPIKeys(userID uint) ([]models.APIKey, error) {
+	return s.APIKeyRepo.ListByUser(userID)
+}
+
+// RevogarAPIKey revoga uma chave de API do usuário.
+func (s *AuthService) RevogarAPIKey(userID, id uint) error {
+	return s.APIKeyRepo.Revoke(userID, id)
+}
+
+// AuthenticateAPIKey valida uma chave de API e retorna o seu dono e as permissões efetivas:
+// os escopos da chave que ainda são concedidos pelo papel atual do usuário.
+func (s *AuthService) AuthenticateAPIKey(plain string) (*models.User, []models.Permission, error) {
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.APIKeyRepo.FindByHash(hashToken(plain))
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.CheckAccount(key.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scopes := make([]models.Permission, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		if user.Role.Can(scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.APIKeyRepo.TouchLastUsed(key.ID, now); err != nil {
+			log.Printf("Erro ao registrar uso da chave de API %d: %v", key.ID, err)
+		}
+	}
+
+	return user, scopes, nil
+}
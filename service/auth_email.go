@@ -0,0 +1,268 @@
+package service
+
+import (
+	"books_api/mail"
+	"books_api/models"
+	"books_api/passpolicy"
+	"books_api/repository"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	verifyEmailTTL   = 24 * time.Hour
+	resetPasswordTTL = time.Hour
+	mailSendTimeout  = 15 * time.Second
+)
+
+var (
+	ErrEmailNotSet          = errors.New("nenhum e-mail cadastrado")
+	ErrEmailAlreadyVerified = errors.New("e-mail já verificado")
+)
+
+// VerificarEmail confirma o e-mail do usuário a partir do token enviado por e-mail.
+func (s *AuthService) VerificarEmail(token string) error {
+	userToken, err := s.TokenRepo.Consume(hashToken(token), models.TokenPurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+	return s.UserRepo.MarkEmailVerified(userToken.UserID, userToken.Email)
+}
+
+// ReenviarVerificacao envia um novo link de verificação para o e-mail do usuário.
+func (s *AuthService) ReenviarVerificacao(userID uint) error {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return ErrEmailNotSet
+	}
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+	return s.sendVerification(user)
+}
+
+// EsqueciSenha envia, em segundo plano, um link de redefinição de senha para o e-mail informado.
+// A busca do usuário e o envio não atrasam a resposta, e suas falhas só são registradas no log, para
+// que nem o tempo nem o resultado da requisição revelem quais e-mails estão cadastrados.
+func (s *AuthService) EsqueciSenha(email string) {
+	go func() {
+		user, err := s.UserRepo.FindByEmail(strings.TrimSpace(email))
+		if err != nil {
+			if !errors.Is(err, repository.ErrUserNotFound) {
+				log.Printf("Erro ao buscar usuário para redefinição de senha: %v", err)
+			}
+			return
+		}
+		if user.Disabled {
+			return
+		}
+		if err := s.sendPasswordReset(user); err != nil {
+			log.Printf("Erro ao enviar redefinição de senha para o usuário %d: %v", user.ID, err)
+		}
+	}()
+}
+
+// ForcarRedefinicaoSenha obriga o usuário a trocar a senha: as sessões abertas são revogadas, os
+// próximos acessos só permitem a troca de senha e um link de redefinição é enviado ao seu e-mail.
+// A senha atual continua válida para entrar e trocá-la.
+func (s *AuthService) ForcarRedefinicaoSenha(id uint) error {
+	user, err := s.UserRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return ErrEmailNotSet
+	}
+
+	if err := s.UserRepo.RequirePasswordChange(user.ID); err != nil {
+		return err
+	}
+	return s.sendPasswordReset(user)
+}
+
+// ValidarTokenRedefinicao confere se o token de redefinição de senha ainda pode ser usado, sem consumi-lo.
+func (s *AuthService) ValidarTokenRedefinicao(token string) error {
+	_, err := s.TokenRepo.Find(hashToken(token), models.TokenPurposeResetPassword)
+	return err
+}
+
+// RedefinirSenha define uma nova senha a partir de um token de redefinição válido.
+// O token só pode ser usado uma vez e todas as sessões do usuário são revogadas.
+func (s *AuthService) RedefinirSenha(token, novaSenha string) error {
+	pending, err := s.TokenRepo.Find(hashToken(token), models.TokenPurposeResetPassword)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.UserRepo.FindByID(pending.UserID)
+	if err != nil {
+		return err
+	}
+
+	// A senha é validada antes de consumir o token, para que o usuário possa tentar outra.
+	if err := passpolicy.Check("nova_senha", novaSenha, user.Username); err != nil {
+		return err
+	}
+
+	if _, err := s.TokenRepo.Consume(hashToken(token), models.TokenPurposeResetPassword); err != nil {
+		return err
+	}
+
+	user.Password = novaSenha
+	if err := user.HashPassword(); err != nil {
+		return fmt.Errorf("erro ao gerar hash da senha: %w", err)
+	}
+	return s.UserRepo.UpdatePassword(user.ID, user.Password, false)
+}
+
+// sendPasswordReset gera um token de redefinição de senha e envia o link para o e-mail do usuário.
+func (s *AuthService) sendPasswordReset(user *models.User) error {
+	token, err := s.issueToken(user, models.TokenPurposeResetPassword, resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+
+	link, err := s.passwordResetLink(token)
+	if err != nil {
+		return err
+	}
+	return s.sendMail(user, mail.TemplateResetPassword, token, link, resetPasswordTTL)
+}
+
+// passwordResetLink monta o link de redefinição de senha, na página do frontend quando configurada.
+func (s *AuthService) passwordResetLink(token string) (string, error) {
+	if s.PasswordResetURL == "" {
+		return s.BaseURL + "/auth/redefinir-senha?token=" + url.QueryEscape(token), nil
+	}
+
+	link, err := url.Parse(s.PasswordResetURL)
+	if err != nil {
+		return "", fmt.Errorf("PASSWORD_RESET_URL inválida: %w", err)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+// LimparTokens remove periodicamente os tokens de verificação e de redefinição expirados ou já
+// usados, até que ctx seja cancelado.
+func (s *AuthService) LimparTokens(ctx context.Context) {
+	if s.TokenCleanupInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.TokenCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.TokenRepo.DeleteExpired(); err != nil {
+				log.Printf("Erro ao remover tokens expirados: %v", err)
+			}
+		}
+	}
+}
+
+// sendVerification gera um token de verificação e envia o link para o e-mail do usuário.
+func (s *AuthService) sendVerification(user *models.User) error {
+	token, err := s.issueToken(user, models.TokenPurposeVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+
+	link := s.BaseURL + "/auth/verificar-email?token=" + url.QueryEscape(token)
+	return s.sendMail(user, mail.TemplateVerifyEmail, token, link, verifyEmailTTL)
+}
+
+// issueToken cria um token de uso único, armazenando apenas o seu hash.
+func (s *AuthService) issueToken(user *models.User, purpose string, ttl time.Duration) (string, error) {
+	token, err := newOneTimeToken()
+	if err != nil {
+		return "", fmt.Errorf("erro ao gerar token: %w", err)
+	}
+
+	err = s.TokenRepo.Replace(&models.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", fmt.Errorf("erro ao salvar token: %w", err)
+	}
+	return token, nil
+}
+
+// sendMail monta a mensagem no idioma do usuário e a envia.
+func (s *AuthService) sendMail(user *models.User, template, token, link string, ttl time.Duration) error {
+	name := user.DisplayName
+	if name == "" {
+		name = user.Username
+	}
+
+	msg, err := mail.Render(user.Language, template, user.Email, map[string]string{
+		"Name":    name,
+		"Token":   token,
+		"Link":    link,
+		"Expires": formatTTL(user.Language, ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+
+	if err := s.Mailer.Send(ctx, msg); err != nil {
+		return err
+	}
+	log.Printf("E-mail %q enviado para o usuário %d", template, user.ID)
+	return nil
+}
+
+// formatTTL descreve a validade de um link no idioma do usuário.
+func formatTTL(lang string, ttl time.Duration) string {
+	hours := int(ttl.Hours())
+	if mail.NormalizeLanguage(lang) == "en" {
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+	if hours == 1 {
+		return "1 hora"
+	}
+	return fmt.Sprintf("%d horas", hours)
+}
+
+// newOneTimeToken gera um token aleatório de 256 bits.
+func newOneTimeToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken calcula o hash SHA-256 de um token para armazenamento e busca.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"books_api/models"
+	"books_api/totp"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	mfaTokenTTL       = 5 * time.Minute
+	mfaTokenPurpose   = "mfa"
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrInvalidMFAToken    = errors.New("token de 2FA inválido ou expirado")
+	ErrInvalidMFACode     = errors.New("código de verificação inválido")
+	ErrMFANotStarted      = errors.New("ativação de 2FA não iniciada")
+	ErrMFAAlreadyEnabled  = errors.New("2FA já está ativado")
+	ErrMFANotEnabled      = errors.New("2FA não está ativado")
+	recoveryCodeEncoding  = base32.StdEncoding.WithPadding(base32.NoPadding)
+	defaultMFARequiredFor = "admin,editor"
+)
+
+// MFAEnrollment contém os dados para cadastrar o segredo TOTP em um aplicativo autenticador.
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// parseMFARequiredRoles lê de MFA_REQUIRED_ROLES os papéis obrigados a usar 2FA.
+// Por padrão, administradores e editores; "none" desativa a exigência.
+func parseMFARequiredRoles() map[models.Role]bool {
+	value, ok := os.LookupEnv("MFA_REQUIRED_ROLES")
+	if !ok {
+		value = defaultMFARequiredFor
+	}
+
+	roles := make(map[models.Role]bool)
+	for _, r := range strings.Split(value, ",") {
+		role := models.Role(strings.TrimSpace(r))
+		if role.Valid() {
+			roles[role] = true
+		}
+	}
+	return roles
+}
+
+// mfaPending informa se o usuário tem um papel que exige 2FA, mas ainda não o ativou.
+func (s *AuthService) mfaPending(user *models.User) bool {
+	return s.MFARequiredRoles[user.Role] && !user.TOTPEnabled
+}
+
+// IniciarMFA gera um novo segredo TOTP para o usuário. O 2FA só é ativado após a confirmação
+// com um primeiro código válido.
+func (s *AuthService) IniciarMFA(userID uint, senha string) (*MFAEnrollment, error) {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.CheckPassword(senha) {
+		return nil, ErrWrongPassword
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar segredo: %w", err)
+	}
+	if err := s.UserRepo.SetTOTPSecret(user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		issuer = "Books API"
+	}
+	return &MFAEnrollment{Secret: secret, URI: totp.ProvisioningURI(issuer, user.Username, secret)}, nil
+}
+
+// ConfirmarMFA ativa o 2FA após validar o primeiro código e retorna os códigos de recuperação,
+// que são exibidos apenas uma vez.
+func (s *AuthService) ConfirmarMFA(userID uint, code string) ([]string, error) {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotStarted
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.UserRepo.EnableTOTP(user.ID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DesativarMFA desativa o 2FA mediante confirmação da senha e de um código válido.
+func (s *AuthService) DesativarMFA(userID uint, senha, code string) error {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.CheckPassword(senha) {
+		return ErrWrongPassword
+	}
+	if !user.TOTPEnabled {
+		return ErrMFANotEnabled
+	}
+	if ok, err := s.verifyMFACode(user, code); err != nil {
+		return err
+	} else if !ok {
+		return ErrInvalidMFACode
+	}
+	return s.UserRepo.DisableTOTP(user.ID)
+}
+
+// RedefinirMFA desativa o 2FA de um usuário sem exigir código. Usado por administradores
+// quando o usuário perde acesso ao aplicativo autenticador e aos códigos de recuperação.
+func (s *AuthService) RedefinirMFA(userID uint) error {
+	if _, err := s.UserRepo.FindByID(userID); err != nil {
+		return err
+	}
+	return s.UserRepo.DisableTOTP(userID)
+}
+
+// GerarCodigosRecuperacao substitui os códigos de recuperação após validar um código TOTP.
+func (s *AuthService) GerarCodigosRecuperacao(userID uint, code string) ([]string, error) {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	if advanced, err := s.UserRepo.AdvanceTOTPStep(user.ID, step); err != nil {
+		return nil, err
+	} else if !advanced {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.UserRepo.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// CompletarLoginMFA troca o token de 2FA pendente, emitido por Authenticate, por um token de acesso
+// após validar um código TOTP ou de recuperação.
+func (s *AuthService) CompletarLoginMFA(mfaToken, code string, info LoginInfo) (*LoginResult, error) {
+	claims, err := s.parseToken(mfaToken)
+	if err != nil || claims.Purpose != mfaTokenPurpose {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.UserRepo.FindByID(claims.Sub)
+	if err != nil || !user.TOTPEnabled {
+		return nil, ErrInvalidMFAToken
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	if retry := s.LoginGuard.Check(user.Username, info.IP); retry > 0 {
+		s.recordLogin(user, user.Username, info, false, "bloqueado")
+		return nil, &LockedError{RetryAfter: retry}
+	}
+
+	ok, err := s.verifyMFACode(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, s.loginFailed(user, user.Username, info, "código 2FA inválido", ErrInvalidMFACode)
+	}
+
+	return s.completeLogin(user, info)
+}
+
+// issueMFAToken emite um token de curta duração que comprova apenas a validação da senha.
+// Ele não é aceito pelo AuthMiddleware.
+func (s *AuthService) issueMFAToken(user *models.User) (string, error) {
+	now := time.Now()
+	return s.signToken(jwt.MapClaims{
+		"sub":     user.ID,
+		"purpose": mfaTokenPurpose,
+		"exp":     now.Add(mfaTokenTTL).Unix(),
+		"iat":     now.Unix(),
+	})
+}
+
+// verifyMFACode aceita um código TOTP ainda não utilizado ou um código de recuperação válido.
+func (s *AuthService) verifyMFACode(user *models.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep); ok {
+		return s.UserRepo.AdvanceTOTPStep(user.ID, step)
+	}
+	return s.UserRepo.ConsumeRecoveryCode(user.ID, hashToken(normalizeRecoveryCode(code)))
+}
+
+// generateRecoveryCodes gera os códigos de recuperação e seus hashes.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("erro ao gerar códigos de recuperação: %w", err)
+		}
+		raw := recoveryCodeEncoding.EncodeToString(b)
+		codes[i] = strings.ToLower(raw[:4] + "-" + raw[4:])
+		hashes[i] = hashToken(raw)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode remove separadores e padroniza maiúsculas antes do cálculo do hash.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
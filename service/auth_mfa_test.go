@@ -0,0 +1,35 @@
+package service
+
+import (
+	"books_api/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Len(t, hashes, recoveryCodeCount)
+
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}$`, code)
+		assert.False(t, seen[code], "duplicate recovery code %s", code)
+		seen[code] = true
+
+		// Codes are accepted regardless of case and separators.
+		assert.Equal(t, hashes[i], hashToken(normalizeRecoveryCode(code)))
+		assert.Equal(t, hashes[i], hashToken(normalizeRecoveryCode(" "+code[:4]+code[5:]+" ")))
+	}
+}
+
+func TestParseMFARequiredRoles(t *testing.T) {
+	t.Setenv("MFA_REQUIRED_ROLES", "admin, invalid")
+	assert.Equal(t, map[models.Role]bool{models.RoleAdmin: true}, parseMFARequiredRoles())
+
+	t.Setenv("MFA_REQUIRED_ROLES", "none")
+	assert.Empty(t, parseMFARequiredRoles())
+}
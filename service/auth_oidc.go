@@ -0,0 +1,283 @@
+package service
+
+import (
+	"books_api/mail"
+	"books_api/models"
+	"books_api/oidc"
+	"books_api/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	oidcFlowTTL     = 10 * time.Minute
+	oidcFlowPurpose = "oidc"
+)
+
+var (
+	ErrOIDCInvalidFlow    = errors.New("sessão de login externo inválida ou expirada")
+	ErrOIDCEmailConflict  = errors.New("já existe uma conta com este e-mail; confirme o e-mail dessa conta para vinculá-la ao login externo")
+	ErrOIDCSignupDisabled = errors.New("nenhuma conta vinculada a este login externo")
+
+	invalidUsernameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+)
+
+// oidcFlowClaims guardam o estado de um login externo em andamento entre o redirecionamento ao
+// provedor e o retorno no callback.
+type oidcFlowClaims struct {
+	Purpose  string `json:"purpose"`
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	jwt.RegisteredClaims
+}
+
+// OIDCProviderNames retorna os nomes dos provedores de identidade configurados.
+func (s *AuthService) OIDCProviderNames() []string {
+	names := make([]string, 0, len(s.OIDCProviders))
+	for name := range s.OIDCProviders {
+		names = append(names, name)
+	}
+	return names
+}
+
+// IniciarLoginOIDC gera a URL de autorização do provedor e o token que guarda state, nonce e o
+// verificador PKCE. O token deve ser devolvido no callback (em cookie) e não é aceito pelo AuthMiddleware.
+func (s *AuthService) IniciarLoginOIDC(ctx context.Context, providerName string) (string, string, error) {
+	provider, ok := s.OIDCProviders[providerName]
+	if !ok {
+		return "", "", oidc.ErrProviderNotFound
+	}
+
+	values := make([]string, 3)
+	for i := range values {
+		v, err := oidc.RandomString()
+		if err != nil {
+			return "", "", err
+		}
+		values[i] = v
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	flowToken, err := s.signToken(jwt.MapClaims{
+		"purpose":  oidcFlowPurpose,
+		"provider": providerName,
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+		"exp":      now.Add(oidcFlowTTL).Unix(),
+		"iat":      now.Unix(),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, flowToken, nil
+}
+
+// CompletarLoginOIDC valida o retorno do provedor, troca o código pelo id_token e autentica o usuário
+// vinculado, vinculando uma conta existente pelo e-mail verificado ou criando uma nova se permitido.
+// Contas com 2FA ainda precisam confirmar o código em CompletarLoginMFA.
+func (s *AuthService) CompletarLoginOIDC(ctx context.Context, providerName, code, state, flowToken string, info LoginInfo) (*LoginResult, error) {
+	provider, ok := s.OIDCProviders[providerName]
+	if !ok {
+		return nil, oidc.ErrProviderNotFound
+	}
+
+	flow := &oidcFlowClaims{}
+	if err := s.VerifyToken(flowToken, flow); err != nil || flow.Purpose != oidcFlowPurpose ||
+		flow.Provider != providerName || state == "" || flow.State != state {
+		return nil, ErrOIDCInvalidFlow
+	}
+	if code == "" {
+		return nil, ErrOIDCInvalidFlow
+	}
+
+	claims, err := provider.Exchange(ctx, code, flow.Verifier, flow.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.resolveOIDCUser(provider, claims)
+	if err != nil {
+		s.recordLogin(nil, providerName+":"+claims.Email, info, false, err.Error())
+		return nil, err
+	}
+
+	if user.Disabled {
+		s.recordLogin(user, user.Username, info, false, "usuário desativado")
+		return nil, ErrUserDisabled
+	}
+
+	if user.TOTPEnabled {
+		mfaToken, err := s.issueMFAToken(user)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+
+	return s.completeLogin(user, info)
+}
+
+// IdentidadesExternas retorna os logins externos vinculados ao usuário.
+func (s *AuthService) IdentidadesExternas(userID uint) ([]models.ExternalIdentity, error) {
+	if s.IdentityRepo == nil {
+		return []models.ExternalIdentity{}, nil
+	}
+	return s.IdentityRepo.ListByUser(userID)
+}
+
+// resolveOIDCUser encontra o usuário da identidade externa. Uma conta local só é vinculada quando
+// o e-mail foi verificado tanto pelo provedor quanto na própria conta, evitando que alguém cadastre
+// previamente o e-mail de outra pessoa para assumir seu login externo.
+func (s *AuthService) resolveOIDCUser(provider *oidc.Provider, claims *oidc.Claims) (*models.User, error) {
+	identity, err := s.IdentityRepo.Find(provider.Name, claims.Subject)
+	if err == nil {
+		return s.UserRepo.FindByID(identity.UserID)
+	}
+	if !errors.Is(err, repository.ErrIdentityNotFound) {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(claims.Email)
+	link := &models.ExternalIdentity{Provider: provider.Name, Subject: claims.Subject, Email: email}
+
+	if email != "" && claims.EmailVerified {
+		existing, err := s.UserRepo.FindByEmail(email)
+		switch {
+		case err == nil && existing.EmailVerified:
+			link.UserID = existing.ID
+			if err := s.IdentityRepo.Create(link); err != nil {
+				return nil, fmt.Errorf("erro ao vincular login externo: %w", err)
+			}
+			log.Printf("Login %s vinculado ao usuário %d pelo e-mail verificado", provider.Name, existing.ID)
+			return existing, nil
+		case err == nil:
+			return nil, ErrOIDCEmailConflict
+		case !errors.Is(err, repository.ErrUserNotFound):
+			return nil, err
+		}
+	} else {
+		// E-mails não verificados pelo provedor não são gravados na conta.
+		email = ""
+	}
+
+	if !provider.AutoCreate {
+		return nil, ErrOIDCSignupDisabled
+	}
+	return s.createOIDCUser(provider, claims, email, link)
+}
+
+// createOIDCUser cria a conta de um usuário no primeiro login externo, com o papel padrão do
+// provedor. A senha é aleatória; o usuário pode definir uma senha própria pelo fluxo de
+// redefinição de senha.
+func (s *AuthService) createOIDCUser(provider *oidc.Provider, claims *oidc.Claims, email string, link *models.ExternalIdentity) (*models.User, error) {
+	username, err := s.availableUsername(oidcUsernameBase(claims))
+	if err != nil {
+		return nil, err
+	}
+	password, err := newOneTimeToken()
+	if err != nil {
+		return nil, err
+	}
+	displayName := strings.TrimSpace(claims.Name)
+	if nome := []rune(displayName); len(nome) > 100 {
+		displayName = string(nome[:100])
+	}
+
+	user := &models.User{
+		Username:      username,
+		Password:      password,
+		DisplayName:   displayName,
+		Email:         email,
+		EmailVerified: email != "",
+		Language:      mail.NormalizeLanguage(""),
+		Role:          oidcDefaultRole(provider),
+	}
+	if err := user.ValidateAccount(); err != nil {
+		return nil, err
+	}
+	// A senha aleatória não passa pela política de senhas.
+	if err := user.HashPassword(); err != nil {
+		return nil, fmt.Errorf("erro ao gerar hash da senha: %w", err)
+	}
+
+	if err := s.IdentityRepo.CreateWithUser(user, link); err != nil {
+		return nil, fmt.Errorf("erro ao criar usuário: %w", err)
+	}
+	log.Printf("Usuário %s criado pelo login %s", user.Username, link.Provider)
+	return user, nil
+}
+
+// oidcDefaultRole retorna o papel configurado para os usuários criados pelo provedor. O nome de
+// usuário escolhido no provedor não é considerado: ADMIN_USERNAME só vale para contas locais.
+func oidcDefaultRole(provider *oidc.Provider) models.Role {
+	role := models.Role(provider.DefaultRole)
+	if role == "" {
+		return models.RoleReader
+	}
+	if !role.Valid() {
+		log.Printf("Papel padrão inválido para o provedor OIDC %s: %q, usando %s", provider.Name, role, models.RoleReader)
+		return models.RoleReader
+	}
+	return role
+}
+
+// availableUsername retorna o nome base ou, se já estiver em uso, o nome com um sufixo numérico.
+func (s *AuthService) availableUsername(base string) (string, error) {
+	for i := 1; i <= 100; i++ {
+		candidate := base
+		if i > 1 {
+			candidate = base + strconv.Itoa(i)
+		}
+		_, err := s.UserRepo.FindByUsername(candidate)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	suffix, err := newOneTimeToken()
+	if err != nil {
+		return "", err
+	}
+	return base + "_" + strings.ToLower(invalidUsernameChars.ReplaceAllString(suffix, ""))[:6], nil
+}
+
+// oidcUsernameBase deriva um nome de usuário válido do preferred_username ou do e-mail.
+func oidcUsernameBase(claims *oidc.Claims) string {
+	base := claims.PreferredUsername
+	if at := strings.Index(base, "@"); at > 0 {
+		base = base[:at]
+	}
+	if base == "" {
+		if at := strings.Index(claims.Email, "@"); at > 0 {
+			base = claims.Email[:at]
+		}
+	}
+
+	base = strings.Trim(invalidUsernameChars.ReplaceAllString(base, "_"), "_.-")
+	if len(base) > 20 {
+		base = base[:20]
+	}
+	if len(base) < 3 {
+		base = "usuario"
+	}
+	return base
+}
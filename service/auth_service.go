@@ -1,11 +1,18 @@
 package service
 
 import (
+	"books_api/mail"
 	"books_api/models"
+	"books_api/oidc"
+	"books_api/passhash"
+	"books_api/passpolicy"
 	"books_api/repository"
+	"books_api/signing"
 	"errors"
 	"fmt"
+	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -13,56 +20,288 @@ import (
 )
 
 var (
-	ErrMissingCredentials   = errors.New("nome de usuário e senha são obrigatórios")
-	ErrInvalidCredentials   = errors.New("usuário ou senha inválidos")
-	ErrJWTSecretNotProvided = errors.New("JWT_SECRET não configurado")
+	ErrMissingCredentials = errors.New("nome de usuário e senha são obrigatórios")
+	ErrInvalidCredentials = errors.New("usuário ou senha inválidos")
+	ErrUserDisabled       = errors.New("usuário desativado")
+	ErrWrongPassword      = errors.New("senha atual incorreta")
+	ErrLastAdmin          = errors.New("não é possível remover o último administrador")
 )
 
 type AuthService struct {
-	UserRepo *repository.UserRepository
+	Keys       *signing.KeyManager
+	UserRepo   *repository.UserRepository
+	TokenRepo  *repository.UserTokenRepository
+	APIKeyRepo *repository.APIKeyRepository
+	Mailer     mail.Mailer
+	// LoginGuard e LoginRepo são opcionais: sem eles não há bloqueio nem histórico de acessos.
+	LoginGuard *LoginGuard
+	LoginRepo  *repository.LoginAttemptRepository
+	// BaseURL é o endereço público da API, usado nos links enviados por e-mail.
+	BaseURL string
+	// PasswordResetURL é a página do frontend que recebe o token de redefinição de senha. Vazia,
+	// o link aponta para GET /auth/redefinir-senha na própria API.
+	PasswordResetURL string
+	// TokenCleanupInterval é a frequência com que os tokens expirados ou usados são removidos.
+	TokenCleanupInterval time.Duration
+	// MFARequiredRoles são os papéis que só têm suas permissões após ativar o 2FA.
+	MFARequiredRoles map[models.Role]bool
+	// OIDCProviders são os provedores de login externo, indexados pelo nome usado nas rotas.
+	// IdentityRepo é obrigatório quando há provedores configurados.
+	OIDCProviders map[string]*oidc.Provider
+	IdentityRepo  *repository.ExternalIdentityRepository
 }
 
-func NewAuthService(userRepo *repository.UserRepository) *AuthService {
-	return &AuthService{UserRepo: userRepo}
+func NewAuthService(keys *signing.KeyManager, userRepo *repository.UserRepository, tokenRepo *repository.UserTokenRepository, apiKeyRepo *repository.APIKeyRepository, mailer mail.Mailer) *AuthService {
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	return &AuthService{
+		Keys:       keys,
+		UserRepo:   userRepo,
+		TokenRepo:  tokenRepo,
+		APIKeyRepo: apiKeyRepo,
+		Mailer:     mailer,
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+
+		PasswordResetURL:     os.Getenv("PASSWORD_RESET_URL"),
+		TokenCleanupInterval: envDuration("TOKEN_CLEANUP_INTERVAL", time.Hour),
+		MFARequiredRoles:     parseMFARequiredRoles(),
+	}
+}
+
+// LoginInfo identifica a origem de uma tentativa de login.
+type LoginInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// LoginResult é o resultado de um login. Quando o usuário possui 2FA, Token fica vazio e
+// MFAToken deve ser trocado por um token de acesso em CompletarLoginMFA. Com
+// PasswordChangeRequired, o token só dá acesso à troca de senha.
+type LoginResult struct {
+	Token                  string
+	MFARequired            bool
+	MFAToken               string
+	MFAEnrollmentRequired  bool
+	PasswordChangeRequired bool
+}
+
+// tokenClaims são as claims lidas dos tokens emitidos pelo serviço.
+type tokenClaims struct {
+	Sub     uint   `json:"sub"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
 }
 
-// Authenticate realiza a autenticação do usuário e gera um token JWT.
-func (s *AuthService) Authenticate(username, password string) (string, error) {
+// Authenticate valida usuário e senha. Sem 2FA, o token de acesso é emitido imediatamente;
+// com 2FA, é emitido um token temporário que deve ser confirmado com um código.
+// Tentativas malsucedidas são contabilizadas e podem bloquear temporariamente o usuário ou o IP.
+func (s *AuthService) Authenticate(username, password string, info LoginInfo) (*LoginResult, error) {
 	if username == "" || password == "" {
-		return "", ErrMissingCredentials
+		return nil, ErrMissingCredentials
+	}
+
+	if retry := s.LoginGuard.Check(username, info.IP); retry > 0 {
+		s.recordLogin(nil, username, info, false, "bloqueado")
+		return nil, &LockedError{RetryAfter: retry}
 	}
 
 	user, err := s.UserRepo.FindByUsername(username)
 	if err != nil {
-		return "", ErrInvalidCredentials
+		return nil, s.loginFailed(nil, username, info, "usuário inexistente", ErrInvalidCredentials)
 	}
 
 	if !user.CheckPassword(password) {
-		return "", ErrInvalidCredentials
+		return nil, s.loginFailed(user, username, info, "senha incorreta", ErrInvalidCredentials)
 	}
+	s.rehashPassword(user, password)
 
-	now := time.Now()
-	claims := jwt.MapClaims{
-		"sub": user.ID,
-		"exp": now.Add(24 * time.Hour).Unix(),
-		"iat": now.Unix(),
-		"jti": uuid.NewString(),
+	if user.Disabled {
+		s.recordLogin(user, username, info, false, "usuário desativado")
+		return nil, ErrUserDisabled
+	}
+
+	if user.TOTPEnabled {
+		mfaToken, err := s.issueMFAToken(user)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+
+	return s.completeLogin(user, info)
+}
+
+// completeLogin zera as falhas de login, registra o acesso e emite o token de acesso.
+func (s *AuthService) completeLogin(user *models.User, info LoginInfo) (*LoginResult, error) {
+	s.LoginGuard.RegisterSuccess(user.Username)
+	s.recordLogin(user, user.Username, info, true, "")
+
+	token, err := s.generateToken(user)
+	if err != nil {
+		return nil, err
 	}
+	return &LoginResult{
+		Token:                  token,
+		MFAEnrollmentRequired:  s.mfaPending(user),
+		PasswordChangeRequired: user.MustChangePassword,
+	}, nil
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "", ErrJWTSecretNotProvided
+// rehashPassword regrava o hash da senha quando ele usa um algoritmo ou parâmetros desatualizados.
+// Só é possível no login, quando a senha em texto puro está disponível. Falhas apenas são registradas.
+func (s *AuthService) rehashPassword(user *models.User, password string) {
+	if !user.PasswordNeedsRehash() {
+		return
 	}
-	tokenString, err := token.SignedString([]byte(secret))
+
+	hash, err := passhash.Hash(password)
 	if err != nil {
-		return "", fmt.Errorf("erro ao assinar token: %w", err)
+		log.Printf("Erro ao atualizar hash da senha do usuário %d: %v", user.ID, err)
+		return
+	}
+	if err := s.UserRepo.ReplacePasswordHash(user.ID, user.Password, hash); err != nil {
+		log.Printf("Erro ao atualizar hash da senha do usuário %d: %v", user.ID, err)
+		return
+	}
+	user.Password = hash
+}
+
+// loginFailed registra uma falha de login e retorna o erro a ser apresentado ao cliente.
+func (s *AuthService) loginFailed(user *models.User, username string, info LoginInfo, reason string, failure error) error {
+	s.recordLogin(user, username, info, false, reason)
+	if locked := s.LoginGuard.RegisterFailure(username, info.IP); locked > 0 {
+		return &LockedError{RetryAfter: locked}
+	}
+	return failure
+}
+
+// recordLogin grava a tentativa de login no histórico de acessos.
+func (s *AuthService) recordLogin(user *models.User, username string, info LoginInfo, success bool, reason string) {
+	if s.LoginRepo == nil {
+		return
 	}
 
+	attempt := &models.LoginAttempt{
+		Username:  username,
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+		Success:   success,
+		Reason:    reason,
+	}
+	if user != nil {
+		attempt.UserID = &user.ID
+	}
+	if err := s.LoginRepo.Create(attempt); err != nil {
+		log.Printf("Erro ao registrar tentativa de login: %v", err)
+	}
+}
+
+// HistoricoAcessos retorna as tentativas de login mais recentes do usuário.
+func (s *AuthService) HistoricoAcessos(userID uint) ([]models.LoginAttempt, error) {
+	if s.LoginRepo == nil {
+		return []models.LoginAttempt{}, nil
+	}
+	return s.LoginRepo.ListByUser(userID, 20)
+}
+
+// DesbloquearLogin remove o bloqueio de login de um usuário e dos IPs de onde partiram as suas
+// tentativas malsucedidas recentes, que de outro modo continuariam impedindo o acesso.
+func (s *AuthService) DesbloquearLogin(userID uint) error {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	ips, err := s.recentFailureIPs(user.ID)
+	if err != nil {
+		return err
+	}
+	s.LoginGuard.Unlock(user.Username, ips...)
+	return nil
+}
+
+// recentFailureIPs retorna os IPs das tentativas de login malsucedidas do usuário dentro da janela
+// de contagem do LoginGuard.
+func (s *AuthService) recentFailureIPs(userID uint) ([]string, error) {
+	if s.LoginRepo == nil || s.LoginGuard == nil {
+		return nil, nil
+	}
+	attempts, err := s.LoginRepo.ListByUser(userID, 100)
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Now().Add(-s.LoginGuard.Window)
+	seen := make(map[string]bool)
+	var ips []string
+	for _, attempt := range attempts {
+		if attempt.Success || attempt.IP == "" || attempt.CreatedAt.Before(since) || seen[attempt.IP] {
+			continue
+		}
+		seen[attempt.IP] = true
+		ips = append(ips, attempt.IP)
+	}
+	return ips, nil
+}
+
+// generateToken emite um token JWT para o usuário, vinculado à sua versão de sessão atual.
+func (s *AuthService) generateToken(user *models.User) (string, error) {
+	now := time.Now()
+	return s.signToken(jwt.MapClaims{
+		"sub":  user.ID,
+		"role": user.Role,
+		"ver":  user.SessionVersion,
+		"exp":  now.Add(24 * time.Hour).Unix(),
+		"iat":  now.Unix(),
+		"jti":  uuid.NewString(),
+	})
+}
+
+// signToken assina as claims com a chave ativa.
+func (s *AuthService) signToken(claims jwt.MapClaims) (string, error) {
+	tokenString, err := s.Keys.Sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("erro ao assinar token: %w", err)
+	}
 	return tokenString, nil
 }
 
-func (s *AuthService) Register(username, password string) error {
+// parseToken valida a assinatura, a validade, o emissor e a audiência de um token emitido pelo serviço.
+func (s *AuthService) parseToken(tokenString string) (*tokenClaims, error) {
+	claims := &tokenClaims{}
+	if err := s.VerifyToken(tokenString, claims); err != nil {
+		return nil, fmt.Errorf("token inválido: %w", err)
+	}
+	return claims, nil
+}
+
+// VerifyToken valida um token emitido pelo serviço e preenche as claims informadas.
+func (s *AuthService) VerifyToken(tokenString string, claims jwt.Claims) error {
+	return s.Keys.Parse(tokenString, claims)
+}
+
+// CheckAccount confirma que o usuário de um token ainda existe e está ativo.
+// Usuários cujo papel exige 2FA, mas que ainda não o ativaram, recebem apenas as
+// permissões de leitor até concluírem a ativação.
+func (s *AuthService) CheckAccount(userID uint) (*models.User, error) {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	if s.mfaPending(user) {
+		user.Role = models.RoleReader
+	}
+	return user, nil
+}
+
+// Register cria um novo usuário. Se um e-mail for informado, um link de verificação é enviado
+// no idioma indicado.
+func (s *AuthService) Register(username, password, email, lang string) error {
 	if username == "" || password == "" {
 		return ErrMissingCredentials
 	}
@@ -70,14 +309,145 @@ func (s *AuthService) Register(username, password string) error {
 	user := &models.User{
 		Username: username,
 		Password: password,
+		Email:    strings.TrimSpace(email),
+		Language: mail.NormalizeLanguage(lang),
+		Role:     s.initialRole(username),
+	}
+	if err := user.Validate(); err != nil {
+		return err
+	}
+	if err := user.HashPassword(); err != nil {
+		return fmt.Errorf("erro ao gerar hash da senha: %w", err)
 	}
 
-	if err := s.UserRepo.Create(user); err != nil {
-		if errors.Is(err, repository.ErrUsernameInUse) {
-			return repository.ErrUsernameInUse
+	if err := s.UserRepo.CreateFirstAdmin(user); err != nil {
+		if errors.Is(err, repository.ErrUsernameInUse) || errors.Is(err, repository.ErrEmailInUse) {
+			return err
 		}
 		return fmt.Errorf("erro ao criar usuário: %w", err)
 	}
 
+	if user.Email != "" {
+		if err := s.sendVerification(user); err != nil {
+			log.Printf("Erro ao enviar e-mail de verificação para o usuário %d: %v", user.ID, err)
+		}
+	}
+
 	return nil
 }
+
+// initialRole define o papel de um novo usuário. O usuário indicado em ADMIN_USERNAME torna-se
+// administrador; o primeiro usuário cadastrado também, pelo UserRepository.CreateFirstAdmin.
+func (s *AuthService) initialRole(username string) models.Role {
+	if admin := os.Getenv("ADMIN_USERNAME"); admin != "" && strings.EqualFold(admin, username) {
+		return models.RoleAdmin
+	}
+	return models.RoleReader
+}
+
+// BootstrapAdmin promove a administrador o usuário indicado em ADMIN_USERNAME,
+// caso ele já exista. Útil para bases criadas antes da existência de papéis.
+func (s *AuthService) BootstrapAdmin() {
+	admin := os.Getenv("ADMIN_USERNAME")
+	if admin == "" {
+		return
+	}
+
+	user, err := s.UserRepo.FindByUsername(admin)
+	if err != nil || user.Role == models.RoleAdmin {
+		return
+	}
+
+	if err := s.UserRepo.UpdateRole(user.ID, models.RoleAdmin); err != nil {
+		log.Printf("Erro ao promover %s a administrador: %v", admin, err)
+		return
+	}
+	log.Printf("Usuário %s promovido a administrador", admin)
+}
+
+// Perfil retorna os dados da conta do próprio usuário.
+func (s *AuthService) Perfil(userID uint) (*models.User, error) {
+	return s.UserRepo.FindByID(userID)
+}
+
+// AtualizarPerfil altera o nome de exibição e o e-mail do próprio usuário.
+func (s *AuthService) AtualizarPerfil(userID uint, displayName, email string) (*models.User, error) {
+	displayName = strings.TrimSpace(displayName)
+	email = strings.TrimSpace(email)
+	if err := models.ValidateProfile(displayName, email); err != nil {
+		return nil, err
+	}
+
+	previous, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.UserRepo.UpdateProfile(userID, displayName, email); err != nil {
+		return nil, err
+	}
+
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Email != "" && !strings.EqualFold(previous.Email, user.Email) {
+		if err := s.sendVerification(user); err != nil {
+			log.Printf("Erro ao enviar e-mail de verificação para o usuário %d: %v", user.ID, err)
+		}
+	}
+	return user, nil
+}
+
+// AlterarSenha troca a senha do próprio usuário após conferir a senha atual.
+// As demais sessões são revogadas e um novo token é emitido para a sessão corrente.
+func (s *AuthService) AlterarSenha(userID uint, senhaAtual, novaSenha string) (string, error) {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if !user.CheckPassword(senhaAtual) {
+		return "", ErrWrongPassword
+	}
+	if err := passpolicy.Check("nova_senha", novaSenha, user.Username); err != nil {
+		return "", err
+	}
+
+	user.Password = novaSenha
+	if err := user.HashPassword(); err != nil {
+		return "", fmt.Errorf("erro ao gerar hash da senha: %w", err)
+	}
+	if err := s.UserRepo.UpdatePassword(user.ID, user.Password, false); err != nil {
+		return "", err
+	}
+
+	user, err = s.UserRepo.FindByID(userID)
+	if err != nil {
+		return "", err
+	}
+	return s.generateToken(user)
+}
+
+// ExcluirConta remove a conta do próprio usuário mediante confirmação da senha.
+func (s *AuthService) ExcluirConta(userID uint, senha string) error {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.CheckPassword(senha) {
+		return ErrWrongPassword
+	}
+
+	if user.Role == models.RoleAdmin {
+		admins, err := s.UserRepo.CountByRole(models.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		if admins <= 1 {
+			return ErrLastAdmin
+		}
+	}
+
+	return s.UserRepo.Delete(user.ID)
+}
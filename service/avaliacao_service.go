@@ -0,0 +1,99 @@
+package service
+
+import (
+	"books_api/models"
+	"books_api/repository"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotAvaliacaoAutor = errors.New("avaliação pertence a outro usuário")
+	ErrSinalizarPropria  = errors.New("não é possível sinalizar a própria avaliação")
+)
+
+// AvaliacaoService gerencia as avaliações dos livros e a moderação delas.
+type AvaliacaoService struct {
+	Repo *repository.AvaliacaoRepository
+}
+
+func NewAvaliacaoService(repo *repository.AvaliacaoRepository) *AvaliacaoService {
+	return &AvaliacaoService{Repo: repo}
+}
+
+// Avaliar registra a avaliação do livro pelo usuário.
+func (s *AvaliacaoService) Avaliar(livroID, userID uint, nota int, texto string) (*models.Avaliacao, error) {
+	avaliacao := &models.Avaliacao{UserID: userID, LivroID: livroID, Nota: nota, Texto: texto}
+	if err := avaliacao.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Create(avaliacao); err != nil {
+		return nil, err
+	}
+	return s.Repo.FindByID(avaliacao.ID)
+}
+
+// Editar altera a nota e o texto de uma avaliação do próprio usuário.
+func (s *AvaliacaoService) Editar(id, userID uint, nota int, texto string) (*models.Avaliacao, error) {
+	atual, err := s.Repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if atual.UserID != userID {
+		return nil, ErrNotAvaliacaoAutor
+	}
+
+	editada := &models.Avaliacao{Nota: nota, Texto: texto}
+	if err := editada.Validate(); err != nil {
+		return nil, err
+	}
+	return s.Repo.Update(id, editada.Nota, editada.Texto, time.Now())
+}
+
+// Remover exclui a avaliação. Sem a permissão de moderar, o usuário só pode excluir as próprias.
+func (s *AvaliacaoService) Remover(id, userID uint, moderar bool) error {
+	atual, err := s.Repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if !moderar && atual.UserID != userID {
+		return ErrNotAvaliacaoAutor
+	}
+	return s.Repo.Delete(id)
+}
+
+// Sinalizar denuncia a avaliação de outro usuário para a moderação.
+func (s *AvaliacaoService) Sinalizar(id, userID uint, motivo string) error {
+	atual, err := s.Repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if atual.UserID == userID {
+		return ErrSinalizarPropria
+	}
+	return s.Repo.Sinalizar(id, userID, strings.TrimSpace(motivo))
+}
+
+// Ocultar esconde a avaliação das listagens e da média do livro.
+func (s *AvaliacaoService) Ocultar(id uint, motivo string) (*models.Avaliacao, error) {
+	return s.Repo.Moderar(id, true, strings.TrimSpace(motivo))
+}
+
+// Reexibir volta a exibir uma avaliação, descartando as denúncias recebidas.
+func (s *AvaliacaoService) Reexibir(id uint) (*models.Avaliacao, error) {
+	return s.Repo.Moderar(id, false, "")
+}
+
+// ListarAvaliacoes retorna uma página de avaliações e o total encontrado.
+func (s *AvaliacaoService) ListarAvaliacoes(filter repository.AvaliacaoFilter) ([]models.Avaliacao, int64, error) {
+	if !repository.ValidAvaliacaoOrdem(filter.Ordenar) {
+		return nil, 0, ErrInvalidOrdem
+	}
+	avaliacoes, total, err := s.Repo.List(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao listar avaliações: %w", err)
+	}
+	return avaliacoes, total, nil
+}
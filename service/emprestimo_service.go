@@ -0,0 +1,96 @@
+package service
+
+import (
+	"books_api/models"
+	"books_api/repository"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidStatus = errors.New("situação de empréstimo inválida")
+	ErrNotBorrower   = errors.New("empréstimo pertence a outro usuário")
+)
+
+// EmprestimoService controla a retirada e a devolução de livros.
+type EmprestimoService struct {
+	Repo   *repository.EmprestimoRepository
+	Multas *repository.MultaRepository
+	// Politica define as multas registradas na devolução com atraso e o bloqueio de novas retiradas.
+	Politica PoliticaMultas
+	// LimitePorUsuario é a quantidade máxima de empréstimos simultâneos; 0 desativa o limite.
+	LimitePorUsuario int
+	// Prazo é o tempo entre a retirada e a data prevista de devolução.
+	Prazo time.Duration
+}
+
+// NewEmprestimoService cria o serviço lendo EMPRESTIMO_LIMITE (padrão 3), EMPRESTIMO_PRAZO_DIAS (padrão 14)
+// e a política de multas.
+func NewEmprestimoService(repo *repository.EmprestimoRepository, multas *repository.MultaRepository) *EmprestimoService {
+	return &EmprestimoService{
+		Repo:             repo,
+		Multas:           multas,
+		Politica:         PoliticaMultasFromEnv(),
+		LimitePorUsuario: envInt("EMPRESTIMO_LIMITE", 3),
+		Prazo:            time.Duration(envInt("EMPRESTIMO_PRAZO_DIAS", 14)) * 24 * time.Hour,
+	}
+}
+
+// Emprestar registra a retirada de um exemplar do livro pelo usuário, com devolução prevista após
+// o prazo padrão. codigoBarras é opcional e escolhe um exemplar específico.
+func (s *EmprestimoService) Emprestar(livroID, userID uint, codigoBarras string) (*models.Emprestimo, error) {
+	now := time.Now()
+	emprestimo := &models.Emprestimo{
+		UserID:                userID,
+		LivroID:               livroID,
+		DataEmprestimo:        now,
+		DataDevolucaoPrevista: now.Add(s.Prazo),
+	}
+	if err := s.Repo.Checkout(emprestimo, strings.TrimSpace(codigoBarras), s.LimitePorUsuario, s.Politica.LimiteBloqueio); err != nil {
+		return nil, err
+	}
+	return emprestimo, nil
+}
+
+// Devolver registra a devolução e, se houver atraso, o valor definitivo da multa. Sem a permissão de
+// gerenciar empréstimos, o usuário só pode devolver os próprios.
+func (s *EmprestimoService) Devolver(id, userID uint, gerenciar bool) (*models.Emprestimo, error) {
+	emprestimo, err := s.Repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !gerenciar && emprestimo.UserID != userID {
+		return nil, ErrNotBorrower
+	}
+
+	emprestimo, err = s.Repo.Return(id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if s.Multas != nil {
+		dias, valor := s.Politica.Calcular(emprestimo.DataDevolucaoPrevista, *emprestimo.DataDevolucao)
+		if valor > 0 {
+			if err := s.Multas.Registrar(emprestimo, dias, valor); err != nil {
+				return nil, fmt.Errorf("erro ao registrar multa: %w", err)
+			}
+		}
+	}
+	return emprestimo, nil
+}
+
+// ListarEmprestimos retorna uma página de empréstimos e o total encontrado.
+func (s *EmprestimoService) ListarEmprestimos(filter repository.EmprestimoFilter) ([]models.Emprestimo, int64, error) {
+	switch filter.Status {
+	case "", models.EmprestimoAtivo, models.EmprestimoAtrasado, models.EmprestimoDevolvido:
+	default:
+		return nil, 0, ErrInvalidStatus
+	}
+
+	emprestimos, total, err := s.Repo.List(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao listar empréstimos: %w", err)
+	}
+	return emprestimos, total, nil
+}
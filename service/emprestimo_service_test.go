@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"books_api/models"
+	"books_api/repository"
+	"books_api/repository/repotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// novoEmprestimoService monta o serviço sobre um banco de teste, com limite de dois empréstimos por usuário.
+func novoEmprestimoService(t *testing.T) (*EmprestimoService, *gorm.DB) {
+	t.Helper()
+	db := repotest.NewDB(t)
+	s := &EmprestimoService{
+		Repo:             repository.NewEmprestimoRepository(db),
+		Multas:           repository.NewMultaRepository(db),
+		Politica:         PoliticaMultas{TaxaDiaria: 100},
+		LimitePorUsuario: 2,
+		Prazo:            14 * 24 * time.Hour,
+	}
+	return s, db
+}
+
+func criarUsuario(t *testing.T, db *gorm.DB, username string) *models.User {
+	t.Helper()
+	user := &models.User{Username: username, Password: "hash"}
+	require.NoError(t, db.Create(user).Error)
+	return user
+}
+
+// criarLivro cadastra um livro com a quantidade informada de exemplares disponíveis.
+func criarLivro(t *testing.T, db *gorm.DB, titulo string, exemplares int) *models.Livro {
+	t.Helper()
+	livro := &models.Livro{Titulo: titulo, Autor: "Autor"}
+	require.NoError(t, db.Create(livro).Error)
+	for i := 1; i <= exemplares; i++ {
+		require.NoError(t, db.Create(&models.Exemplar{
+			LivroID:      livro.ID,
+			CodigoBarras: fmt.Sprintf("%d-%d", livro.ID, i),
+			Status:       models.ExemplarDisponivel,
+		}).Error)
+	}
+	return livro
+}
+
+func statusExemplar(t *testing.T, db *gorm.DB, id uint) string {
+	t.Helper()
+	var exemplar models.Exemplar
+	require.NoError(t, db.First(&exemplar, id).Error)
+	return exemplar.Status
+}
+
+func TestEmprestarLivroIndisponivel(t *testing.T) {
+	s, db := novoEmprestimoService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 1)
+	leitor := criarUsuario(t, db, "leitor")
+	outro := criarUsuario(t, db, "outro")
+
+	emprestimo, err := s.Emprestar(livro.ID, leitor.ID, "")
+	require.NoError(t, err)
+	require.NotNil(t, emprestimo.ExemplarID)
+	assert.Equal(t, models.ExemplarEmprestado, statusExemplar(t, db, *emprestimo.ExemplarID))
+	assert.WithinDuration(t, emprestimo.DataEmprestimo.Add(s.Prazo), emprestimo.DataDevolucaoPrevista, time.Second)
+
+	_, err = s.Emprestar(livro.ID, outro.ID, "")
+	assert.ErrorIs(t, err, repository.ErrLivroIndisponivel)
+
+	// Sem exemplares cadastrados, o livro também não pode ser emprestado.
+	semExemplares := criarLivro(t, db, "Memórias Póstumas", 0)
+	_, err = s.Emprestar(semExemplares.ID, outro.ID, "")
+	assert.ErrorIs(t, err, repository.ErrLivroIndisponivel)
+
+	_, err = s.Emprestar(9999, outro.ID, "")
+	assert.ErrorIs(t, err, repository.ErrLivroNotFound)
+}
+
+func TestEmprestarPorCodigoDeBarras(t *testing.T) {
+	s, db := novoEmprestimoService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 2)
+	leitor := criarUsuario(t, db, "leitor")
+
+	emprestimo, err := s.Emprestar(livro.ID, leitor.ID, fmt.Sprintf(" %d-2 ", livro.ID))
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%d-2", livro.ID), emprestimo.Exemplar.CodigoBarras)
+
+	_, err = s.Emprestar(livro.ID, leitor.ID, fmt.Sprintf("%d-2", livro.ID))
+	assert.ErrorIs(t, err, repository.ErrLivroIndisponivel)
+}
+
+func TestEmprestarLimitePorUsuario(t *testing.T) {
+	s, db := novoEmprestimoService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 3)
+	leitor := criarUsuario(t, db, "leitor")
+
+	primeiro, err := s.Emprestar(livro.ID, leitor.ID, "")
+	require.NoError(t, err)
+	_, err = s.Emprestar(livro.ID, leitor.ID, "")
+	require.NoError(t, err)
+
+	_, err = s.Emprestar(livro.ID, leitor.ID, "")
+	assert.ErrorIs(t, err, repository.ErrLimiteEmprestimos)
+
+	// A devolução libera uma vaga; o limite zero desativa a verificação.
+	_, err = s.Devolver(primeiro.ID, leitor.ID, false)
+	require.NoError(t, err)
+	_, err = s.Emprestar(livro.ID, leitor.ID, "")
+	require.NoError(t, err)
+
+	s.LimitePorUsuario = 0
+	_, err = s.Emprestar(livro.ID, leitor.ID, "")
+	assert.NoError(t, err)
+}
+
+func TestDevolverEmprestimoJaDevolvido(t *testing.T) {
+	s, db := novoEmprestimoService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 1)
+	leitor := criarUsuario(t, db, "leitor")
+
+	emprestimo, err := s.Emprestar(livro.ID, leitor.ID, "")
+	require.NoError(t, err)
+
+	devolvido, err := s.Devolver(emprestimo.ID, leitor.ID, false)
+	require.NoError(t, err)
+	require.NotNil(t, devolvido.DataDevolucao)
+	assert.Equal(t, models.ExemplarDisponivel, statusExemplar(t, db, *emprestimo.ExemplarID))
+
+	_, err = s.Devolver(emprestimo.ID, leitor.ID, false)
+	assert.ErrorIs(t, err, repository.ErrEmprestimoDevolvido)
+
+	_, err = s.Devolver(9999, leitor.ID, true)
+	assert.ErrorIs(t, err, repository.ErrEmprestimoNotFound)
+}
+
+func TestDevolverEmprestimoDeOutroUsuario(t *testing.T) {
+	s, db := novoEmprestimoService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 1)
+	leitor := criarUsuario(t, db, "leitor")
+	outro := criarUsuario(t, db, "outro")
+
+	emprestimo, err := s.Emprestar(livro.ID, leitor.ID, "")
+	require.NoError(t, err)
+
+	_, err = s.Devolver(emprestimo.ID, outro.ID, false)
+	assert.ErrorIs(t, err, ErrNotBorrower)
+
+	// Quem gerencia empréstimos pode registrar a devolução de qualquer usuário.
+	_, err = s.Devolver(emprestimo.ID, outro.ID, true)
+	assert.NoError(t, err)
+}
+
+func TestDevolverComAtrasoRegistraMulta(t *testing.T) {
+	s, db := novoEmprestimoService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 1)
+	leitor := criarUsuario(t, db, "leitor")
+
+	emprestimo, err := s.Emprestar(livro.ID, leitor.ID, "")
+	require.NoError(t, err)
+	prevista := time.Now().Add(-3*24*time.Hour + time.Hour)
+	require.NoError(t, db.Model(emprestimo).Update("data_devolucao_prevista", prevista).Error)
+
+	_, err = s.Devolver(emprestimo.ID, leitor.ID, false)
+	require.NoError(t, err)
+
+	var multa models.Multa
+	require.NoError(t, db.Where("emprestimo_id = ?", emprestimo.ID).First(&multa).Error)
+	assert.Equal(t, 3, multa.DiasAtraso)
+	assert.Equal(t, int64(300), multa.Valor)
+}
+
+func TestExcluirComEmprestimoAtivo(t *testing.T) {
+	s, db := novoEmprestimoService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 1)
+	leitor := criarUsuario(t, db, "leitor")
+	users := repository.NewUserRepository(db)
+	livros := repository.NewPostgresLivroRepository(db)
+
+	emprestimo, err := s.Emprestar(livro.ID, leitor.ID, "")
+	require.NoError(t, err)
+
+	// Enquanto o livro não volta, nem o leitor nem o livro podem ser excluídos.
+	assert.ErrorIs(t, users.Delete(leitor.ID), repository.ErrEmprestimosAtivos)
+	assert.ErrorIs(t, livros.Delete(context.Background(), livro.ID), repository.ErrEmprestimosAtivos)
+	_, err = s.Repo.FindByID(emprestimo.ID)
+	require.NoError(t, err)
+
+	_, err = s.Devolver(emprestimo.ID, leitor.ID, false)
+	require.NoError(t, err)
+	assert.NoError(t, users.Delete(leitor.ID))
+	assert.NoError(t, livros.Delete(context.Background(), livro.ID))
+	assert.ErrorIs(t, users.Delete(leitor.ID), repository.ErrUserNotFound)
+}
+
+func TestExcluirComMultaPendente(t *testing.T) {
+	s, db := novoEmprestimoService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 1)
+	leitor := criarUsuario(t, db, "leitor")
+	users := repository.NewUserRepository(db)
+
+	emprestimo, err := s.Emprestar(livro.ID, leitor.ID, "")
+	require.NoError(t, err)
+	require.NoError(t, db.Model(emprestimo).Update("data_devolucao_prevista", time.Now().Add(-48*time.Hour)).Error)
+	_, err = s.Devolver(emprestimo.ID, leitor.ID, false)
+	require.NoError(t, err)
+
+	// A conta só pode ser excluída depois de paga a multa.
+	assert.ErrorIs(t, users.Delete(leitor.ID), repository.ErrMultasNaoPagas)
+	var multa models.Multa
+	require.NoError(t, db.Where("emprestimo_id = ?", emprestimo.ID).First(&multa).Error)
+	_, err = s.Multas.Pagar(multa.ID, time.Now())
+	require.NoError(t, err)
+	assert.NoError(t, users.Delete(leitor.ID))
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envInt lê um inteiro de uma variável de ambiente, retornando o valor padrão se ausente ou inválido.
+func envInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Valor inválido para %s: %q, usando %d", key, value, def)
+		return def
+	}
+	return n
+}
+
+// envDuration lê uma duração (ex.: "15m") de uma variável de ambiente, retornando o valor padrão se ausente ou inválido.
+func envDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Valor inválido para %s: %q, usando %s", key, value, def)
+		return def
+	}
+	return d
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"books_api/models"
+	"books_api/repository"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrEstantePadrao     = errors.New("as estantes padrão não podem ser excluídas")
+	ErrTokenEstanteVazio = errors.New("link de compartilhamento inválido")
+)
+
+// EstanteService gerencia as estantes e listas de leitura dos usuários.
+type EstanteService struct {
+	Repo *repository.EstanteRepository
+	// BaseURL é o endereço público da API, usado nos links de compartilhamento.
+	BaseURL string
+}
+
+func NewEstanteService(repo *repository.EstanteRepository, baseURL string) *EstanteService {
+	return &EstanteService{Repo: repo, BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// MinhasEstantes retorna as estantes do usuário, criando as estantes padrão no primeiro acesso.
+func (s *EstanteService) MinhasEstantes(userID uint) ([]models.Estante, error) {
+	if err := s.Repo.GarantirPadrao(userID); err != nil {
+		return nil, err
+	}
+	estantes, err := s.Repo.ListByUser(userID, false)
+	if err != nil {
+		return nil, err
+	}
+	for i := range estantes {
+		s.preencherLink(&estantes[i])
+	}
+	return estantes, nil
+}
+
+// EstantesPublicas retorna as estantes públicas de outro usuário.
+func (s *EstanteService) EstantesPublicas(userID uint) ([]models.Estante, error) {
+	return s.Repo.ListByUser(userID, true)
+}
+
+// BuscarEstante retorna uma estante com os livros. Estantes privadas só são visíveis ao dono; para
+// os demais usuários, elas não existem.
+func (s *EstanteService) BuscarEstante(id, userID uint) (*models.Estante, error) {
+	estante, err := s.Repo.FindComItens(id)
+	if err != nil {
+		return nil, err
+	}
+	if estante.UserID != userID {
+		if !estante.Publica {
+			return nil, repository.ErrEstanteNotFound
+		}
+		return estante, nil
+	}
+	s.preencherLink(estante)
+	return estante, nil
+}
+
+// BuscarCompartilhada retorna a estante compartilhada pelo link.
+func (s *EstanteService) BuscarCompartilhada(token string) (*models.Estante, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrTokenEstanteVazio
+	}
+	return s.Repo.FindByToken(token)
+}
+
+// CriarEstante cria uma lista personalizada para o usuário.
+func (s *EstanteService) CriarEstante(userID uint, nome, descricao string, publica bool) (*models.Estante, error) {
+	estante := &models.Estante{
+		UserID:    userID,
+		Nome:      nome,
+		Descricao: descricao,
+		Tipo:      models.EstantePersonalizada,
+		Publica:   publica,
+	}
+	if err := estante.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Create(estante); err != nil {
+		return nil, err
+	}
+	return estante, nil
+}
+
+// AtualizarEstante altera o nome, a descrição e a visibilidade de uma estante do usuário.
+func (s *EstanteService) AtualizarEstante(id, userID uint, nome, descricao string, publica bool) (*models.Estante, error) {
+	estante, err := s.estanteDoUsuario(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	estante.Nome, estante.Descricao, estante.Publica = nome, descricao, publica
+	if err := estante.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Update(estante); err != nil {
+		return nil, err
+	}
+	s.preencherLink(estante)
+	return estante, nil
+}
+
+// RemoverEstante exclui uma lista personalizada do usuário.
+func (s *EstanteService) RemoverEstante(id, userID uint) error {
+	estante, err := s.estanteDoUsuario(id, userID)
+	if err != nil {
+		return err
+	}
+	if estante.Padrao() {
+		return ErrEstantePadrao
+	}
+	return s.Repo.Delete(id)
+}
+
+// AdicionarLivro coloca o livro na estante do usuário. posicao 0 coloca o livro no fim da lista.
+func (s *EstanteService) AdicionarLivro(id, userID, livroID uint, nota string, posicao int) (*models.EstanteItem, error) {
+	estante, err := s.estanteDoUsuario(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	nota = strings.TrimSpace(nota)
+	if err := models.ValidateNotaItem(nota); err != nil {
+		return nil, err
+	}
+	return s.Repo.AdicionarLivro(estante, livroID, nota, posicao)
+}
+
+// AtualizarLivro altera a nota ou a posição de um livro na estante do usuário.
+func (s *EstanteService) AtualizarLivro(id, userID, livroID uint, nota *string, posicao int) (*models.EstanteItem, error) {
+	if _, err := s.estanteDoUsuario(id, userID); err != nil {
+		return nil, err
+	}
+	if nota != nil {
+		trimmed := strings.TrimSpace(*nota)
+		if err := models.ValidateNotaItem(trimmed); err != nil {
+			return nil, err
+		}
+		nota = &trimmed
+	}
+	return s.Repo.AtualizarItem(id, livroID, nota, posicao)
+}
+
+// RemoverLivro tira o livro da estante do usuário.
+func (s *EstanteService) RemoverLivro(id, userID, livroID uint) error {
+	if _, err := s.estanteDoUsuario(id, userID); err != nil {
+		return err
+	}
+	return s.Repo.RemoverLivro(id, livroID)
+}
+
+// Compartilhar gera um novo link de compartilhamento, invalidando o anterior.
+func (s *EstanteService) Compartilhar(id, userID uint) (*models.Estante, error) {
+	estante, err := s.estanteDoUsuario(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	token, err := newOneTimeToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.DefinirToken(id, &token); err != nil {
+		return nil, err
+	}
+	estante.TokenCompartilhamento = &token
+	s.preencherLink(estante)
+	return estante, nil
+}
+
+// PararCompartilhamento invalida o link de compartilhamento da estante.
+func (s *EstanteService) PararCompartilhamento(id, userID uint) error {
+	if _, err := s.estanteDoUsuario(id, userID); err != nil {
+		return err
+	}
+	return s.Repo.DefinirToken(id, nil)
+}
+
+// estanteDoUsuario busca a estante, garantindo que ela pertence ao usuário. Estantes de outros
+// usuários são tratadas como inexistentes.
+func (s *EstanteService) estanteDoUsuario(id, userID uint) (*models.Estante, error) {
+	estante, err := s.Repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if estante.UserID != userID {
+		return nil, repository.ErrEstanteNotFound
+	}
+	return estante, nil
+}
+
+// preencherLink monta o link de compartilhamento da estante, se houver.
+func (s *EstanteService) preencherLink(estante *models.Estante) {
+	if estante.TokenCompartilhamento != nil {
+		estante.LinkCompartilhamento = s.BaseURL + "/estantes/compartilhadas/" + *estante.TokenCompartilhamento
+	}
+}
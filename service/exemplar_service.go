@@ -0,0 +1,173 @@
+package service
+
+import (
+	"books_api/models"
+	"books_api/repository"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidExemplar       = errors.New("código de barras é obrigatório")
+	ErrInvalidExemplarStatus = errors.New("situação de exemplar inválida")
+	ErrLocalizacaoRequired   = errors.New("localização é obrigatória")
+)
+
+// ExemplarService gerencia os exemplares físicos dos livros e os inventários.
+type ExemplarService struct {
+	Exemplares  *repository.ExemplarRepository
+	Inventarios *repository.InventarioRepository
+}
+
+func NewExemplarService(exemplares *repository.ExemplarRepository, inventarios *repository.InventarioRepository) *ExemplarService {
+	return &ExemplarService{Exemplares: exemplares, Inventarios: inventarios}
+}
+
+// LeituraInventario é o resultado da leitura de um exemplar em um inventário, com os alertas
+// que devem ser conferidos por quem está fazendo a contagem.
+type LeituraInventario struct {
+	Exemplar *models.Exemplar `json:"exemplar"`
+	Alertas  []string         `json:"alertas,omitempty"`
+}
+
+// ResultadoInventario é o inventário concluído e os exemplares marcados como perdidos.
+type ResultadoInventario struct {
+	Inventario *models.Inventario `json:"inventario"`
+	Perdidos   []models.Exemplar  `json:"perdidos"`
+}
+
+// CadastrarExemplar adiciona um exemplar a um livro.
+func (s *ExemplarService) CadastrarExemplar(exemplar *models.Exemplar) error {
+	exemplar.CodigoBarras = strings.TrimSpace(exemplar.CodigoBarras)
+	exemplar.Localizacao = strings.TrimSpace(exemplar.Localizacao)
+	if exemplar.CodigoBarras == "" {
+		return ErrInvalidExemplar
+	}
+	switch exemplar.Status {
+	case "":
+		exemplar.Status = models.ExemplarDisponivel
+	case models.ExemplarEmprestado, models.ExemplarReservado:
+		// Exemplares só ficam emprestados ou reservados por meio de um empréstimo ou de uma reserva.
+		return ErrInvalidExemplarStatus
+	default:
+		if !models.ValidExemplarStatus(exemplar.Status) {
+			return ErrInvalidExemplarStatus
+		}
+	}
+	return s.Exemplares.Create(exemplar)
+}
+
+// AtualizarExemplar altera a condição, a localização e a situação de um exemplar. Campos vazios
+// não são alterados.
+func (s *ExemplarService) AtualizarExemplar(id uint, condicao, localizacao, status string) (*models.Exemplar, error) {
+	atual, err := s.Exemplares.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		status = atual.Status
+	}
+	if !models.ValidExemplarStatus(status) ||
+		((status == models.ExemplarEmprestado || status == models.ExemplarReservado) && atual.Status != status) {
+		return nil, ErrInvalidExemplarStatus
+	}
+	return s.Exemplares.Update(id, strings.TrimSpace(condicao), strings.TrimSpace(localizacao), status)
+}
+
+// RemoverExemplar exclui um exemplar que não esteja emprestado.
+func (s *ExemplarService) RemoverExemplar(id uint) error {
+	return s.Exemplares.Delete(id)
+}
+
+// ListarExemplares retorna uma página de exemplares e o total encontrado.
+func (s *ExemplarService) ListarExemplares(filter repository.ExemplarFilter) ([]models.Exemplar, int64, error) {
+	if filter.Status != "" && !models.ValidExemplarStatus(filter.Status) {
+		return nil, 0, ErrInvalidExemplarStatus
+	}
+	exemplares, total, err := s.Exemplares.List(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao listar exemplares: %w", err)
+	}
+	return exemplares, total, nil
+}
+
+// ResumoInventario conta os exemplares por localização e situação.
+func (s *ExemplarService) ResumoInventario() ([]repository.ResumoInventario, error) {
+	return s.Exemplares.Resumo()
+}
+
+// IniciarInventario abre a conferência dos exemplares de uma localização.
+func (s *ExemplarService) IniciarInventario(localizacao string, userID uint) (*models.Inventario, error) {
+	localizacao = strings.TrimSpace(localizacao)
+	if localizacao == "" {
+		return nil, ErrLocalizacaoRequired
+	}
+	inventario := &models.Inventario{Localizacao: localizacao, IniciadoPor: userID, IniciadoEm: time.Now()}
+	if err := s.Inventarios.Create(inventario); err != nil {
+		return nil, err
+	}
+	return inventario, nil
+}
+
+// BuscarInventario retorna o inventário e o andamento da contagem.
+func (s *ExemplarService) BuscarInventario(id uint) (*models.Inventario, *repository.ProgressoInventario, error) {
+	inventario, err := s.Inventarios.FindByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	progresso, err := s.Inventarios.Progresso(inventario)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inventario, progresso, nil
+}
+
+// RegistrarLeitura registra a leitura de um exemplar pelo código de barras.
+func (s *ExemplarService) RegistrarLeitura(inventarioID uint, codigoBarras string) (*LeituraInventario, error) {
+	inventario, err := s.Inventarios.FindByID(inventarioID)
+	if err != nil {
+		return nil, err
+	}
+	if inventario.ConcluidoEm != nil {
+		return nil, repository.ErrInventarioConcluido
+	}
+
+	exemplar, err := s.Exemplares.FindByCodigoBarras(strings.TrimSpace(codigoBarras))
+	if err != nil {
+		return nil, err
+	}
+
+	leitura := &LeituraInventario{Exemplar: exemplar}
+	if exemplar.Localizacao != inventario.Localizacao {
+		leitura.Alertas = append(leitura.Alertas, fmt.Sprintf("exemplar cadastrado em outra localização: %s", exemplar.Localizacao))
+	}
+	switch exemplar.Status {
+	case models.ExemplarEmprestado:
+		leitura.Alertas = append(leitura.Alertas, "exemplar consta como emprestado")
+	case models.ExemplarPerdido:
+		leitura.Alertas = append(leitura.Alertas, "exemplar constava como perdido e voltou a ficar disponível")
+	}
+
+	if err := s.Inventarios.RegistrarLeitura(inventario.ID, exemplar, time.Now()); err != nil {
+		return nil, err
+	}
+	return leitura, nil
+}
+
+// ConcluirInventario encerra o inventário, marcando como perdidos os exemplares não lidos.
+func (s *ExemplarService) ConcluirInventario(id uint) (*ResultadoInventario, error) {
+	perdidos, err := s.Inventarios.Concluir(id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	inventario, err := s.Inventarios.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if perdidos == nil {
+		perdidos = []models.Exemplar{}
+	}
+	return &ResultadoInventario{Inventario: inventario, Perdidos: perdidos}, nil
+}
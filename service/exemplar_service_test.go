@@ -0,0 +1,146 @@
+package service
+
+import (
+	"testing"
+
+	"books_api/models"
+	"books_api/repository"
+	"books_api/repository/repotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func novoExemplarService(t *testing.T) (*ExemplarService, *gorm.DB) {
+	t.Helper()
+	db := repotest.NewDB(t)
+	return NewExemplarService(repository.NewExemplarRepository(db), repository.NewInventarioRepository(db)), db
+}
+
+func TestCadastrarExemplar(t *testing.T) {
+	s, db := novoExemplarService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 0)
+
+	exemplar := &models.Exemplar{LivroID: livro.ID, CodigoBarras: " 001 ", Localizacao: " A1 "}
+	require.NoError(t, s.CadastrarExemplar(exemplar))
+	assert.Equal(t, "001", exemplar.CodigoBarras)
+	assert.Equal(t, "A1", exemplar.Localizacao)
+	assert.Equal(t, models.ExemplarDisponivel, exemplar.Status)
+
+	err := s.CadastrarExemplar(&models.Exemplar{LivroID: livro.ID, CodigoBarras: "001"})
+	assert.ErrorIs(t, err, repository.ErrCodigoBarrasInUse)
+	err = s.CadastrarExemplar(&models.Exemplar{LivroID: livro.ID, CodigoBarras: "  "})
+	assert.ErrorIs(t, err, ErrInvalidExemplar)
+	err = s.CadastrarExemplar(&models.Exemplar{LivroID: livro.ID, CodigoBarras: "002", Status: models.ExemplarEmprestado})
+	assert.ErrorIs(t, err, ErrInvalidExemplarStatus)
+	err = s.CadastrarExemplar(&models.Exemplar{LivroID: 9999, CodigoBarras: "003"})
+	assert.ErrorIs(t, err, repository.ErrLivroNotFound)
+}
+
+func TestAtualizarExemplarMantemCamposVazios(t *testing.T) {
+	s, db := novoExemplarService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 0)
+	exemplar := &models.Exemplar{LivroID: livro.ID, CodigoBarras: "001", Condicao: "bom", Localizacao: "A1"}
+	require.NoError(t, s.CadastrarExemplar(exemplar))
+
+	atualizado, err := s.AtualizarExemplar(exemplar.ID, "", " B2 ", "")
+	require.NoError(t, err)
+	assert.Equal(t, "bom", atualizado.Condicao)
+	assert.Equal(t, "B2", atualizado.Localizacao)
+	assert.Equal(t, models.ExemplarDisponivel, atualizado.Status)
+
+	atualizado, err = s.AtualizarExemplar(exemplar.ID, "danificado", "", models.ExemplarEmReparo)
+	require.NoError(t, err)
+	assert.Equal(t, "danificado", atualizado.Condicao)
+	assert.Equal(t, "B2", atualizado.Localizacao)
+	assert.Equal(t, models.ExemplarEmReparo, atualizado.Status)
+
+	_, err = s.AtualizarExemplar(exemplar.ID, "", "", "extraviado")
+	assert.ErrorIs(t, err, ErrInvalidExemplarStatus)
+	_, err = s.AtualizarExemplar(9999, "bom", "", "")
+	assert.ErrorIs(t, err, repository.ErrExemplarNotFound)
+}
+
+func TestAtualizarExemplarEmprestado(t *testing.T) {
+	s, db := novoExemplarService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 0)
+	exemplar := &models.Exemplar{LivroID: livro.ID, CodigoBarras: "001"}
+	require.NoError(t, s.CadastrarExemplar(exemplar))
+
+	_, err := s.AtualizarExemplar(exemplar.ID, "", "", models.ExemplarEmprestado)
+	assert.ErrorIs(t, err, ErrInvalidExemplarStatus)
+
+	require.NoError(t, db.Model(exemplar).Update("status", models.ExemplarEmprestado).Error)
+	_, err = s.AtualizarExemplar(exemplar.ID, "", "", models.ExemplarDisponivel)
+	assert.ErrorIs(t, err, repository.ErrExemplarEmprestado)
+
+	// A condição de um exemplar emprestado ainda pode ser registrada.
+	atualizado, err := s.AtualizarExemplar(exemplar.ID, "capa rasgada", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "capa rasgada", atualizado.Condicao)
+	assert.Equal(t, models.ExemplarEmprestado, atualizado.Status)
+}
+
+func TestInventario(t *testing.T) {
+	s, db := novoExemplarService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 0)
+	cadastrar := func(codigo, localizacao, status string) *models.Exemplar {
+		exemplar := &models.Exemplar{LivroID: livro.ID, CodigoBarras: codigo, Localizacao: localizacao, Status: status}
+		require.NoError(t, s.CadastrarExemplar(exemplar))
+		return exemplar
+	}
+	lido := cadastrar("001", "A1", "")
+	naoLido := cadastrar("002", "A1", "")
+	perdido := cadastrar("003", "A1", models.ExemplarPerdido)
+	cadastrar("004", "B2", "")
+
+	inventario, err := s.IniciarInventario(" A1 ", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "A1", inventario.Localizacao)
+	_, err = s.IniciarInventario("A1", 1)
+	assert.ErrorIs(t, err, repository.ErrInventarioEmAndamento)
+	_, err = s.IniciarInventario(" ", 1)
+	assert.ErrorIs(t, err, ErrLocalizacaoRequired)
+
+	leitura, err := s.RegistrarLeitura(inventario.ID, "001")
+	require.NoError(t, err)
+	assert.Empty(t, leitura.Alertas)
+	// Leituras repetidas não contam duas vezes.
+	_, err = s.RegistrarLeitura(inventario.ID, "001")
+	require.NoError(t, err)
+
+	leitura, err = s.RegistrarLeitura(inventario.ID, "003")
+	require.NoError(t, err)
+	assert.Len(t, leitura.Alertas, 1)
+	assert.Equal(t, models.ExemplarDisponivel, statusExemplar(t, db, perdido.ID))
+
+	leitura, err = s.RegistrarLeitura(inventario.ID, "004")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"exemplar cadastrado em outra localização: B2"}, leitura.Alertas)
+
+	_, err = s.RegistrarLeitura(inventario.ID, "999")
+	assert.ErrorIs(t, err, repository.ErrExemplarNotFound)
+
+	_, progresso, err := s.BuscarInventario(inventario.ID)
+	require.NoError(t, err)
+	assert.Equal(t, repository.ProgressoInventario{Lidos: 3, Esperados: 3, Pendentes: 1}, *progresso)
+
+	resultado, err := s.ConcluirInventario(inventario.ID)
+	require.NoError(t, err)
+	require.Len(t, resultado.Perdidos, 1)
+	assert.Equal(t, naoLido.ID, resultado.Perdidos[0].ID)
+	assert.Equal(t, 1, resultado.Inventario.NaoLocalizados)
+	assert.NotNil(t, resultado.Inventario.ConcluidoEm)
+	assert.Equal(t, models.ExemplarPerdido, statusExemplar(t, db, naoLido.ID))
+	assert.Equal(t, models.ExemplarDisponivel, statusExemplar(t, db, lido.ID))
+
+	_, err = s.ConcluirInventario(inventario.ID)
+	assert.ErrorIs(t, err, repository.ErrInventarioConcluido)
+	_, err = s.RegistrarLeitura(inventario.ID, "002")
+	assert.ErrorIs(t, err, repository.ErrInventarioConcluido)
+
+	// Concluído o inventário, outro pode ser iniciado na mesma localização.
+	_, err = s.IniciarInventario("A1", 1)
+	assert.NoError(t, err)
+}
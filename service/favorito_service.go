@@ -0,0 +1,62 @@
+package service
+
+import (
+	"books_api/models"
+	"books_api/repository"
+	"fmt"
+)
+
+// FavoritoService gerencia os livros favoritos e os autores seguidos pelos usuários.
+type FavoritoService struct {
+	Repo *repository.FavoritoRepository
+}
+
+func NewFavoritoService(repo *repository.FavoritoRepository) *FavoritoService {
+	return &FavoritoService{Repo: repo}
+}
+
+// Favoritar adiciona o livro aos favoritos do usuário.
+func (s *FavoritoService) Favoritar(userID, livroID uint) (*models.Favorito, error) {
+	return s.Repo.Favoritar(userID, livroID)
+}
+
+// Desfavoritar remove o livro dos favoritos do usuário.
+func (s *FavoritoService) Desfavoritar(userID, livroID uint) error {
+	return s.Repo.Desfavoritar(userID, livroID)
+}
+
+// ListarFavoritos retorna uma página dos favoritos do usuário e o total encontrado.
+func (s *FavoritoService) ListarFavoritos(userID uint, page, limit int) ([]models.Favorito, int64, error) {
+	favoritos, total, err := s.Repo.ListFavoritos(userID, page, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao listar favoritos: %w", err)
+	}
+	return favoritos, total, nil
+}
+
+// Seguir passa a seguir o autor. email indica se o usuário também quer ser avisado por e-mail dos
+// livros novos; seguir um autor já seguido só atualiza essa preferência.
+func (s *FavoritoService) Seguir(userID uint, autor string, email bool) (*models.AutorSeguido, error) {
+	seguido := &models.AutorSeguido{UserID: userID, Autor: autor, Email: email}
+	if err := seguido.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Seguir(seguido); err != nil {
+		return nil, err
+	}
+	return s.Repo.FindSeguido(userID, seguido.Chave)
+}
+
+// DeixarDeSeguir para de seguir o autor.
+func (s *FavoritoService) DeixarDeSeguir(userID, id uint) error {
+	return s.Repo.DeixarDeSeguir(userID, id)
+}
+
+// ListarSeguindo retorna os autores seguidos pelo usuário.
+func (s *FavoritoService) ListarSeguindo(userID uint) ([]models.AutorSeguido, error) {
+	seguidos, err := s.Repo.ListSeguindo(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar autores seguidos: %w", err)
+	}
+	return seguidos, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"books_api/models"
+	"books_api/notify"
+	"books_api/repository"
+	"books_api/repository/repotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestChaveAutor(t *testing.T) {
+	assert.Equal(t, "machado de assis", models.ChaveAutor("  Machado   de ASSIS "))
+	assert.Equal(t, "", models.ChaveAutor("   "))
+}
+
+func TestSeguirAutorInvalido(t *testing.T) {
+	s := &FavoritoService{}
+
+	_, err := s.Seguir(1, "   ", false)
+	assert.ErrorIs(t, err, models.ErrAutorInvalido)
+
+	_, err = s.Seguir(1, strings.Repeat("a", 201), false)
+	assert.ErrorIs(t, err, models.ErrAutorInvalido)
+}
+
+// notifierGravado guarda os avisos enviados.
+type notifierGravado struct {
+	mu       sync.Mutex
+	enviados []notify.Notification
+}
+
+func (n *notifierGravado) Notify(_ context.Context, notification notify.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.enviados = append(n.enviados, notification)
+	return nil
+}
+
+// novoNotificacaoService monta o serviço sobre um banco de teste com um seguidor de Machado de Assis
+// que pediu aviso por e-mail.
+func novoNotificacaoService(t *testing.T) (*NotificacaoService, *notifierGravado, *gorm.DB) {
+	t.Helper()
+	db := repotest.NewDB(t)
+	favoritos := repository.NewFavoritoRepository(db)
+	notifier := &notifierGravado{}
+
+	user := &models.User{Username: "leitor", Password: "hash", Email: "leitor@example.com", EmailVerified: true}
+	require.NoError(t, db.Create(user).Error)
+	require.NoError(t, favoritos.Seguir(&models.AutorSeguido{
+		UserID: user.ID,
+		Autor:  "Machado de Assis",
+		Chave:  models.ChaveAutor("Machado de Assis"),
+		Email:  true,
+	}))
+	return NewNotificacaoService(repository.NewNotificacaoRepository(db), favoritos, notifier), notifier, db
+}
+
+func contarNotificacoes(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var total int64
+	require.NoError(t, db.Model(&models.Notificacao{}).Count(&total).Error)
+	return total
+}
+
+func TestNotificarNovoLivro(t *testing.T) {
+	s, notifier, db := novoNotificacaoService(t)
+
+	livro := &models.Livro{Titulo: "Dom Casmurro", Autor: "machado DE assis "}
+	require.NoError(t, db.Create(livro).Error)
+	s.NotificarNovoLivro(livro)
+	assert.Equal(t, int64(1), contarNotificacoes(t, db))
+	require.Len(t, notifier.enviados, 1)
+	assert.Equal(t, "leitor@example.com", notifier.enviados[0].Email)
+}
+
+func TestNotificarNovoLivroSemAutor(t *testing.T) {
+	s, notifier, db := novoNotificacaoService(t)
+
+	for _, autor := range []string{"", "   "} {
+		livro := &models.Livro{Titulo: "Anônimo", Autor: autor}
+		require.NoError(t, db.Create(livro).Error)
+		s.NotificarNovoLivro(livro)
+	}
+	assert.Zero(t, contarNotificacoes(t, db))
+	assert.Empty(t, notifier.enviados)
+}
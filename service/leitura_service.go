@@ -0,0 +1,173 @@
+package service
+
+import (
+	"books_api/models"
+	"books_api/repository"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+var (
+	ErrSessaoVazia          = errors.New("informe as páginas lidas ou conclua a leitura")
+	ErrInvalidLeituraStatus = errors.New("situação de leitura inválida")
+)
+
+// LeituraService registra as sessões de leitura dos usuários e calcula as estatísticas e metas anuais.
+type LeituraService struct {
+	Repo *repository.LeituraRepository
+	// Estantes, se definido, é usado para mover o livro para as estantes "lendo" e "lidos" do usuário
+	// conforme a leitura avança.
+	Estantes *repository.EstanteRepository
+}
+
+func NewLeituraService(repo *repository.LeituraRepository, estantes *repository.EstanteRepository) *LeituraService {
+	return &LeituraService{Repo: repo, Estantes: estantes}
+}
+
+// ProgressoMeta é o andamento da meta anual de leitura.
+type ProgressoMeta struct {
+	Livros     int     `json:"livros"`
+	Concluidos int64   `json:"concluidos"`
+	Percentual float64 `json:"percentual"`
+	// Esperado é quantos livros deveriam ter sido concluídos até agora para cumprir a meta em ritmo constante.
+	Esperado int  `json:"esperado"`
+	NoRitmo  bool `json:"no_ritmo"`
+}
+
+// Estatisticas são as estatísticas de leitura do ano com o andamento da meta, se houver.
+type Estatisticas struct {
+	*repository.EstatisticasLeitura
+	Meta *ProgressoMeta `json:"meta"`
+}
+
+// RegistrarSessao grava uma sessão de leitura do livro. inicio e fim vazios valem o instante atual, e
+// concluida encerra a leitura mesmo que as páginas não alcancem o total do livro.
+func (s *LeituraService) RegistrarSessao(userID, livroID uint, paginas int, inicio, fim time.Time, concluida bool) (*models.Leitura, error) {
+	now := time.Now()
+	if fim.IsZero() {
+		fim = now
+	}
+	if inicio.IsZero() {
+		inicio = fim
+	}
+	sessao := &models.SessaoLeitura{UserID: userID, Paginas: paginas, InicioEm: inicio, FimEm: fim}
+	if err := sessao.Validate(now); err != nil {
+		return nil, err
+	}
+	if paginas == 0 && !concluida {
+		return nil, ErrSessaoVazia
+	}
+
+	leitura, err := s.Repo.RegistrarSessao(livroID, sessao, concluida)
+	if err != nil {
+		return nil, err
+	}
+
+	tipo := models.EstanteLendo
+	if leitura.ConcluidaEm != nil {
+		tipo = models.EstanteLidos
+	}
+	s.moverParaEstante(userID, livroID, tipo)
+	return leitura, nil
+}
+
+// BuscarLeitura retorna a leitura do livro pelo usuário com as sessões.
+func (s *LeituraService) BuscarLeitura(userID, livroID uint) (*models.Leitura, error) {
+	return s.Repo.FindByLivro(userID, livroID)
+}
+
+// ListarLeituras retorna uma página das leituras do usuário e o total encontrado.
+func (s *LeituraService) ListarLeituras(filter repository.LeituraFilter) ([]models.Leitura, int64, error) {
+	if filter.Status != "" && filter.Status != models.LeituraEmAndamento && filter.Status != models.LeituraConcluida {
+		return nil, 0, ErrInvalidLeituraStatus
+	}
+	leituras, total, err := s.Repo.List(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao listar leituras: %w", err)
+	}
+	return leituras, total, nil
+}
+
+// RemoverLeitura exclui a leitura do livro e as sessões dela. As estantes não são alteradas.
+func (s *LeituraService) RemoverLeitura(userID, livroID uint) error {
+	return s.Repo.Delete(userID, livroID)
+}
+
+// DefinirMeta define quantos livros o usuário pretende concluir no ano.
+func (s *LeituraService) DefinirMeta(userID uint, ano, livros int) (*models.MetaLeitura, error) {
+	meta := &models.MetaLeitura{UserID: userID, Ano: ano, Livros: livros}
+	if err := meta.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.DefinirMeta(meta); err != nil {
+		return nil, err
+	}
+	return s.Repo.FindMeta(userID, ano)
+}
+
+// RemoverMeta exclui a meta do usuário para o ano.
+func (s *LeituraService) RemoverMeta(userID uint, ano int) error {
+	return s.Repo.DeleteMeta(userID, ano)
+}
+
+// Estatisticas resume as leituras do usuário no ano, no fuso horário do servidor.
+func (s *LeituraService) Estatisticas(userID uint, ano int) (*Estatisticas, error) {
+	if ano < 1900 || ano > 9999 {
+		return nil, models.ErrAnoInvalido
+	}
+	inicio := time.Date(ano, time.January, 1, 0, 0, 0, 0, time.Local)
+	resumo, err := s.Repo.Estatisticas(userID, inicio, inicio.AddDate(1, 0, 0))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao calcular estatísticas de leitura: %w", err)
+	}
+
+	estatisticas := &Estatisticas{EstatisticasLeitura: resumo}
+	meta, err := s.Repo.FindMeta(userID, ano)
+	switch {
+	case err == nil:
+		estatisticas.Meta = progressoMeta(meta.Livros, resumo.LivrosConcluidos, ano, time.Now())
+	case !errors.Is(err, repository.ErrMetaLeituraNotFound):
+		return nil, err
+	}
+	return estatisticas, nil
+}
+
+// progressoMeta calcula o andamento da meta em now. Em anos passados, espera-se a meta inteira; em
+// anos futuros, nenhum livro.
+func progressoMeta(livros int, concluidos int64, ano int, now time.Time) *ProgressoMeta {
+	inicio := time.Date(ano, time.January, 1, 0, 0, 0, 0, now.Location())
+	fim := inicio.AddDate(1, 0, 0)
+	decorrido := math.Max(0, math.Min(1, float64(now.Sub(inicio))/float64(fim.Sub(inicio))))
+
+	progresso := &ProgressoMeta{
+		Livros:     livros,
+		Concluidos: concluidos,
+		Percentual: math.Min(100, math.Round(float64(concluidos)*1000/float64(livros))/10),
+		Esperado:   int(math.Floor(float64(livros) * decorrido)),
+	}
+	progresso.NoRitmo = concluidos >= int64(progresso.Esperado)
+	return progresso
+}
+
+// moverParaEstante coloca o livro na estante padrão do tipo informado, tirando-o das outras estantes
+// padrão. Falhas são apenas registradas, pois a sessão já foi gravada.
+func (s *LeituraService) moverParaEstante(userID, livroID uint, tipo string) {
+	if s.Estantes == nil {
+		return
+	}
+	if err := s.Estantes.GarantirPadrao(userID); err != nil {
+		log.Printf("Erro ao criar as estantes padrão do usuário %d: %v", userID, err)
+		return
+	}
+	estante, err := s.Estantes.FindPadrao(userID, tipo)
+	if err != nil {
+		log.Printf("Erro ao buscar a estante %q do usuário %d: %v", tipo, userID, err)
+		return
+	}
+	if _, err := s.Estantes.AdicionarLivro(estante, livroID, "", 0); err != nil && !errors.Is(err, repository.ErrLivroNaEstante) {
+		log.Printf("Erro ao mover o livro %d para a estante %q do usuário %d: %v", livroID, tipo, userID, err)
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"books_api/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressoMeta(t *testing.T) {
+	meio := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	progresso := progressoMeta(24, 8, 2026, meio)
+	assert.Equal(t, 9, progresso.Esperado)
+	assert.Equal(t, 33.3, progresso.Percentual)
+	assert.False(t, progresso.NoRitmo)
+
+	progresso = progressoMeta(24, 9, 2026, meio)
+	assert.True(t, progresso.NoRitmo)
+
+	passado := progressoMeta(10, 12, 2025, meio)
+	assert.Equal(t, 10, passado.Esperado)
+	assert.Equal(t, 100.0, passado.Percentual)
+
+	futuro := progressoMeta(10, 0, 2027, meio)
+	assert.Equal(t, 0, futuro.Esperado)
+	assert.True(t, futuro.NoRitmo)
+}
+
+func TestLeituraPreencherProgresso(t *testing.T) {
+	leitura := &models.Leitura{PaginasLidas: 50, Livro: models.Livro{Paginas: 300}}
+	leitura.PreencherProgresso()
+	if assert.NotNil(t, leitura.Progresso) {
+		assert.Equal(t, 16.7, *leitura.Progresso)
+	}
+
+	leitura.PaginasLidas = 350
+	leitura.PreencherProgresso()
+	assert.Equal(t, 100.0, *leitura.Progresso)
+
+	semPaginas := &models.Leitura{PaginasLidas: 50}
+	semPaginas.PreencherProgresso()
+	assert.Nil(t, semPaginas.Progresso)
+
+	concluida := time.Now()
+	semPaginas.ConcluidaEm = &concluida
+	semPaginas.PreencherProgresso()
+	assert.Equal(t, 100.0, *semPaginas.Progresso)
+}
+
+func TestRegistrarSessaoValidacao(t *testing.T) {
+	s := &LeituraService{}
+	now := time.Now()
+
+	_, err := s.RegistrarSessao(1, 1, 0, time.Time{}, time.Time{}, false)
+	assert.ErrorIs(t, err, ErrSessaoVazia)
+
+	_, err = s.RegistrarSessao(1, 1, -5, time.Time{}, time.Time{}, false)
+	assert.ErrorIs(t, err, models.ErrPaginasSessaoInvalidas)
+
+	_, err = s.RegistrarSessao(1, 1, 10, now, now.Add(-time.Hour), false)
+	assert.ErrorIs(t, err, models.ErrPeriodoSessaoInvalido)
+
+	_, err = s.RegistrarSessao(1, 1, 10, now, now.Add(time.Hour), false)
+	assert.ErrorIs(t, err, models.ErrPeriodoSessaoInvalido)
+}
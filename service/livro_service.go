@@ -4,13 +4,14 @@ import (
 	"books_api/models"
 	"books_api/repository"
 	"context"
+	"errors"
 	"fmt"
-	"gorm.io/gorm"
+	"log"
 )
 
 // Interface para facilitar o mock nos testes
 type LivroService interface {
-	ListarLivros(ctx context.Context) ([]models.Livro, error)
+	ListarLivros(ctx context.Context, ordem string) ([]models.Livro, error)
 	BuscarLivroPorID(ctx context.Context, id uint) (*models.Livro, error)
 	CriarLivro(ctx context.Context, livro *models.Livro) error
 	AtualizarLivro(ctx context.Context, id uint, livroAtualizado *models.Livro) (*models.Livro, error)
@@ -18,56 +19,112 @@ type LivroService interface {
 	AtualizarImagemLivro(id uint, imagePath string) error
 }
 
+// NotificadorLivros é avisado dos livros cadastrados, para notificar os interessados.
+type NotificadorLivros interface {
+	NotificarNovoLivro(livro *models.Livro)
+}
+
 type livroService struct {
-	db *gorm.DB
+	repo       repository.LivroRepository
+	exemplares *repository.ExemplarRepository
+	novidades  NotificadorLivros
 }
 
-func NewLivroService(db *gorm.DB) LivroService {
-	return &livroService{db: db}
+// NewLivroService cria o serviço de livros sobre o repositório informado. exemplares é opcional e
+// preenche a disponibilidade dos livros; novidades é opcional e recebe, em segundo plano, cada livro cadastrado.
+func NewLivroService(repo repository.LivroRepository, exemplares *repository.ExemplarRepository, novidades NotificadorLivros) LivroService {
+	return &livroService{repo: repo, exemplares: exemplares, novidades: novidades}
 }
 
+// ErrInvalidOrdem indica uma ordenação desconhecida na listagem.
+var ErrInvalidOrdem = errors.New("ordenação inválida")
+
 // Implementação real do serviço
-func (s *livroService) ListarLivros(ctx context.Context) ([]models.Livro, error) {
-	livros, err := repository.GetLivrosFromCache(ctx, 1, 10, nil)
+func (s *livroService) ListarLivros(ctx context.Context, ordem string) ([]models.Livro, error) {
+	if !repository.ValidLivroOrdem(ordem) {
+		return nil, ErrInvalidOrdem
+	}
+	livros, err := s.repo.List(ctx, repository.LivroFilter{Page: 1, Limit: 10, Ordem: ordem})
 	if err != nil {
 		return nil, fmt.Errorf("erro ao listar livros: %w", err)
 	}
+	s.preencherDisponibilidade(livros)
 	return livros, nil
 }
 
+// BuscarLivroPorID retorna o livro com a disponibilidade, ou nil se ele não existir.
 func (s *livroService) BuscarLivroPorID(ctx context.Context, id uint) (*models.Livro, error) {
-	livro, err := repository.GetLivroByID(ctx, id)
+	livro, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrLivroNotFound) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar livro com ID %d: %w", id, err)
 	}
-	return livro, nil
+
+	livros := []models.Livro{*livro}
+	s.preencherDisponibilidade(livros)
+	return &livros[0], nil
 }
 
-func (s *livroService) AtualizarImagemLivro(id uint, imagePath string) error {
-	livro, err := s.BuscarLivroPorID(context.Background(), id)
-	if err != nil || livro == nil {
-		return fmt.Errorf("livro não encontrado")
+// preencherDisponibilidade calcula a disponibilidade dos livros a partir dos exemplares. A contagem
+// não passa pelo cache, para refletir empréstimos e devoluções imediatamente.
+func (s *livroService) preencherDisponibilidade(livros []models.Livro) {
+	if s.exemplares == nil || len(livros) == 0 {
+		return
 	}
 
-	// Atualizar o campo ImagePath
-	livro.ImagePath = imagePath
+	ids := make([]uint, len(livros))
+	for i := range livros {
+		ids[i] = livros[i].ID
+	}
+	disponibilidade, err := s.exemplares.Disponibilidade(ids)
+	if err != nil {
+		log.Printf("Erro ao calcular disponibilidade dos livros: %v", err)
+		return
+	}
 
-	if err := s.db.Save(&livro).Error; err != nil {
-		return err
+	for i := range livros {
+		if d, ok := disponibilidade[livros[i].ID]; ok {
+			livros[i].Disponibilidade = d
+		} else {
+			livros[i].Disponibilidade = &models.Disponibilidade{}
+		}
 	}
+}
 
+func (s *livroService) AtualizarImagemLivro(id uint, imagePath string) error {
+	if err := s.repo.UpdateImagem(context.Background(), id, imagePath); err != nil {
+		if errors.Is(err, repository.ErrLivroNotFound) {
+			return fmt.Errorf("livro não encontrado")
+		}
+		return err
+	}
 	return nil
 }
 
 func (s *livroService) CriarLivro(ctx context.Context, livro *models.Livro) error {
-	if err := repository.CreateLivro(ctx, livro); err != nil {
+	// Um livro novo ainda não tem avaliações.
+	livro.MediaAvaliacoes, livro.TotalAvaliacoes = 0, 0
+	if livro.Paginas < 0 {
+		livro.Paginas = 0
+	}
+	if err := s.repo.Create(ctx, livro); err != nil {
 		return fmt.Errorf("erro ao criar livro: %w", err)
 	}
+	if s.novidades != nil {
+		novo := *livro
+		go s.novidades.NotificarNovoLivro(&novo)
+	}
 	return nil
 }
 
+// AtualizarLivro altera os dados cadastrais do livro. Retorna nil se o livro não existir.
 func (s *livroService) AtualizarLivro(ctx context.Context, id uint, livroAtualizado *models.Livro) (*models.Livro, error) {
-	livro, err := repository.UpdateLivro(ctx, id, livroAtualizado)
+	livro, err := s.repo.Update(ctx, id, livroAtualizado)
+	if errors.Is(err, repository.ErrLivroNotFound) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("erro ao atualizar livro com ID %d: %w", id, err)
 	}
@@ -75,7 +132,7 @@ func (s *livroService) AtualizarLivro(ctx context.Context, id uint, livroAtualiz
 }
 
 func (s *livroService) DeletarLivro(ctx context.Context, id uint) error {
-	if err := repository.DeleteLivro(ctx, id); err != nil {
+	if err := s.repo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("erro ao deletar livro com ID %d: %w", id, err)
 	}
 	return nil
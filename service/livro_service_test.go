@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"books_api/models"
+	"books_api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type novidadesFake chan *models.Livro
+
+func (n novidadesFake) NotificarNovoLivro(livro *models.Livro) { n <- livro }
+
+func TestLivroServiceCRUD(t *testing.T) {
+	ctx := context.Background()
+	novidades := make(novidadesFake, 1)
+	s := NewLivroService(repository.NewMemoryLivroRepository(), nil, novidades)
+
+	livro := &models.Livro{Titulo: "Dom Casmurro", Autor: "Machado de Assis", Paginas: -1, MediaAvaliacoes: 5, TotalAvaliacoes: 3}
+	require.NoError(t, s.CriarLivro(ctx, livro))
+	assert.NotZero(t, livro.ID)
+	assert.Zero(t, livro.Paginas)
+	assert.Zero(t, livro.TotalAvaliacoes, "livro novo não tem avaliações")
+
+	select {
+	case notificado := <-novidades:
+		assert.Equal(t, livro.ID, notificado.ID)
+	case <-time.After(time.Second):
+		t.Fatal("livro novo não foi notificado")
+	}
+
+	encontrado, err := s.BuscarLivroPorID(ctx, livro.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Dom Casmurro", encontrado.Titulo)
+
+	atualizado, err := s.AtualizarLivro(ctx, livro.ID, &models.Livro{Titulo: "Dom Casmurro", Autor: "Machado de Assis", Paginas: 256})
+	require.NoError(t, err)
+	assert.Equal(t, 256, atualizado.Paginas)
+
+	require.NoError(t, s.AtualizarImagemLivro(livro.ID, "uploads/1.png"))
+	encontrado, err = s.BuscarLivroPorID(ctx, livro.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "uploads/1.png", encontrado.ImagePath)
+
+	require.NoError(t, s.DeletarLivro(ctx, livro.ID))
+	encontrado, err = s.BuscarLivroPorID(ctx, livro.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, encontrado)
+}
+
+func TestLivroServiceNaoEncontrado(t *testing.T) {
+	ctx := context.Background()
+	s := NewLivroService(repository.NewMemoryLivroRepository(), nil, nil)
+
+	livro, err := s.AtualizarLivro(ctx, 42, &models.Livro{Titulo: "Inexistente"})
+	assert.NoError(t, err)
+	assert.Nil(t, livro)
+
+	assert.EqualError(t, s.AtualizarImagemLivro(42, "uploads/42.png"), "livro não encontrado")
+}
+
+func TestLivroServiceListarOrdenado(t *testing.T) {
+	s := NewLivroService(repository.NewMemoryLivroRepository(
+		models.Livro{Titulo: "B", Ano: 1990, MediaAvaliacoes: 4.5, TotalAvaliacoes: 2},
+		models.Livro{Titulo: "A", Ano: 2010, MediaAvaliacoes: 3, TotalAvaliacoes: 10},
+		models.Livro{Titulo: "C", Ano: 2000, MediaAvaliacoes: 4.5, TotalAvaliacoes: 5},
+	), nil, nil)
+
+	titulos := func(ordem string) []string {
+		livros, err := s.ListarLivros(context.Background(), ordem)
+		require.NoError(t, err)
+		var resultado []string
+		for _, livro := range livros {
+			resultado = append(resultado, livro.Titulo)
+		}
+		return resultado
+	}
+
+	assert.Equal(t, []string{"B", "A", "C"}, titulos(""))
+	assert.Equal(t, []string{"A", "B", "C"}, titulos("titulo"))
+	assert.Equal(t, []string{"A", "C", "B"}, titulos("ano"))
+	assert.Equal(t, []string{"C", "B", "A"}, titulos("avaliacao"))
+	assert.Equal(t, []string{"A", "C", "B"}, titulos("avaliacoes"))
+
+	_, err := s.ListarLivros(context.Background(), "preco")
+	assert.ErrorIs(t, err, ErrInvalidOrdem)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"books_api/cache"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const loginGuardTimeout = 2 * time.Second
+
+// LockedError indica que novas tentativas de login estão temporariamente bloqueadas.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("muitas tentativas de login, tente novamente em %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginGuard conta tentativas de login malsucedidas no Redis, por usuário e por IP, e aplica
+// bloqueios temporários com duração crescente. Falhas no Redis não impedem o login, e com o Breaker
+// aberto o Redis nem é consultado.
+type LoginGuard struct {
+	Redis         *redis.Client
+	Breaker       *cache.Breaker
+	MaxAttempts   int           // falhas por usuário antes do bloqueio
+	MaxAttemptsIP int           // falhas por IP antes do bloqueio
+	BaseLockout   time.Duration // duração do primeiro bloqueio
+	MaxLockout    time.Duration // duração máxima de um bloqueio
+	Window        time.Duration // tempo sem falhas após o qual a contagem é zerada
+}
+
+// NewLoginGuard cria um LoginGuard configurado pelas variáveis LOGIN_MAX_ATTEMPTS,
+// LOGIN_MAX_ATTEMPTS_IP, LOGIN_LOCKOUT_BASE, LOGIN_LOCKOUT_MAX e LOGIN_ATTEMPT_WINDOW.
+func NewLoginGuard(client *redis.Client, breaker *cache.Breaker) *LoginGuard {
+	return &LoginGuard{
+		Redis:         client,
+		Breaker:       breaker,
+		MaxAttempts:   envInt("LOGIN_MAX_ATTEMPTS", 5),
+		MaxAttemptsIP: envInt("LOGIN_MAX_ATTEMPTS_IP", 20),
+		BaseLockout:   envDuration("LOGIN_LOCKOUT_BASE", time.Minute),
+		MaxLockout:    envDuration("LOGIN_LOCKOUT_MAX", time.Hour),
+		Window:        envDuration("LOGIN_ATTEMPT_WINDOW", time.Hour),
+	}
+}
+
+// Check retorna o tempo restante de bloqueio para o usuário ou IP, ou zero se o login é permitido.
+func (g *LoginGuard) Check(username, ip string) time.Duration {
+	if g == nil || g.Redis == nil {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), loginGuardTimeout)
+	defer cancel()
+
+	var remaining time.Duration
+	for _, key := range []string{lockKey("user", username), lockKey("ip", ip)} {
+		var ttl time.Duration
+		err := g.do(ctx, func() (err error) {
+			ttl, err = g.Redis.PTTL(ctx, key).Result()
+			return err
+		})
+		if err != nil {
+			log.Printf("Erro ao consultar bloqueio de login: %v", err)
+			continue
+		}
+		if ttl > remaining {
+			remaining = ttl
+		}
+	}
+	return remaining
+}
+
+// RegisterFailure contabiliza uma falha de login e, se o limite for atingido, bloqueia o usuário
+// ou o IP. Retorna a duração do bloqueio aplicado, ou zero.
+func (g *LoginGuard) RegisterFailure(username, ip string) time.Duration {
+	if g == nil || g.Redis == nil {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), loginGuardTimeout)
+	defer cancel()
+
+	var locked time.Duration
+	targets := []struct {
+		kind, id string
+		max      int
+	}{
+		{"user", username, g.MaxAttempts},
+		{"ip", ip, g.MaxAttemptsIP},
+	}
+	for _, target := range targets {
+		failures, err := g.incrementFailures(ctx, failKey(target.kind, target.id))
+		if err != nil {
+			log.Printf("Erro ao registrar falha de login: %v", err)
+			continue
+		}
+
+		d := lockoutDuration(failures, target.max, g.BaseLockout, g.MaxLockout)
+		if d == 0 {
+			continue
+		}
+		err = g.do(ctx, func() error {
+			return g.Redis.Set(ctx, lockKey(target.kind, target.id), 1, d).Err()
+		})
+		if err != nil {
+			log.Printf("Erro ao bloquear login: %v", err)
+			continue
+		}
+		if d > locked {
+			locked = d
+		}
+	}
+	return locked
+}
+
+// RegisterSuccess zera a contagem de falhas do usuário após um login bem-sucedido.
+func (g *LoginGuard) RegisterSuccess(username string) {
+	g.Unlock(username)
+}
+
+// Unlock remove o bloqueio e a contagem de falhas do usuário e dos IPs informados. O bloqueio de
+// um IP vale para todos os usuários que entram por ele, por isso só é removido quando o IP é passado.
+func (g *LoginGuard) Unlock(username string, ips ...string) {
+	if g == nil || g.Redis == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), loginGuardTimeout)
+	defer cancel()
+
+	keys := []string{failKey("user", username), lockKey("user", username)}
+	for _, ip := range ips {
+		keys = append(keys, failKey("ip", ip), lockKey("ip", ip))
+	}
+	if err := g.do(ctx, func() error { return g.Redis.Del(ctx, keys...).Err() }); err != nil {
+		log.Printf("Erro ao desbloquear login: %v", err)
+	}
+}
+
+// incrementFailures incrementa o contador de falhas e renova a janela de contagem.
+func (g *LoginGuard) incrementFailures(ctx context.Context, key string) (int64, error) {
+	var failures int64
+	err := g.do(ctx, func() error {
+		pipe := g.Redis.TxPipeline()
+		incr := pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, g.Window)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+		failures = incr.Val()
+		return nil
+	})
+	return failures, err
+}
+
+// do executa a operação no Redis pelo Breaker, quando houver.
+func (g *LoginGuard) do(ctx context.Context, fn func() error) error {
+	if g.Breaker == nil {
+		return fn()
+	}
+	return g.Breaker.Do(ctx, fn)
+}
+
+// lockoutDuration calcula o bloqueio após a quantidade de falhas informada. A partir do limite,
+// cada nova falha dobra a duração do bloqueio, até o máximo configurado.
+func lockoutDuration(failures int64, max int, base, ceiling time.Duration) time.Duration {
+	if max <= 0 || failures < int64(max) {
+		return 0
+	}
+
+	d := base
+	for i := int64(max); i < failures && d < ceiling; i++ {
+		d *= 2
+	}
+	if d > ceiling {
+		d = ceiling
+	}
+	return d
+}
+
+func failKey(kind, id string) string {
+	return "login:fail:" + kind + ":" + strings.ToLower(id)
+}
+
+func lockKey(kind, id string) string {
+	return "login:lock:" + kind + ":" + strings.ToLower(id)
+}
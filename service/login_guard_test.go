@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"books_api/cache"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	base, ceiling := time.Minute, 10*time.Minute
+
+	assert.Equal(t, time.Duration(0), lockoutDuration(4, 5, base, ceiling))
+	assert.Equal(t, time.Minute, lockoutDuration(5, 5, base, ceiling))
+	assert.Equal(t, 2*time.Minute, lockoutDuration(6, 5, base, ceiling))
+	assert.Equal(t, 8*time.Minute, lockoutDuration(8, 5, base, ceiling))
+	assert.Equal(t, ceiling, lockoutDuration(9, 5, base, ceiling))
+	assert.Equal(t, ceiling, lockoutDuration(500, 5, base, ceiling))
+	assert.Equal(t, time.Duration(0), lockoutDuration(10, 0, base, ceiling))
+}
+
+func TestLoginGuardWithoutRedis(t *testing.T) {
+	var guard *LoginGuard
+	assert.Equal(t, time.Duration(0), guard.Check("leitor", "127.0.0.1"))
+	assert.Equal(t, time.Duration(0), guard.RegisterFailure("leitor", "127.0.0.1"))
+	guard.RegisterSuccess("leitor")
+}
+
+func TestLoginGuardUnlockIP(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	guard := &LoginGuard{Redis: client, MaxAttempts: 5, MaxAttemptsIP: 2, BaseLockout: time.Minute, MaxLockout: time.Hour, Window: time.Hour}
+
+	guard.RegisterFailure("leitor", "10.0.0.1")
+	guard.RegisterFailure("leitor", "10.0.0.1")
+	assert.Equal(t, time.Minute, guard.Check("leitor", "10.0.0.1"))
+
+	// Sem o IP, o bloqueio dele continua valendo.
+	guard.Unlock("leitor")
+	assert.Equal(t, time.Minute, guard.Check("leitor", "10.0.0.1"))
+
+	guard.Unlock("leitor", "10.0.0.1")
+	assert.Equal(t, time.Duration(0), guard.Check("leitor", "10.0.0.1"))
+	assert.False(t, mr.Exists(failKey("ip", "10.0.0.1")))
+}
+
+func TestLoginGuardBreaker(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	breaker := cache.NewBreaker(cache.NewRedis(client), 1, time.Hour)
+	guard := &LoginGuard{Redis: client, Breaker: breaker, MaxAttempts: 1, MaxAttemptsIP: 1, BaseLockout: time.Minute, MaxLockout: time.Hour, Window: time.Hour}
+
+	mr.Close()
+	assert.Equal(t, time.Duration(0), guard.Check("leitor", "10.0.0.1"))
+	assert.True(t, breaker.Open())
+
+	// Com o Breaker aberto, o login segue sem esperar pelo Redis.
+	mr.Restart()
+	assert.Equal(t, time.Duration(0), guard.RegisterFailure("leitor", "10.0.0.1"))
+	assert.False(t, mr.Exists(failKey("user", "leitor")))
+}
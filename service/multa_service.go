@@ -0,0 +1,80 @@
+package service
+
+import (
+	"books_api/models"
+	"books_api/repository"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidMultaStatus = errors.New("situação de multa inválida")
+
+// PoliticaMultas define o cálculo das multas por atraso. Os valores são expressos em centavos.
+type PoliticaMultas struct {
+	// TaxaDiaria é cobrada por dia de atraso iniciado.
+	TaxaDiaria int64
+	// Teto limita o valor da multa de um empréstimo; 0 desativa o limite.
+	Teto int64
+	// LimiteBloqueio é o total de multas pendentes a partir do qual o usuário não pode retirar
+	// livros; 0 desativa o bloqueio.
+	LimiteBloqueio int64
+}
+
+// PoliticaMultasFromEnv lê MULTA_TAXA_DIARIA (padrão 100), MULTA_TETO (padrão 2000) e
+// MULTA_LIMITE_BLOQUEIO (padrão 1000), todos em centavos.
+func PoliticaMultasFromEnv() PoliticaMultas {
+	return PoliticaMultas{
+		TaxaDiaria:     int64(envInt("MULTA_TAXA_DIARIA", 100)),
+		Teto:           int64(envInt("MULTA_TETO", 2000)),
+		LimiteBloqueio: int64(envInt("MULTA_LIMITE_BLOQUEIO", 1000)),
+	}
+}
+
+// Calcular retorna os dias de atraso entre a data prevista e ate, contando cada dia iniciado, e o
+// valor da multa correspondente.
+func (p PoliticaMultas) Calcular(prevista, ate time.Time) (int, int64) {
+	if !ate.After(prevista) {
+		return 0, 0
+	}
+	atraso := ate.Sub(prevista)
+	dias := int(atraso / (24 * time.Hour))
+	if atraso%(24*time.Hour) != 0 {
+		dias++
+	}
+
+	valor := int64(dias) * p.TaxaDiaria
+	if p.Teto > 0 && valor > p.Teto {
+		valor = p.Teto
+	}
+	return dias, valor
+}
+
+// MultaService consulta e quita as multas por atraso.
+type MultaService struct {
+	Repo *repository.MultaRepository
+}
+
+func NewMultaService(repo *repository.MultaRepository) *MultaService {
+	return &MultaService{Repo: repo}
+}
+
+// ListarMultas retorna uma página de multas e o total encontrado.
+func (s *MultaService) ListarMultas(filter repository.MultaFilter) ([]models.Multa, int64, error) {
+	switch filter.Status {
+	case "", models.MultaPendente, models.MultaPaga:
+	default:
+		return nil, 0, ErrInvalidMultaStatus
+	}
+
+	multas, total, err := s.Repo.List(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao listar multas: %w", err)
+	}
+	return multas, total, nil
+}
+
+// PagarMulta registra o pagamento de uma multa.
+func (s *MultaService) PagarMulta(id uint) (*models.Multa, error) {
+	return s.Repo.Pagar(id, time.Now())
+}
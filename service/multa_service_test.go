@@ -0,0 +1,48 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoliticaMultasCalcular(t *testing.T) {
+	politica := PoliticaMultas{TaxaDiaria: 150, Teto: 1000}
+	prevista := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		ate   time.Time
+		dias  int
+		valor int64
+	}{
+		{"no prazo", prevista.Add(-time.Hour), 0, 0},
+		{"no vencimento", prevista, 0, 0},
+		{"dia iniciado", prevista.Add(time.Minute), 1, 150},
+		{"dias completos", prevista.Add(72 * time.Hour), 3, 450},
+		{"teto", prevista.Add(30 * 24 * time.Hour), 30, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dias, valor := politica.Calcular(prevista, tt.ate)
+			assert.Equal(t, tt.dias, dias)
+			assert.Equal(t, tt.valor, valor)
+		})
+	}
+
+	_, valor := PoliticaMultas{TaxaDiaria: 150}.Calcular(prevista, prevista.Add(30*24*time.Hour))
+	assert.Equal(t, int64(4500), valor, "sem teto")
+}
+
+func TestPoliticaMultasFromEnv(t *testing.T) {
+	t.Setenv("MULTA_TAXA_DIARIA", "250")
+	t.Setenv("MULTA_TETO", "0")
+	t.Setenv("MULTA_LIMITE_BLOQUEIO", "5000")
+	assert.Equal(t, PoliticaMultas{TaxaDiaria: 250, Teto: 0, LimiteBloqueio: 5000}, PoliticaMultasFromEnv())
+}
+
+func TestFormatMoeda(t *testing.T) {
+	assert.Equal(t, "R$ 12,05", formatMoeda("pt-BR", 1205))
+	assert.Equal(t, "R$ 0.50", formatMoeda("en", 50))
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"books_api/models"
+	"books_api/notify"
+	"books_api/repository"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// NotificacaoService guarda as notificações exibidas aos usuários no aplicativo e avisa os seguidores
+// dos autores quando um livro novo é cadastrado.
+type NotificacaoService struct {
+	Repo       *repository.NotificacaoRepository
+	Seguidores *repository.FavoritoRepository
+	// Notifier envia o e-mail aos seguidores que o pediram; se nil, só a notificação no aplicativo é criada.
+	Notifier notify.Notifier
+}
+
+func NewNotificacaoService(repo *repository.NotificacaoRepository, seguidores *repository.FavoritoRepository, notifier notify.Notifier) *NotificacaoService {
+	return &NotificacaoService{Repo: repo, Seguidores: seguidores, Notifier: notifier}
+}
+
+// NotificarNovoLivro cria uma notificação para cada seguidor do autor do livro e envia e-mail aos que
+// optaram por recebê-lo e têm o endereço confirmado. Falhas são apenas registradas no log.
+func (s *NotificacaoService) NotificarNovoLivro(livro *models.Livro) {
+	chave := models.ChaveAutor(livro.Autor)
+	if chave == "" {
+		return
+	}
+	seguidores, err := s.Seguidores.Seguidores(chave)
+	if err != nil {
+		log.Printf("Erro ao buscar seguidores do autor %q: %v", livro.Autor, err)
+		return
+	}
+	if len(seguidores) == 0 {
+		return
+	}
+
+	mensagem := fmt.Sprintf("Novo livro de %s: %s", livro.Autor, livro.Titulo)
+	notificacoes := make([]models.Notificacao, len(seguidores))
+	for i, seguidor := range seguidores {
+		livroID := livro.ID
+		notificacoes[i] = models.Notificacao{
+			UserID:   seguidor.UserID,
+			Tipo:     models.NotificacaoLivroNovo,
+			Mensagem: mensagem,
+			LivroID:  &livroID,
+		}
+	}
+	if err := s.Repo.CreateMany(notificacoes); err != nil {
+		log.Printf("Erro ao gravar notificações do livro %d: %v", livro.ID, err)
+	}
+
+	if s.Notifier == nil {
+		return
+	}
+	for _, seguidor := range seguidores {
+		if !seguidor.Email || seguidor.User.Email == "" || !seguidor.User.EmailVerified {
+			continue
+		}
+		s.enviarEmail(seguidor.User, livro)
+	}
+}
+
+// ListarNotificacoes retorna uma página das notificações do usuário, o total encontrado e quantas ainda
+// não foram lidas.
+func (s *NotificacaoService) ListarNotificacoes(filter repository.NotificacaoFilter) ([]models.Notificacao, int64, int64, error) {
+	notificacoes, total, err := s.Repo.List(filter)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("erro ao listar notificações: %w", err)
+	}
+	naoLidas, err := s.Repo.ContarNaoLidas(filter.UserID)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("erro ao contar notificações não lidas: %w", err)
+	}
+	return notificacoes, total, naoLidas, nil
+}
+
+// MarcarLida marca como lida uma notificação do usuário.
+func (s *NotificacaoService) MarcarLida(userID, id uint) (*models.Notificacao, error) {
+	return s.Repo.MarcarLida(userID, id, time.Now())
+}
+
+// MarcarTodasLidas marca como lidas todas as notificações do usuário.
+func (s *NotificacaoService) MarcarTodasLidas(userID uint) (int64, error) {
+	return s.Repo.MarcarTodasLidas(userID, time.Now())
+}
+
+func (s *NotificacaoService) enviarEmail(user models.User, livro *models.Livro) {
+	name := user.DisplayName
+	if name == "" {
+		name = user.Username
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	err := s.Notifier.Notify(ctx, notify.Notification{
+		Event:    notify.EventLivroNovo,
+		UserID:   user.ID,
+		Name:     name,
+		Email:    user.Email,
+		Language: user.Language,
+		Data: map[string]string{
+			"livro_id": strconv.FormatUint(uint64(livro.ID), 10),
+			"livro":    livro.Titulo,
+			"autor":    livro.Autor,
+		},
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("Erro ao enviar aviso do livro %d ao usuário %d: %v", livro.ID, user.ID, err)
+	}
+}
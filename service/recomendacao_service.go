@@ -0,0 +1,492 @@
+package service
+
+import (
+	"books_api/cache"
+	"books_api/models"
+	"books_api/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	recomendacaoTimeout = 2 * time.Second
+	// Pesos das interações no cálculo de coocorrência: curtir um livro vale mais que vê-lo.
+	pesoVisualizacao = 1.0
+	pesoCurtida      = 3.0
+	// maxLivrosPorUsuario limita as interações mais recentes de cada usuário consideradas no cálculo,
+	// que cresce com o quadrado dessa quantidade.
+	maxLivrosPorUsuario = 200
+	// MaxRecomendacoes é a quantidade máxima de livros retornada pelas recomendações.
+	MaxRecomendacoes = 50
+)
+
+// Similar é um livro relacionado a outro, com a pontuação de similaridade entre 0 e 1.
+type Similar struct {
+	LivroID   uint    `json:"livro_id"`
+	Pontuacao float64 `json:"pontuacao"`
+}
+
+// RecomendacaoService recomenda livros a partir da coocorrência de interações: livros vistos ou curtidos
+// pelos mesmos usuários são considerados similares. As listas de similares são recalculadas periodicamente
+// e guardadas no Redis; na falta delas, são usados livros do mesmo autor ou gênero. Com o Breaker aberto,
+// o serviço segue como se não houvesse Redis.
+type RecomendacaoService struct {
+	Repo    *repository.InteracaoRepository
+	Redis   *redis.Client
+	Breaker *cache.Breaker
+	// Janela é o período de visualizações considerado. Curtidas valem até serem removidas.
+	Janela time.Duration
+	// Intervalo é a frequência do recálculo dos similares.
+	Intervalo time.Duration
+	// Similares é a quantidade de similares guardada para cada livro.
+	Similares int
+	// CacheUsuario é por quanto tempo as recomendações de um usuário ficam guardadas.
+	CacheUsuario time.Duration
+	// IntervaloVisualizacao é o tempo mínimo entre duas visualizações registradas do mesmo livro pelo mesmo usuário.
+	IntervaloVisualizacao time.Duration
+}
+
+// NewRecomendacaoService cria o serviço lendo RECOMENDACAO_JANELA_DIAS (padrão 180),
+// RECOMENDACAO_INTERVALO (padrão 1h), RECOMENDACAO_SIMILARES (padrão 20),
+// RECOMENDACAO_CACHE_USUARIO (padrão 10m) e RECOMENDACAO_INTERVALO_VISUALIZACAO (padrão 1h).
+func NewRecomendacaoService(repo *repository.InteracaoRepository, client *redis.Client, breaker *cache.Breaker) *RecomendacaoService {
+	return &RecomendacaoService{
+		Repo:                  repo,
+		Redis:                 client,
+		Breaker:               breaker,
+		Janela:                time.Duration(envInt("RECOMENDACAO_JANELA_DIAS", 180)) * 24 * time.Hour,
+		Intervalo:             envDuration("RECOMENDACAO_INTERVALO", time.Hour),
+		Similares:             envInt("RECOMENDACAO_SIMILARES", 20),
+		CacheUsuario:          envDuration("RECOMENDACAO_CACHE_USUARIO", 10*time.Minute),
+		IntervaloVisualizacao: envDuration("RECOMENDACAO_INTERVALO_VISUALIZACAO", time.Hour),
+	}
+}
+
+// RegistrarVisualizacao registra que o usuário viu os detalhes do livro. Visualizações repetidas dentro
+// de IntervaloVisualizacao são ignoradas. Falhas são apenas registradas no log.
+func (s *RecomendacaoService) RegistrarVisualizacao(userID, livroID uint) {
+	if s == nil || userID == 0 {
+		return
+	}
+	if s.Redis != nil && s.IntervaloVisualizacao > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), recomendacaoTimeout)
+		key := "recomendacoes:visto:" + strconv.Itoa(int(userID)) + ":" + strconv.Itoa(int(livroID))
+		var nova bool
+		err := s.do(ctx, func() (err error) {
+			nova, err = s.Redis.SetNX(ctx, key, 1, s.IntervaloVisualizacao).Result()
+			return err
+		})
+		cancel()
+		if err != nil {
+			log.Printf("Erro ao verificar visualização recente: %v", err)
+		} else if !nova {
+			return
+		}
+	}
+	if err := s.Repo.RegistrarVisualizacao(userID, livroID); err != nil {
+		log.Printf("Erro ao registrar visualização do livro %d: %v", livroID, err)
+	}
+}
+
+// Curtir registra a curtida do usuário no livro.
+func (s *RecomendacaoService) Curtir(userID, livroID uint) error {
+	if err := s.Repo.Curtir(userID, livroID); err != nil {
+		return err
+	}
+	s.limparCacheUsuario(userID)
+	return nil
+}
+
+// Descurtir remove a curtida do usuário no livro.
+func (s *RecomendacaoService) Descurtir(userID, livroID uint) error {
+	if err := s.Repo.Descurtir(userID, livroID); err != nil {
+		return err
+	}
+	s.limparCacheUsuario(userID)
+	return nil
+}
+
+// LivrosSimilares retorna até limite livros similares ao informado, completando com livros do mesmo
+// autor e depois do mesmo gênero.
+func (s *RecomendacaoService) LivrosSimilares(livroID uint, limite int) ([]models.Livro, error) {
+	livro, err := s.Repo.FindLivro(livroID)
+	if err != nil {
+		return nil, err
+	}
+	limite = limiteRecomendacoes(limite)
+
+	ctx, cancel := context.WithTimeout(context.Background(), recomendacaoTimeout)
+	defer cancel()
+	similares := s.similaresEmCache(ctx, []uint{livroID})[livroID]
+
+	ids := make([]uint, 0, limite)
+	for _, similar := range similares {
+		if len(ids) == limite {
+			break
+		}
+		ids = append(ids, similar.LivroID)
+	}
+	ids, err = s.completar(ids, append([]uint{livroID}, ids...), []string{livro.Autor}, []string{livro.Genero}, limite)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.FindLivros(ids)
+}
+
+// Recomendacoes retorna até limite livros para o usuário, somando os similares dos livros com que ele
+// interagiu, ponderados pelo tipo de interação. Livros já vistos, curtidos ou lidos não são recomendados.
+// Sem histórico suficiente, completa com livros dos mesmos autores e gêneros e depois com os mais avaliados.
+func (s *RecomendacaoService) Recomendacoes(userID uint, limite int) ([]models.Livro, error) {
+	limite = limiteRecomendacoes(limite)
+	ctx, cancel := context.WithTimeout(context.Background(), recomendacaoTimeout)
+	defer cancel()
+
+	key := "recomendacoes:usuario:" + strconv.Itoa(int(userID))
+	if s.Redis != nil {
+		var data []byte
+		err := s.do(ctx, func() (err error) {
+			data, err = s.Redis.Get(ctx, key).Bytes()
+			if errors.Is(err, redis.Nil) {
+				return cache.ErrMiss
+			}
+			return err
+		})
+		if err == nil {
+			var ids []uint
+			if err := json.Unmarshal(data, &ids); err == nil {
+				if len(ids) > limite {
+					ids = ids[:limite]
+				}
+				return s.Repo.FindLivros(ids)
+			}
+		} else if !errors.Is(err, cache.ErrMiss) {
+			log.Printf("Erro ao buscar recomendações no Redis: %v", err)
+		}
+	}
+
+	interacoes, err := s.Repo.Interacoes(&userID, time.Now().Add(-s.Janela))
+	if err != nil {
+		return nil, err
+	}
+	if len(interacoes) > maxLivrosPorUsuario {
+		interacoes = interacoes[:maxLivrosPorUsuario]
+	}
+	lidos, err := s.Repo.LivrosLidos(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	excluir := append([]uint{}, lidos...)
+	origem := make([]uint, len(interacoes))
+	for i, interacao := range interacoes {
+		origem[i] = interacao.LivroID
+		excluir = append(excluir, interacao.LivroID)
+	}
+	ids := pontuarRecomendacoes(interacoes, s.similaresEmCache(ctx, origem), excluir, MaxRecomendacoes)
+
+	var autores, generos []string
+	if len(origem) > 0 {
+		livros, err := s.Repo.FindLivros(origem)
+		if err != nil {
+			return nil, err
+		}
+		for _, livro := range livros {
+			autores = append(autores, livro.Autor)
+			generos = append(generos, livro.Genero)
+		}
+	}
+	ids, err = s.completar(ids, append(excluir, ids...), autores, generos, MaxRecomendacoes)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) < MaxRecomendacoes {
+		populares, err := s.Repo.Populares(append(excluir, ids...), MaxRecomendacoes-len(ids))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, populares...)
+	}
+
+	if s.Redis != nil && s.CacheUsuario > 0 {
+		if data, err := json.Marshal(ids); err == nil {
+			err := s.do(ctx, func() error { return s.Redis.Set(ctx, key, data, s.CacheUsuario).Err() })
+			if err != nil {
+				log.Printf("Erro ao guardar recomendações no Redis: %v", err)
+			}
+		}
+	}
+	if len(ids) > limite {
+		ids = ids[:limite]
+	}
+	return s.Repo.FindLivros(ids)
+}
+
+// Atualizar recalcula os similares de todos os livros e os grava no Redis. Quando várias instâncias
+// rodam ao mesmo tempo, só a que obtiver o bloqueio faz o cálculo no intervalo. Retorna quantos livros
+// tiveram similares gravados.
+func (s *RecomendacaoService) Atualizar(ctx context.Context) (int, error) {
+	if s.Redis == nil {
+		return 0, nil
+	}
+	bloqueio := s.Intervalo / 2
+	if bloqueio <= 0 {
+		bloqueio = time.Minute
+	}
+	var obtido bool
+	err := s.do(ctx, func() (err error) {
+		obtido, err = s.Redis.SetNX(ctx, "recomendacoes:calculo", 1, bloqueio).Result()
+		return err
+	})
+	if err != nil || !obtido {
+		return 0, err
+	}
+
+	now := time.Now()
+	if _, err := s.Repo.PodarVisualizacoes(now.Add(-s.Janela)); err != nil {
+		log.Printf("Erro ao excluir visualizações antigas: %v", err)
+	}
+	interacoes, err := s.Repo.Interacoes(nil, now.Add(-s.Janela))
+	if err != nil {
+		return 0, err
+	}
+	similares := calcularSimilares(interacoes, s.Similares)
+
+	// As listas expiram se o recálculo parar de rodar, e as recomendações passam a usar só autor e gênero.
+	validade := 2 * s.Intervalo
+	if validade <= 0 {
+		validade = 24 * time.Hour
+	}
+	pipe := s.Redis.Pipeline()
+	for livroID, lista := range similares {
+		data, err := json.Marshal(lista)
+		if err != nil {
+			return 0, err
+		}
+		pipe.Set(ctx, similaresKey(livroID), data, validade)
+	}
+	err = s.do(ctx, func() error {
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(similares), nil
+}
+
+// Run recalcula os similares ao iniciar e depois a cada Intervalo, até que o contexto seja cancelado.
+func (s *RecomendacaoService) Run(ctx context.Context) {
+	if s.Intervalo <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.Intervalo)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.Atualizar(ctx)
+		if err != nil {
+			log.Printf("Erro ao calcular livros similares: %v", err)
+		} else if n > 0 {
+			log.Printf("Similares de %d livro(s) atualizados", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// completar acrescenta aos ids livros dos autores e gêneros informados até chegar a limite.
+func (s *RecomendacaoService) completar(ids, excluir []uint, autores, generos []string, limite int) ([]uint, error) {
+	if len(ids) >= limite {
+		return ids, nil
+	}
+	relacionados, err := s.Repo.Relacionados(autores, generos, excluir, limite-len(ids))
+	if err != nil {
+		return nil, err
+	}
+	return append(ids, relacionados...), nil
+}
+
+// similaresEmCache lê do Redis as listas de similares dos livros. Livros sem lista ficam fora do mapa.
+func (s *RecomendacaoService) similaresEmCache(ctx context.Context, livroIDs []uint) map[uint][]Similar {
+	resultado := make(map[uint][]Similar)
+	if s.Redis == nil || len(livroIDs) == 0 {
+		return resultado
+	}
+	keys := make([]string, len(livroIDs))
+	for i, id := range livroIDs {
+		keys[i] = similaresKey(id)
+	}
+	var valores []interface{}
+	err := s.do(ctx, func() (err error) {
+		valores, err = s.Redis.MGet(ctx, keys...).Result()
+		return err
+	})
+	if err != nil {
+		log.Printf("Erro ao buscar livros similares no Redis: %v", err)
+		return resultado
+	}
+	for i, valor := range valores {
+		data, ok := valor.(string)
+		if !ok {
+			continue
+		}
+		var lista []Similar
+		if err := json.Unmarshal([]byte(data), &lista); err != nil {
+			log.Printf("Erro ao desserializar livros similares: %v", err)
+			continue
+		}
+		resultado[livroIDs[i]] = lista
+	}
+	return resultado
+}
+
+func (s *RecomendacaoService) limparCacheUsuario(userID uint) {
+	if s.Redis == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), recomendacaoTimeout)
+	defer cancel()
+	err := s.do(ctx, func() error {
+		return s.Redis.Del(ctx, "recomendacoes:usuario:"+strconv.Itoa(int(userID))).Err()
+	})
+	if err != nil {
+		log.Printf("Erro ao limpar recomendações do usuário: %v", err)
+	}
+}
+
+// do executa a operação no Redis pelo Breaker, quando houver.
+func (s *RecomendacaoService) do(ctx context.Context, fn func() error) error {
+	if s.Breaker == nil {
+		return fn()
+	}
+	return s.Breaker.Do(ctx, fn)
+}
+
+func similaresKey(livroID uint) string {
+	return "recomendacoes:similares:" + strconv.Itoa(int(livroID))
+}
+
+func limiteRecomendacoes(limite int) int {
+	if limite <= 0 {
+		return 10
+	}
+	if limite > MaxRecomendacoes {
+		return MaxRecomendacoes
+	}
+	return limite
+}
+
+func pesoInteracao(interacao repository.InteracaoUsuario) float64 {
+	if interacao.Curtiu {
+		return pesoCurtida
+	}
+	return pesoVisualizacao
+}
+
+// calcularSimilares calcula, para cada livro, os limite livros mais similares pela similaridade de
+// cosseno entre os vetores de interações: a coocorrência ponderada de dois livros dividida pela raiz do
+// produto dos pesos totais de cada um. As interações devem vir agrupadas por usuário, das mais recentes
+// para as mais antigas.
+func calcularSimilares(interacoes []repository.InteracaoUsuario, limite int) map[uint][]Similar {
+	totais := make(map[uint]float64)
+	coocorrencias := make(map[uint]map[uint]float64)
+
+	acumular := func(livros []repository.InteracaoUsuario) {
+		if len(livros) > maxLivrosPorUsuario {
+			livros = livros[:maxLivrosPorUsuario]
+		}
+		for i, a := range livros {
+			pesoA := pesoInteracao(a)
+			totais[a.LivroID] += pesoA
+			for _, b := range livros[i+1:] {
+				peso := math.Min(pesoA, pesoInteracao(b))
+				for _, par := range [2][2]uint{{a.LivroID, b.LivroID}, {b.LivroID, a.LivroID}} {
+					if coocorrencias[par[0]] == nil {
+						coocorrencias[par[0]] = make(map[uint]float64)
+					}
+					coocorrencias[par[0]][par[1]] += peso
+				}
+			}
+		}
+	}
+
+	inicio := 0
+	for i := 1; i <= len(interacoes); i++ {
+		if i == len(interacoes) || interacoes[i].UserID != interacoes[inicio].UserID {
+			acumular(interacoes[inicio:i])
+			inicio = i
+		}
+	}
+
+	similares := make(map[uint][]Similar, len(coocorrencias))
+	for livroID, outros := range coocorrencias {
+		lista := make([]Similar, 0, len(outros))
+		for outroID, peso := range outros {
+			pontuacao := peso / math.Sqrt(totais[livroID]*totais[outroID])
+			lista = append(lista, Similar{LivroID: outroID, Pontuacao: math.Round(pontuacao*10000) / 10000})
+		}
+		ordenarSimilares(lista)
+		if limite > 0 && len(lista) > limite {
+			lista = lista[:limite]
+		}
+		similares[livroID] = lista
+	}
+	return similares
+}
+
+// pontuarRecomendacoes soma as pontuações dos similares de cada livro com que o usuário interagiu,
+// multiplicadas pelo peso da interação, e retorna até limite livros fora de excluir, do maior para o
+// menor total.
+func pontuarRecomendacoes(interacoes []repository.InteracaoUsuario, similares map[uint][]Similar, excluir []uint, limite int) []uint {
+	excluidos := make(map[uint]bool, len(excluir))
+	for _, id := range excluir {
+		excluidos[id] = true
+	}
+
+	pontuacoes := make(map[uint]float64)
+	for _, interacao := range interacoes {
+		peso := pesoInteracao(interacao)
+		for _, similar := range similares[interacao.LivroID] {
+			if !excluidos[similar.LivroID] {
+				pontuacoes[similar.LivroID] += peso * similar.Pontuacao
+			}
+		}
+	}
+
+	lista := make([]Similar, 0, len(pontuacoes))
+	for id, pontuacao := range pontuacoes {
+		lista = append(lista, Similar{LivroID: id, Pontuacao: pontuacao})
+	}
+	ordenarSimilares(lista)
+	if len(lista) > limite {
+		lista = lista[:limite]
+	}
+	ids := make([]uint, len(lista))
+	for i, similar := range lista {
+		ids[i] = similar.LivroID
+	}
+	return ids
+}
+
+// ordenarSimilares ordena da maior para a menor pontuação, desempatando pelo ID para um resultado estável.
+func ordenarSimilares(lista []Similar) {
+	sort.Slice(lista, func(i, j int) bool {
+		if lista[i].Pontuacao != lista[j].Pontuacao {
+			return lista[i].Pontuacao > lista[j].Pontuacao
+		}
+		return lista[i].LivroID < lista[j].LivroID
+	})
+}
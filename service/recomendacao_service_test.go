@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+
+	"books_api/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func interacao(userID, livroID uint, curtiu bool) repository.InteracaoUsuario {
+	return repository.InteracaoUsuario{UserID: userID, LivroID: livroID, Curtiu: curtiu}
+}
+
+func TestCalcularSimilares(t *testing.T) {
+	interacoes := []repository.InteracaoUsuario{
+		interacao(1, 10, false), interacao(1, 20, false),
+		interacao(2, 10, false), interacao(2, 20, false), interacao(2, 30, false),
+		interacao(3, 30, false),
+	}
+
+	similares := calcularSimilares(interacoes, 10)
+
+	// 10 e 20 aparecem sempre juntos; 30 aparece com eles para um só usuário.
+	assert.Equal(t, []Similar{{LivroID: 20, Pontuacao: 1}, {LivroID: 30, Pontuacao: 0.5}}, similares[10])
+	assert.Equal(t, []Similar{{LivroID: 10, Pontuacao: 0.5}, {LivroID: 20, Pontuacao: 0.5}}, similares[30])
+
+	limitados := calcularSimilares(interacoes, 1)
+	assert.Len(t, limitados[10], 1)
+	assert.Equal(t, uint(20), limitados[10][0].LivroID)
+}
+
+func TestCalcularSimilaresPesoCurtida(t *testing.T) {
+	interacoes := []repository.InteracaoUsuario{
+		interacao(1, 10, true), interacao(1, 20, true), interacao(1, 30, false),
+	}
+
+	similares := calcularSimilares(interacoes, 10)
+
+	// Dois livros curtidos pelo mesmo usuário são mais similares que um curtido e outro só visto.
+	assert.Equal(t, uint(20), similares[10][0].LivroID)
+	assert.Greater(t, similares[10][0].Pontuacao, similares[10][1].Pontuacao)
+}
+
+func TestPontuarRecomendacoes(t *testing.T) {
+	interacoes := []repository.InteracaoUsuario{interacao(1, 10, true), interacao(1, 20, false)}
+	similares := map[uint][]Similar{
+		10: {{LivroID: 20, Pontuacao: 0.9}, {LivroID: 30, Pontuacao: 0.2}, {LivroID: 40, Pontuacao: 0.1}},
+		20: {{LivroID: 40, Pontuacao: 0.5}, {LivroID: 50, Pontuacao: 0.5}},
+	}
+
+	// 40 soma as pontuações vindas dos dois livros; 30 vem de um livro curtido, que pesa mais.
+	ids := pontuarRecomendacoes(interacoes, similares, []uint{10, 20}, 10)
+	assert.Equal(t, []uint{40, 30, 50}, ids)
+
+	assert.Equal(t, []uint{40}, pontuarRecomendacoes(interacoes, similares, []uint{10, 20}, 1))
+	assert.Empty(t, pontuarRecomendacoes(nil, similares, nil, 10))
+}
+
+func TestLimiteRecomendacoes(t *testing.T) {
+	assert.Equal(t, 10, limiteRecomendacoes(0))
+	assert.Equal(t, 5, limiteRecomendacoes(5))
+	assert.Equal(t, MaxRecomendacoes, limiteRecomendacoes(1000))
+}
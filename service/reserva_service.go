@@ -0,0 +1,115 @@
+package service
+
+import (
+	"books_api/models"
+	"books_api/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidReservaStatus = errors.New("situação de reserva inválida")
+	ErrNotReservaOwner      = errors.New("reserva pertence a outro usuário")
+)
+
+// ReservaService controla a fila de espera dos livros indisponíveis.
+type ReservaService struct {
+	Repo *repository.ReservaRepository
+	// PrazoRetirada é o tempo que o usuário tem para retirar o exemplar separado para a reserva.
+	PrazoRetirada time.Duration
+	// Intervalo é a frequência com que Run procura reservas com o prazo de retirada vencido.
+	Intervalo time.Duration
+}
+
+// NewReservaService cria o serviço lendo RESERVA_PRAZO_RETIRADA_DIAS (padrão 3) e
+// RESERVA_VERIFICACAO_INTERVALO (padrão 1m).
+func NewReservaService(repo *repository.ReservaRepository) *ReservaService {
+	return &ReservaService{
+		Repo:          repo,
+		PrazoRetirada: time.Duration(envInt("RESERVA_PRAZO_RETIRADA_DIAS", 3)) * 24 * time.Hour,
+		Intervalo:     envDuration("RESERVA_VERIFICACAO_INTERVALO", time.Minute),
+	}
+}
+
+// Reservar coloca o usuário no fim da fila de espera do livro.
+func (s *ReservaService) Reservar(livroID, userID uint) (*models.Reserva, error) {
+	reserva := &models.Reserva{UserID: userID, LivroID: livroID}
+	if err := s.Repo.Create(reserva); err != nil {
+		return nil, err
+	}
+	return reserva, nil
+}
+
+// Cancelar encerra a reserva. Sem a permissão de gerenciar empréstimos, o usuário só pode cancelar
+// as próprias.
+func (s *ReservaService) Cancelar(id, userID uint, gerenciar bool) (*models.Reserva, error) {
+	reserva, err := s.Repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !gerenciar && reserva.UserID != userID {
+		return nil, ErrNotReservaOwner
+	}
+	return s.Repo.Cancelar(id, time.Now(), s.PrazoRetirada)
+}
+
+// MarcarDisponivel distribui os exemplares disponíveis do livro para a fila de espera, na ordem de
+// chegada. codigoBarras é opcional e devolve ao acervo um exemplar que estava perdido ou em reparo.
+func (s *ReservaService) MarcarDisponivel(livroID uint, codigoBarras string) ([]models.Reserva, error) {
+	atribuidas, err := s.Repo.Atribuir(livroID, strings.TrimSpace(codigoBarras), time.Now(), s.PrazoRetirada)
+	if err != nil {
+		return nil, err
+	}
+	if atribuidas == nil {
+		atribuidas = []models.Reserva{}
+	}
+	return atribuidas, nil
+}
+
+// ListarReservas retorna uma página de reservas e o total encontrado.
+func (s *ReservaService) ListarReservas(filter repository.ReservaFilter) ([]models.Reserva, int64, error) {
+	if filter.Status != "" && !models.ValidReservaStatus(filter.Status) {
+		return nil, 0, ErrInvalidReservaStatus
+	}
+	reservas, total, err := s.Repo.List(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao listar reservas: %w", err)
+	}
+	return reservas, total, nil
+}
+
+// ExpirarReservas encerra as reservas com prazo de retirada vencido e avança as filas.
+func (s *ReservaService) ExpirarReservas() (int, error) {
+	expiradas, err := s.Repo.Expirar(time.Now(), s.PrazoRetirada)
+	return len(expiradas), err
+}
+
+// Run expira periodicamente as reservas não retiradas até que o contexto seja cancelado.
+// Pode rodar em várias instâncias ao mesmo tempo, pois cada fila é processada com o livro bloqueado.
+func (s *ReservaService) Run(ctx context.Context) {
+	if s.Intervalo <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.Intervalo)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.ExpirarReservas()
+			if err != nil {
+				log.Printf("Erro ao expirar reservas: %v", err)
+			}
+			if n > 0 {
+				log.Printf("%d reserva(s) expirada(s)", n)
+			}
+		}
+	}
+}
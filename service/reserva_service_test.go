@@ -0,0 +1,170 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"books_api/models"
+	"books_api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// novoReservaService monta o serviço de reservas sobre o mesmo banco do serviço de empréstimos.
+func novoReservaService(t *testing.T) (*ReservaService, *EmprestimoService, *gorm.DB) {
+	t.Helper()
+	emprestimos, db := novoEmprestimoService(t)
+	return &ReservaService{Repo: repository.NewReservaRepository(db), PrazoRetirada: 24 * time.Hour}, emprestimos, db
+}
+
+func buscarReserva(t *testing.T, s *ReservaService, id uint) *models.Reserva {
+	t.Helper()
+	reserva, err := s.Repo.FindByID(id)
+	require.NoError(t, err)
+	return reserva
+}
+
+func TestReservarPosicaoNaFila(t *testing.T) {
+	s, emprestimos, db := novoReservaService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 1)
+	leitor, b, c := criarUsuario(t, db, "leitor"), criarUsuario(t, db, "b"), criarUsuario(t, db, "c")
+
+	// Com exemplares disponíveis e sem fila, o livro deve ser emprestado, não reservado.
+	_, err := s.Reservar(livro.ID, b.ID)
+	assert.ErrorIs(t, err, repository.ErrLivroDisponivel)
+
+	_, err = emprestimos.Emprestar(livro.ID, leitor.ID, "")
+	require.NoError(t, err)
+
+	primeira, err := s.Reservar(livro.ID, b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReservaAguardando, primeira.Status)
+	assert.Equal(t, 1, primeira.Posicao)
+	segunda, err := s.Reservar(livro.ID, c.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, segunda.Posicao)
+
+	_, err = s.Reservar(livro.ID, b.ID)
+	assert.ErrorIs(t, err, repository.ErrReservaDuplicada)
+
+	// Quem sai da fila faz os seguintes avançarem.
+	_, err = s.Cancelar(primeira.ID, b.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, buscarReserva(t, s, segunda.ID).Posicao)
+}
+
+func TestReservaExpiradaAvancaFila(t *testing.T) {
+	s, emprestimos, db := novoReservaService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 1)
+	leitor, b, c := criarUsuario(t, db, "leitor"), criarUsuario(t, db, "b"), criarUsuario(t, db, "c")
+
+	emprestimo, err := emprestimos.Emprestar(livro.ID, leitor.ID, "")
+	require.NoError(t, err)
+	primeira, err := s.Reservar(livro.ID, b.ID)
+	require.NoError(t, err)
+	segunda, err := s.Reservar(livro.ID, c.ID)
+	require.NoError(t, err)
+
+	// O exemplar devolvido é separado para o primeiro da fila, e só ele pode retirá-lo.
+	_, err = emprestimos.Devolver(emprestimo.ID, leitor.ID, false)
+	require.NoError(t, err)
+	atribuidas, err := s.MarcarDisponivel(livro.ID, "")
+	require.NoError(t, err)
+	require.Len(t, atribuidas, 1)
+	assert.Equal(t, primeira.ID, atribuidas[0].ID)
+	assert.Equal(t, models.ExemplarReservado, statusExemplar(t, db, *emprestimo.ExemplarID))
+	_, err = emprestimos.Emprestar(livro.ID, c.ID, "")
+	assert.ErrorIs(t, err, repository.ErrLivroReservado)
+
+	n, err := s.ExpirarReservas()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n, "prazo de retirada ainda não venceu")
+
+	require.NoError(t, db.Model(&models.Reserva{}).Where("id = ?", primeira.ID).
+		Update("prazo_retirada", time.Now().Add(-time.Minute)).Error)
+	n, err = s.ExpirarReservas()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	expirada := buscarReserva(t, s, primeira.ID)
+	assert.Equal(t, models.ReservaExpirada, expirada.Status)
+	assert.NotNil(t, expirada.EncerradaEm)
+
+	proxima := buscarReserva(t, s, segunda.ID)
+	assert.Equal(t, models.ReservaDisponivel, proxima.Status)
+	require.NotNil(t, proxima.ExemplarID)
+	assert.Equal(t, *emprestimo.ExemplarID, *proxima.ExemplarID)
+	require.NotNil(t, proxima.PrazoRetirada)
+	assert.WithinDuration(t, time.Now().Add(s.PrazoRetirada), *proxima.PrazoRetirada, time.Minute)
+
+	// A retirada do exemplar separado atende a reserva.
+	_, err = emprestimos.Emprestar(livro.ID, c.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.ReservaAtendida, buscarReserva(t, s, segunda.ID).Status)
+}
+
+func TestCancelarReserva(t *testing.T) {
+	s, emprestimos, db := novoReservaService(t)
+	livro := criarLivro(t, db, "Dom Casmurro", 1)
+	leitor, b, c := criarUsuario(t, db, "leitor"), criarUsuario(t, db, "b"), criarUsuario(t, db, "c")
+
+	emprestimo, err := emprestimos.Emprestar(livro.ID, leitor.ID, "")
+	require.NoError(t, err)
+	reserva, err := s.Reservar(livro.ID, b.ID)
+	require.NoError(t, err)
+	_, err = emprestimos.Devolver(emprestimo.ID, leitor.ID, false)
+	require.NoError(t, err)
+	_, err = s.MarcarDisponivel(livro.ID, "")
+	require.NoError(t, err)
+
+	_, err = s.Cancelar(reserva.ID, c.ID, false)
+	assert.ErrorIs(t, err, ErrNotReservaOwner)
+
+	cancelada, err := s.Cancelar(reserva.ID, b.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReservaCancelada, cancelada.Status)
+	assert.NotNil(t, cancelada.EncerradaEm)
+	// Sem ninguém na fila, o exemplar separado volta ao acervo.
+	assert.Equal(t, models.ExemplarDisponivel, statusExemplar(t, db, *emprestimo.ExemplarID))
+
+	_, err = s.Cancelar(reserva.ID, b.ID, false)
+	assert.ErrorIs(t, err, repository.ErrReservaEncerrada)
+	_, err = s.Cancelar(9999, b.ID, true)
+	assert.ErrorIs(t, err, repository.ErrReservaNotFound)
+}
+
+func TestExemplarReservado(t *testing.T) {
+	s, emprestimos, db := novoReservaService(t)
+	exemplares := NewExemplarService(repository.NewExemplarRepository(db), repository.NewInventarioRepository(db))
+	livro := criarLivro(t, db, "Dom Casmurro", 1)
+	leitor, b := criarUsuario(t, db, "leitor"), criarUsuario(t, db, "b")
+
+	emprestimo, err := emprestimos.Emprestar(livro.ID, leitor.ID, "")
+	require.NoError(t, err)
+	reserva, err := s.Reservar(livro.ID, b.ID)
+	require.NoError(t, err)
+	_, err = emprestimos.Devolver(emprestimo.ID, leitor.ID, false)
+	require.NoError(t, err)
+	_, err = s.MarcarDisponivel(livro.ID, "")
+	require.NoError(t, err)
+	exemplarID := *emprestimo.ExemplarID
+
+	disponibilidade, err := exemplares.Exemplares.Disponibilidade([]uint{livro.ID})
+	require.NoError(t, err)
+	assert.Equal(t, models.Disponibilidade{Total: 1, Reservados: 1}, *disponibilidade[livro.ID])
+
+	// O exemplar separado só sai da reserva pela retirada ou expiração.
+	for _, status := range []string{models.ExemplarDisponivel, models.ExemplarPerdido} {
+		_, err = exemplares.AtualizarExemplar(exemplarID, "", "", status)
+		assert.ErrorIs(t, err, repository.ErrExemplarReservado, status)
+	}
+	atualizado, err := exemplares.AtualizarExemplar(exemplarID, "capa rasgada", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.ExemplarReservado, atualizado.Status)
+
+	_, err = emprestimos.Emprestar(livro.ID, b.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.ReservaAtendida, buscarReserva(t, s, reserva.ID).Status)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"books_api/models"
+	"books_api/repository"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidRole      = errors.New("papel de usuário inválido")
+	ErrCannotModifySelf = errors.New("não é permitido alterar a própria conta por esta rota")
+)
+
+// UserService reúne as operações de administração de usuários.
+type UserService struct {
+	UserRepo *repository.UserRepository
+}
+
+func NewUserService(userRepo *repository.UserRepository) *UserService {
+	return &UserService{UserRepo: userRepo}
+}
+
+// ListarUsuarios retorna uma página de usuários e o total encontrado.
+func (s *UserService) ListarUsuarios(filter repository.UserFilter) ([]models.User, int64, error) {
+	users, total, err := s.UserRepo.List(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao listar usuários: %w", err)
+	}
+	return users, total, nil
+}
+
+// BuscarUsuario retorna um usuário pelo ID.
+func (s *UserService) BuscarUsuario(id uint) (*models.User, error) {
+	return s.UserRepo.FindByID(id)
+}
+
+// DefinirAtivo ativa ou desativa a conta de outro usuário.
+func (s *UserService) DefinirAtivo(adminID, id uint, ativo bool) error {
+	if adminID == id {
+		return ErrCannotModifySelf
+	}
+	return s.UserRepo.SetDisabled(id, !ativo)
+}
+
+// AlterarPapel altera o papel de outro usuário.
+func (s *UserService) AlterarPapel(adminID, id uint, role models.Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+	if adminID == id {
+		return ErrCannotModifySelf
+	}
+	return s.UserRepo.UpdateRole(id, role)
+}
+
+// DeletarUsuario remove a conta de outro usuário.
+func (s *UserService) DeletarUsuario(adminID, id uint) error {
+	if adminID == id {
+		return ErrCannotModifySelf
+	}
+	return s.UserRepo.Delete(id)
+}
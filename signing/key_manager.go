@@ -0,0 +1,391 @@
+// Package signing gerencia as chaves usadas para assinar e validar os tokens JWT emitidos pela API.
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"books_api/models"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Algoritmos de assinatura suportados.
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+	AlgHS256 = "HS256"
+)
+
+const rsaKeyBits = 2048
+
+var (
+	ErrUnknownKey       = errors.New("chave de assinatura desconhecida")
+	ErrInvalidIssuer    = errors.New("emissor do token inválido")
+	ErrInvalidAudience  = errors.New("audiência do token inválida")
+	ErrSecretNotDefined = errors.New("JWT_SECRET não configurado")
+)
+
+// Store persiste as chaves de assinatura, permitindo que várias instâncias da API compartilhem as mesmas chaves.
+type Store interface {
+	List() ([]models.SigningKey, error)
+	Rotate(key *models.SigningKey, retiredAt, expiresAt time.Time) error
+	DeleteExpired() error
+}
+
+// Config define o algoritmo, as claims de emissor/audiência e a política de rotação.
+type Config struct {
+	Algorithm string
+	Issuer    string
+	Audience  string
+	// Secret é usado apenas com HS256.
+	Secret string
+	// RotationInterval é a idade a partir da qual a chave ativa é substituída.
+	RotationInterval time.Duration
+	// Overlap é por quanto tempo uma chave substituída continua válida para verificação.
+	// Deve ser maior que a validade dos tokens.
+	Overlap time.Duration
+	// RefreshInterval é a frequência com que as chaves são recarregadas do Store.
+	RefreshInterval time.Duration
+	// UnknownKeyReload é o intervalo mínimo entre as recargas do Store provocadas por tokens
+	// com kid desconhecido, que podem ter sido assinados por uma chave criada em outra instância.
+	UnknownKeyReload time.Duration
+}
+
+// ConfigFromEnv lê a configuração de JWT_SIGNING_ALG, JWT_ISSUER, JWT_AUDIENCE, JWT_SECRET,
+// JWT_KEY_ROTATION, JWT_KEY_OVERLAP, JWT_KEY_REFRESH e JWT_KEY_UNKNOWN_RELOAD.
+func ConfigFromEnv() Config {
+	return Config{
+		Algorithm:        envString("JWT_SIGNING_ALG", AlgRS256),
+		Issuer:           envString("JWT_ISSUER", "books_api"),
+		Audience:         envString("JWT_AUDIENCE", "books_api"),
+		Secret:           os.Getenv("JWT_SECRET"),
+		RotationInterval: envDuration("JWT_KEY_ROTATION", 30*24*time.Hour),
+		Overlap:          envDuration("JWT_KEY_OVERLAP", 48*time.Hour),
+		RefreshInterval:  envDuration("JWT_KEY_REFRESH", 5*time.Minute),
+		UnknownKeyReload: envDuration("JWT_KEY_UNKNOWN_RELOAD", 10*time.Second),
+	}
+}
+
+// key é uma chave carregada em memória.
+type key struct {
+	id        string
+	alg       string
+	signer    crypto.Signer
+	createdAt time.Time
+	retired   bool
+}
+
+// KeyManager assina tokens com a chave ativa e valida tokens com qualquer chave ainda publicada.
+type KeyManager struct {
+	cfg   Config
+	store Store
+
+	mu     sync.RWMutex
+	keys   map[string]*key
+	active *key
+
+	// missMu serializa as recargas por kid desconhecido; lastMissReload é a última delas.
+	missMu         sync.Mutex
+	lastMissReload time.Time
+}
+
+// NewKeyManager cria o gerenciador de chaves e garante que exista uma chave ativa.
+func NewKeyManager(cfg Config, store Store) (*KeyManager, error) {
+	switch cfg.Algorithm {
+	case AlgRS256, AlgEdDSA:
+	case AlgHS256:
+		if cfg.Secret == "" {
+			return nil, ErrSecretNotDefined
+		}
+	default:
+		return nil, fmt.Errorf("algoritmo de assinatura não suportado: %s", cfg.Algorithm)
+	}
+
+	m := &KeyManager{cfg: cfg, store: store, keys: make(map[string]*key)}
+	if err := m.RotateIfNeeded(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Sign assina as claims com a chave ativa, incluindo emissor, audiência e o kid no cabeçalho.
+func (m *KeyManager) Sign(claims jwt.MapClaims) (string, error) {
+	claims["iss"] = m.cfg.Issuer
+	claims["aud"] = m.cfg.Audience
+
+	if m.cfg.Algorithm == AlgHS256 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(m.cfg.Secret))
+	}
+
+	m.mu.RLock()
+	active := m.active
+	m.mu.RUnlock()
+	if active == nil {
+		return "", ErrUnknownKey
+	}
+
+	token := jwt.NewWithClaims(signingMethod(active.alg), claims)
+	token.Header["kid"] = active.id
+	return token.SignedString(active.signer)
+}
+
+// Parse valida assinatura, validade, emissor e audiência do token e preenche as claims informadas.
+func (m *KeyManager) Parse(tokenString string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, m.keyfunc)
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return errors.New("token inválido")
+	}
+
+	verifier, ok := claims.(interface {
+		VerifyIssuer(cmp string, req bool) bool
+		VerifyAudience(cmp string, req bool) bool
+	})
+	if !ok {
+		return errors.New("claims sem suporte à verificação de emissor e audiência")
+	}
+	if !verifier.VerifyIssuer(m.cfg.Issuer, true) {
+		return ErrInvalidIssuer
+	}
+	if !verifier.VerifyAudience(m.cfg.Audience, true) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+// keyfunc escolhe a chave de verificação pelo kid do cabeçalho, exigindo o algoritmo configurado.
+func (m *KeyManager) keyfunc(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() != m.cfg.Algorithm {
+		return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
+	}
+	if m.cfg.Algorithm == AlgHS256 {
+		return []byte(m.cfg.Secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	k, ok := m.lookup(kid)
+	if !ok {
+		k, ok = m.reloadForUnknownKey(kid)
+	}
+	if !ok || k.alg != m.cfg.Algorithm {
+		return nil, ErrUnknownKey
+	}
+	return k.signer.Public(), nil
+}
+
+func (m *KeyManager) lookup(kid string) (*key, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	k, ok := m.keys[kid]
+	return k, ok
+}
+
+// reloadForUnknownKey recarrega as chaves do Store ao receber um kid desconhecido, no máximo uma
+// vez a cada UnknownKeyReload, para aceitar tokens de chaves criadas por outras instâncias antes
+// da próxima atualização periódica sem deixar que kids inventados sobrecarreguem o banco.
+func (m *KeyManager) reloadForUnknownKey(kid string) (*key, bool) {
+	if kid == "" {
+		return nil, false
+	}
+
+	m.missMu.Lock()
+	defer m.missMu.Unlock()
+
+	// Outra requisição pode ter recarregado as chaves enquanto esta esperava.
+	if k, ok := m.lookup(kid); ok {
+		return k, true
+	}
+	if !m.lastMissReload.IsZero() && time.Since(m.lastMissReload) < m.cfg.UnknownKeyReload {
+		return nil, false
+	}
+
+	m.lastMissReload = time.Now()
+	if err := m.reload(); err != nil {
+		log.Printf("Erro ao recarregar chaves de assinatura: %v", err)
+		return nil, false
+	}
+	return m.lookup(kid)
+}
+
+// RotateIfNeeded recarrega as chaves do Store, remove as expiradas e cria uma nova chave ativa
+// se não houver nenhuma ou se a atual tiver ultrapassado o intervalo de rotação.
+func (m *KeyManager) RotateIfNeeded() error {
+	if m.cfg.Algorithm == AlgHS256 {
+		return nil
+	}
+
+	if err := m.store.DeleteExpired(); err != nil {
+		return fmt.Errorf("erro ao remover chaves expiradas: %w", err)
+	}
+	if err := m.reload(); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	active := m.active
+	m.mu.RUnlock()
+	if active != nil && time.Since(active.createdAt) < m.cfg.RotationInterval {
+		return nil
+	}
+
+	return m.Rotate()
+}
+
+// Rotate cria imediatamente uma nova chave ativa. A chave anterior continua publicada durante o Overlap.
+func (m *KeyManager) Rotate() error {
+	stored, err := generateKey(m.cfg.Algorithm)
+	if err != nil {
+		return fmt.Errorf("erro ao gerar chave de assinatura: %w", err)
+	}
+
+	now := time.Now()
+	if err := m.store.Rotate(stored, now, now.Add(m.cfg.Overlap)); err != nil {
+		return fmt.Errorf("erro ao salvar chave de assinatura: %w", err)
+	}
+	log.Printf("Nova chave de assinatura %s (%s) ativada", stored.ID, stored.Algorithm)
+	return m.reload()
+}
+
+// Run mantém as chaves atualizadas até o contexto ser cancelado, recarregando-as do Store
+// e executando a rotação agendada.
+func (m *KeyManager) Run(ctx context.Context) {
+	if m.cfg.Algorithm == AlgHS256 || m.cfg.RefreshInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(m.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.RotateIfNeeded(); err != nil {
+				log.Printf("Erro na rotação de chaves de assinatura: %v", err)
+			}
+		}
+	}
+}
+
+// reload substitui as chaves em memória pelas chaves do Store.
+func (m *KeyManager) reload() error {
+	stored, err := m.store.List()
+	if err != nil {
+		return fmt.Errorf("erro ao carregar chaves de assinatura: %w", err)
+	}
+
+	keys := make(map[string]*key, len(stored))
+	var active *key
+	for _, s := range stored {
+		signer, err := decodePrivateKey(s.PrivateKey)
+		if err != nil {
+			log.Printf("Chave de assinatura %s ignorada: %v", s.ID, err)
+			continue
+		}
+		k := &key{id: s.ID, alg: s.Algorithm, signer: signer, createdAt: s.CreatedAt, retired: s.RetiredAt != nil}
+		keys[k.id] = k
+
+		if !k.retired && k.alg == m.cfg.Algorithm && (active == nil || k.createdAt.After(active.createdAt)) {
+			active = k
+		}
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.active = active
+	m.mu.Unlock()
+	return nil
+}
+
+// generateKey cria uma chave privada do algoritmo informado, codificada em PEM (PKCS#8).
+func generateKey(alg string) (*models.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("algoritmo de assinatura não suportado: %s", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	return &models.SigningKey{
+		ID:         base64.RawURLEncoding.EncodeToString(id),
+		Algorithm:  alg,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+func decodePrivateKey(data string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("PEM inválido")
+	}
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("tipo de chave não suportado")
+	}
+	return signer, nil
+}
+
+func signingMethod(alg string) jwt.SigningMethod {
+	if alg == AlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+func envString(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+func envDuration(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Valor inválido para %s: %q, usando %s", name, value, def)
+		return def
+	}
+	return d
+}
@@ -0,0 +1,202 @@
+package signing
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"books_api/models"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore keeps signing keys in memory, standing in for the database.
+type memoryStore struct {
+	mu   sync.Mutex
+	keys []models.SigningKey
+}
+
+func (s *memoryStore) List() ([]models.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []models.SigningKey
+	for _, k := range s.keys {
+		if k.ExpiresAt == nil || k.ExpiresAt.After(time.Now()) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (s *memoryStore) Rotate(key *models.SigningKey, retiredAt, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.keys {
+		if s.keys[i].RetiredAt == nil {
+			s.keys[i].RetiredAt = &retiredAt
+			s.keys[i].ExpiresAt = &expiresAt
+		}
+	}
+	s.keys = append(s.keys, *key)
+	return nil
+}
+
+func (s *memoryStore) DeleteExpired() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.keys[:0]
+	for _, k := range s.keys {
+		if k.ExpiresAt == nil || k.ExpiresAt.After(time.Now()) {
+			kept = append(kept, k)
+		}
+	}
+	s.keys = kept
+	return nil
+}
+
+func testConfig(alg string) Config {
+	return Config{
+		Algorithm:        alg,
+		Issuer:           "books_api",
+		Audience:         "books_api",
+		RotationInterval: time.Hour,
+		Overlap:          time.Hour,
+	}
+}
+
+func newClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "1", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func TestSignAndParse(t *testing.T) {
+	for _, alg := range []string{AlgRS256, AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			m, err := NewKeyManager(testConfig(alg), &memoryStore{})
+			require.NoError(t, err)
+
+			token, err := m.Sign(newClaims())
+			require.NoError(t, err)
+
+			claims := &jwt.RegisteredClaims{}
+			require.NoError(t, m.Parse(token, claims))
+			assert.Equal(t, "books_api", claims.Issuer)
+
+			jwks := m.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, alg, jwks.Keys[0].Alg)
+			assert.Equal(t, "sig", jwks.Keys[0].Use)
+		})
+	}
+}
+
+func TestRotationKeepsPreviousKeyDuringOverlap(t *testing.T) {
+	store := &memoryStore{}
+	m, err := NewKeyManager(testConfig(AlgRS256), store)
+	require.NoError(t, err)
+
+	oldToken, err := m.Sign(newClaims())
+	require.NoError(t, err)
+
+	require.NoError(t, m.Rotate())
+	newToken, err := m.Sign(newClaims())
+	require.NoError(t, err)
+
+	assert.NoError(t, m.Parse(oldToken, &jwt.RegisteredClaims{}))
+	assert.NoError(t, m.Parse(newToken, &jwt.RegisteredClaims{}))
+	assert.Len(t, m.JWKS().Keys, 2)
+
+	// Once the overlap ends, the retired key is no longer published or accepted.
+	past := time.Now().Add(-time.Second)
+	store.keys[0].ExpiresAt = &past
+	require.NoError(t, m.RotateIfNeeded())
+
+	assert.Error(t, m.Parse(oldToken, &jwt.RegisteredClaims{}))
+	assert.NoError(t, m.Parse(newToken, &jwt.RegisteredClaims{}))
+	assert.Len(t, m.JWKS().Keys, 1)
+}
+
+func TestInstancesShareKeysThroughStore(t *testing.T) {
+	store := &memoryStore{}
+	a, err := NewKeyManager(testConfig(AlgEdDSA), store)
+	require.NoError(t, err)
+	b, err := NewKeyManager(testConfig(AlgEdDSA), store)
+	require.NoError(t, err)
+
+	token, err := a.Sign(newClaims())
+	require.NoError(t, err)
+	assert.NoError(t, b.Parse(token, &jwt.RegisteredClaims{}))
+	assert.Len(t, store.keys, 1)
+}
+
+func TestUnknownKeyReloadsFromStore(t *testing.T) {
+	store := &memoryStore{}
+	cfg := testConfig(AlgRS256)
+	cfg.UnknownKeyReload = time.Hour
+	a, err := NewKeyManager(cfg, store)
+	require.NoError(t, err)
+	b, err := NewKeyManager(cfg, store)
+	require.NoError(t, err)
+
+	// b only learns about the key rotated by a when it sees a token signed with it.
+	require.NoError(t, a.Rotate())
+	token, err := a.Sign(newClaims())
+	require.NoError(t, err)
+	assert.NoError(t, b.Parse(token, &jwt.RegisteredClaims{}))
+
+	// Further misses within the interval are rejected without hitting the store again.
+	require.NoError(t, a.Rotate())
+	token, err = a.Sign(newClaims())
+	require.NoError(t, err)
+	assert.ErrorIs(t, b.Parse(token, &jwt.RegisteredClaims{}), ErrUnknownKey)
+
+	b.lastMissReload = time.Now().Add(-time.Hour)
+	assert.NoError(t, b.Parse(token, &jwt.RegisteredClaims{}))
+}
+
+func TestParseRejectsWrongIssuerAudienceAndAlgorithm(t *testing.T) {
+	store := &memoryStore{}
+	m, err := NewKeyManager(testConfig(AlgRS256), store)
+	require.NoError(t, err)
+
+	other := testConfig(AlgRS256)
+	other.Issuer = "outro"
+	wrongIssuer := &KeyManager{cfg: other, store: store, keys: m.keys, active: m.active}
+	token, err := wrongIssuer.Sign(newClaims())
+	require.NoError(t, err)
+	assert.ErrorIs(t, m.Parse(token, &jwt.RegisteredClaims{}), ErrInvalidIssuer)
+
+	other = testConfig(AlgRS256)
+	other.Audience = "outro"
+	wrongAudience := &KeyManager{cfg: other, store: store, keys: m.keys, active: m.active}
+	token, err = wrongAudience.Sign(newClaims())
+	require.NoError(t, err)
+	assert.ErrorIs(t, m.Parse(token, &jwt.RegisteredClaims{}), ErrInvalidAudience)
+
+	// An HMAC token must not be accepted when asymmetric signing is configured.
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iss": "books_api", "aud": "books_api"})
+	token, err = hmac.SignedString([]byte("segredo"))
+	require.NoError(t, err)
+	assert.Error(t, m.Parse(token, &jwt.RegisteredClaims{}))
+}
+
+func TestHS256(t *testing.T) {
+	cfg := testConfig(AlgHS256)
+	_, err := NewKeyManager(cfg, &memoryStore{})
+	assert.ErrorIs(t, err, ErrSecretNotDefined)
+
+	cfg.Secret = "segredo"
+	m, err := NewKeyManager(cfg, &memoryStore{})
+	require.NoError(t, err)
+
+	token, err := m.Sign(newClaims())
+	require.NoError(t, err)
+	assert.NoError(t, m.Parse(token, &jwt.RegisteredClaims{}))
+	assert.Empty(t, m.JWKS().Keys)
+}